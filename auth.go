@@ -143,6 +143,8 @@ func generateRandomString(length int) string {
 //     a timing side-channel that could be exploited.
 //
 // 2. Authentication Flow:
+//   - Requests using a scenario with token_expiry_after are checked by
+//     checkExpiringToken instead
 //   - If authentication is disabled, requests pass through immediately
 //   - Extracts credentials from Authorization: Basic <base64> header
 //   - Validates both username and password using constant-time comparison
@@ -184,9 +186,18 @@ func generateRandomString(length int) string {
 //   - Consider implementing rate limiting to prevent brute force attacks
 func basicAuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		// Scenarios with token_expiry_after treat the Authorization header as
+		// an expiring token (see checkExpiringToken). With -auth this only
+		// happens after the credentials have been validated.
+		scenarioType, after := tokenExpiryScenario(r)
+
 		// If authentication is disabled globally, bypass all checks
 		// This allows the server to run in open mode for development
 		if !*enableAuth {
+			if after > 0 {
+				checkExpiringToken(w, r, next, scenarioType, clientIP(r), after)
+				return
+			}
 			next(w, r)
 			return
 		}
@@ -204,7 +215,7 @@ func basicAuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
 			// Set WWW-Authenticate header to inform client about required auth method
 			// The "realm" parameter is a human-readable string describing the protected area
 			w.Header().Set("WWW-Authenticate", `Basic realm="Restricted"`)
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			writeErrorResponse(w, r, http.StatusUnauthorized, errCodeUnauthorized, "Unauthorized")
 			return
 		}
 
@@ -226,13 +237,18 @@ func basicAuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
 			// This prevents username enumeration by ensuring identical responses
 			// for "no credentials" and "wrong credentials" scenarios
 			w.Header().Set("WWW-Authenticate", `Basic realm="Restricted"`)
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			writeErrorResponse(w, r, http.StatusUnauthorized, errCodeUnauthorized, "Unauthorized")
 			return
 		}
 
 		// Authentication successful - proceed to the next handler
 		// At this point, we can be confident that the request is from an
-		// authenticated user with valid credentials
+		// authenticated user with valid credentials. Expiring credentials
+		// are tracked per user rather than per client IP.
+		if after > 0 {
+			checkExpiringToken(w, r, next, scenarioType, "user:"+user, after)
+			return
+		}
 		next(w, r)
 	}
 }
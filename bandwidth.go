@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// bandwidthUnits are the accepted bandwidth suffixes in bytes per second,
+// longest first so "MBps" is not read as "Bps". Units are decimal like
+// network link speeds.
+var bandwidthUnits = []struct {
+	suffix string
+	bytes  float64
+}{
+	{"gbps", 1e9},
+	{"mbps", 1e6},
+	{"kbps", 1e3},
+	{"bps", 1},
+}
+
+// parseBandwidth parses a bandwidth such as "1MBps", "500KBps" or a plain
+// number of bytes per second. Note that the units are bytes, not bits.
+func parseBandwidth(val string) (int64, error) {
+	number, multiplier := strings.ToLower(strings.TrimSpace(val)), 1.0
+	for _, unit := range bandwidthUnits {
+		if strings.HasSuffix(number, unit.suffix) {
+			number, multiplier = strings.TrimSpace(strings.TrimSuffix(number, unit.suffix)), unit.bytes
+			break
+		}
+	}
+	parsed, err := strconv.ParseFloat(number, 64)
+	rate := int64(parsed * multiplier)
+	if err != nil || parsed <= 0 || rate < 1 {
+		return 0, fmt.Errorf("bandwidth must be a positive rate in bytes per second (e.g. 1MBps, 500KBps, 2048)")
+	}
+	return rate, nil
+}
+
+// getBandwidth returns the bandwidth parameter in bytes per second, or 0 if
+// the output is not throttled
+func getBandwidth(r *http.Request) (int64, error) {
+	val := r.URL.Query().Get("bandwidth")
+	if val == "" {
+		return 0, nil
+	}
+	return parseBandwidth(val)
+}
+
+// throttledWriter paces writes to at most rate bytes per second using a
+// token bucket over the bytes written. The bucket holds a tenth of a second
+// worth of bytes, so short bursts are smoothed without exceeding the rate.
+type throttledWriter struct {
+	ctx    context.Context
+	w      io.Writer
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// newThrottledWriter creates a throttledWriter writing to w. Waits end with
+// the context's error once ctx is done.
+func newThrottledWriter(ctx context.Context, w io.Writer, rate int64) *throttledWriter {
+	burst := max(float64(rate)/10, 1)
+	return &throttledWriter{ctx: ctx, w: w, rate: float64(rate), burst: burst, tokens: burst, last: time.Now()}
+}
+
+// Write writes p in pieces of at most the bucket size, waiting for tokens
+// before each piece
+func (t *throttledWriter) Write(p []byte) (int, error) {
+	written := 0
+	for written < len(p) {
+		n := min(len(p)-written, int(t.burst))
+
+		now := time.Now()
+		t.tokens = min(t.tokens+now.Sub(t.last).Seconds()*t.rate, t.burst)
+		t.last = now
+		if missing := float64(n) - t.tokens; missing > 0 {
+			if err := sleepContext(t.ctx, time.Duration(missing/t.rate*float64(time.Second))); err != nil {
+				return written, err
+			}
+			t.tokens, t.last = float64(n), time.Now()
+		}
+		t.tokens -= float64(n)
+
+		m, err := t.w.Write(p[written : written+n])
+		written += m
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
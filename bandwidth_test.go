@@ -0,0 +1,87 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseBandwidth(t *testing.T) {
+	tests := []struct {
+		value       string
+		expected    int64
+		expectError bool
+	}{
+		{"2048", 2048, false},
+		{"100Bps", 100, false},
+		{"500KBps", 500000, false},
+		{"1MBps", 1000000, false},
+		{"1.5mbps", 1500000, false},
+		{"1GBps", 1000000000, false},
+		{"0", 0, true},
+		{"-1KBps", 0, true},
+		{"0.5", 0, true},
+		{"fast", 0, true},
+		{"1Mbit", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseBandwidth(tt.value)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error, got %d", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("Expected %d bytes per second, got %d", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestStreamingPayloadHandler_Bandwidth checks that a stream with a low
+// bandwidth cap takes as long as its size requires at that rate
+func TestStreamingPayloadHandler_Bandwidth(t *testing.T) {
+	originalAuth := *enableAuth
+	*enableAuth = false
+	defer func() { *enableAuth = originalAuth }()
+
+	server := httptest.NewServer(http.HandlerFunc(StreamingPayloadHandler))
+	defer server.Close()
+
+	fetch := func(query string) (int, time.Duration) {
+		start := time.Now()
+		resp, err := http.Get(server.URL + "/stream_payload?count=50&delay=0s&fixed_timestamp=2024-01-01T00:00:00Z" + query)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("Failed to read body: %v", err)
+		}
+		return len(body), time.Since(start)
+	}
+
+	size, unthrottled := fetch("")
+	throttledSize, throttled := fetch("&bandwidth=10KBps")
+	if throttledSize != size {
+		t.Fatalf("Expected the same body with and without bandwidth, got %d and %d bytes", size, throttledSize)
+	}
+
+	// The bucket starts with a tenth of a second worth of bytes
+	expected := time.Duration(float64(size-1000) / 10000 * float64(time.Second))
+	if throttled < expected {
+		t.Errorf("Expected %d bytes at 10KBps to take at least %v, took %v", size, expected, throttled)
+	}
+	if throttled > 2*expected+unthrottled+time.Second {
+		t.Errorf("Expected %d bytes at 10KBps to take about %v, took %v", size, expected, throttled)
+	}
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// BatchPlugin implements PayloadPlugin for submitting several requests in one call
+type BatchPlugin struct{}
+
+// Path returns the HTTP path for the batch endpoint
+func (p BatchPlugin) Path() string {
+	return "/batch"
+}
+
+// Handler returns the handler function for the batch endpoint
+func (p BatchPlugin) Handler() http.HandlerFunc {
+	return BatchHandler
+}
+
+func init() {
+	registerPlugin(BatchPlugin{})
+}
+
+// maxBatchRequests bounds the number of sub-requests in a batch
+const maxBatchRequests = 20
+
+// maxBatchRequestBytes bounds the size of the batch request body
+const maxBatchRequestBytes = 1 << 20
+
+// maxBatchResponseBytes bounds the size of all sub-response bodies of a
+// batch together, since they are buffered in memory
+const maxBatchResponseBytes = 10 << 20
+
+// errBatchResponseTooLarge stops a sub-request whose body exceeds what is
+// left of maxBatchResponseBytes
+var errBatchResponseTooLarge = errors.New("batch response too large")
+
+// batchRecorder captures a sub-response, failing writes beyond the bytes
+// left in the batch
+type batchRecorder struct {
+	header    http.Header
+	status    int
+	body      bytes.Buffer
+	remaining *int
+	truncated bool
+}
+
+func (b *batchRecorder) Header() http.Header {
+	return b.header
+}
+
+func (b *batchRecorder) WriteHeader(status int) {
+	if b.status == 0 {
+		b.status = status
+	}
+}
+
+func (b *batchRecorder) Write(p []byte) (int, error) {
+	b.WriteHeader(http.StatusOK)
+	if len(p) > *b.remaining {
+		b.truncated = true
+		return 0, errBatchResponseTooLarge
+	}
+	*b.remaining -= len(p)
+	return b.body.Write(p)
+}
+
+// Flush is a no-op; the sub-response is sent as part of the batch
+func (b *batchRecorder) Flush() {}
+
+// batchMux dispatches sub-requests, the server's mux with all endpoints
+var batchMux http.Handler = serverMux
+
+// BatchRequest is a single sub-request of a batch
+type BatchRequest struct {
+	ID     string `json:"id,omitempty"`     // Optional, echoed in the sub-response
+	Method string `json:"method,omitempty"` // Default: GET
+	URL    string `json:"url"`              // Path and query, e.g. /rest_payload?count=5
+}
+
+// BatchResponse is the outcome of a single sub-request
+type BatchResponse struct {
+	ID          string          `json:"id,omitempty"`
+	Method      string          `json:"method"`
+	URL         string          `json:"url"`
+	Status      int             `json:"status"`
+	ContentType string          `json:"content_type,omitempty"`
+	Body        json.RawMessage `json:"body"` // JSON bodies are embedded as-is, others as a JSON string
+}
+
+// BatchHandler accepts a JSON array of sub-requests, dispatches each against
+// the server's endpoints in order and returns an array with the status and
+// body of every sub-request, like the ServiceNow Batch API. Sub-requests
+// inherit the Authorization header of the batch request and pass through the
+// middleware of their endpoint, but their bodies are buffered, so options
+// that shape the transfer (transfer, proto=1.0, tiny_chunks, bandwidth) have
+// no visible effect. Only POST is accepted; batches cannot be nested and
+// cannot contain long-lived endpoints such as /stream_payload. Sub-response
+// bodies may take up to maxBatchResponseBytes together.
+func BatchHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeErrorResponse(w, r, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed, use POST")
+		return
+	}
+
+	var requests []BatchRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBatchRequestBytes)).Decode(&requests); err != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, errCodeBadRequest, fmt.Sprintf("Invalid batch request, expected a JSON array of {method,url}: %v", err))
+		return
+	}
+	if len(requests) == 0 || len(requests) > maxBatchRequests {
+		writeErrorResponse(w, r, http.StatusBadRequest, errCodeBadRequest, fmt.Sprintf("A batch must contain between 1 and %d requests", maxBatchRequests))
+		return
+	}
+	for i, sub := range requests {
+		if err := validateBatchRequest(sub); err != nil {
+			writeErrorResponse(w, r, http.StatusBadRequest, errCodeBadRequest, fmt.Sprintf("Request #%d: %v", i+1, err))
+			return
+		}
+	}
+
+	responses := make([]BatchResponse, 0, len(requests))
+	remaining := maxBatchResponseBytes
+	for _, sub := range requests {
+		if r.Context().Err() != nil {
+			return
+		}
+		responses = append(responses, dispatchBatchRequest(r, sub, &remaining))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(responses); err != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, errCodeInternal, "Failed to encode response")
+	}
+}
+
+// validateBatchRequest checks that a sub-request targets a local endpoint
+func validateBatchRequest(sub BatchRequest) error {
+	if !strings.HasPrefix(sub.URL, "/") || strings.HasPrefix(sub.URL, "//") {
+		return fmt.Errorf("url must be a path on this server, got %q", sub.URL)
+	}
+	path, _, _ := strings.Cut(sub.URL, "?")
+	if path == "/batch" {
+		return fmt.Errorf("batches cannot be nested")
+	}
+	if longLivedPaths[path] {
+		return fmt.Errorf("%s is long-lived and cannot be batched", path)
+	}
+	switch strings.ToUpper(sub.Method) {
+	case "", http.MethodGet, http.MethodPost, http.MethodDelete:
+		return nil
+	default:
+		return fmt.Errorf("method must be GET, POST or DELETE, got %q", sub.Method)
+	}
+}
+
+// dispatchBatchRequest runs a single sub-request and captures its response.
+// remaining holds the bytes left of maxBatchResponseBytes; a sub-response
+// exceeding them is replaced by a 413 error.
+func dispatchBatchRequest(r *http.Request, sub BatchRequest, remaining *int) BatchResponse {
+	method := strings.ToUpper(sub.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	subReq, err := http.NewRequestWithContext(r.Context(), method, sub.URL, nil)
+	if err != nil {
+		body, _ := json.Marshal(err.Error())
+		return BatchResponse{ID: sub.ID, Method: method, URL: sub.URL, Status: http.StatusBadRequest, Body: body}
+	}
+	subReq.RemoteAddr = r.RemoteAddr
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		subReq.Header.Set("Authorization", auth)
+	}
+
+	rec := &batchRecorder{header: make(http.Header), remaining: remaining}
+	batchMux.ServeHTTP(rec, subReq)
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	if rec.truncated {
+		// Later sub-requests may still fit into what is left
+		*remaining += rec.body.Len()
+		body, _ := json.Marshal(ErrorResponse{Error: ErrorDetail{
+			Code:    errCodePayloadTooLarge,
+			Message: fmt.Sprintf("Response exceeds the %d bytes left in the batch", *remaining),
+		}})
+		return BatchResponse{ID: sub.ID, Method: method, URL: sub.URL, Status: http.StatusRequestEntityTooLarge, ContentType: "application/json", Body: body}
+	}
+
+	body := rec.body.Bytes()
+	contentType := rec.header.Get("Content-Type")
+	if !strings.HasPrefix(contentType, "application/json") || !json.Valid(body) {
+		body, _ = json.Marshal(string(body))
+	}
+
+	return BatchResponse{
+		ID:          sub.ID,
+		Method:      method,
+		URL:         sub.URL,
+		Status:      rec.status,
+		ContentType: contentType,
+		Body:        body,
+	}
+}
+
+// OpenAPISpec returns the OpenAPI specification for the batch endpoint
+func (p BatchPlugin) OpenAPISpec() OpenAPIPathSpec {
+	batchRequestSchema := &OpenAPISchema{
+		Type: "object",
+		Properties: map[string]*OpenAPISchema{
+			"id":     {Type: "string", Description: "Optional identifier, echoed in the sub-response"},
+			"method": {Type: "string", Description: "GET (default), POST or DELETE", Enum: []interface{}{"GET", "POST", "DELETE"}},
+			"url":    {Type: "string", Description: "Path and query of an endpoint on this server", Example: "/rest_payload?count=5"},
+		},
+	}
+
+	return OpenAPIPathSpec{
+		Path: "/batch",
+		Operation: OpenAPIPath{
+			Post: &OpenAPIOperation{
+				OperationID: "postBatch",
+				Summary:     "Submit several requests in one call",
+				Description: fmt.Sprintf("Dispatches up to %d sub-requests against this server's endpoints in order and returns the status and body of each, like the ServiceNow Batch API. JSON bodies are embedded as-is, other bodies as strings. Sub-requests inherit the Authorization header and pass through their endpoint's middleware, but are buffered, so transfer, proto=1.0, tiny_chunks and bandwidth have no visible effect. Batches cannot be nested or contain long-lived endpoints (/stream_payload, /longpoll, /slow-read). Sub-response bodies may take %d bytes together; a sub-response exceeding what is left gets status 413", maxBatchRequests, maxBatchResponseBytes),
+				Tags:        []string{"batch"},
+				Parameters:  expectContinueOpenAPIParameters(),
+				RequestBody: &OpenAPIRequestBody{
+					Description: "The sub-requests",
+					Required:    true,
+					Content: map[string]OpenAPIMediaType{
+						"application/json": {
+							Schema: &OpenAPISchema{
+								Type:  "array",
+								Items: batchRequestSchema,
+							},
+							Example: []BatchRequest{
+								{ID: "first", URL: "/rest_payload?count=2"},
+								{ID: "second", URL: "/paginated_payload?limit=1"},
+							},
+						},
+					},
+				},
+				Responses: map[string]OpenAPIResponse{
+					"200": {
+						Description: "Sub-responses in request order",
+						Content: map[string]OpenAPIMediaType{
+							"application/json": {
+								Schema: &OpenAPISchema{
+									Type: "array",
+									Items: &OpenAPISchema{
+										Type: "object",
+										Properties: map[string]*OpenAPISchema{
+											"id":           {Type: "string"},
+											"method":       {Type: "string"},
+											"url":          {Type: "string"},
+											"status":       {Type: "integer", Description: "HTTP status of the sub-request"},
+											"content_type": {Type: "string"},
+											"body":         {Description: "Response body: JSON embedded as-is, anything else as a string"},
+										},
+									},
+								},
+							},
+						},
+					},
+					"400": {
+						Description: "Bad request - not a JSON array, too many sub-requests or an invalid sub-request. Clients accepting application/json get the JSON error object, others plain text",
+						Content: map[string]OpenAPIMediaType{
+							"application/json": {
+								Schema:  &OpenAPISchema{Ref: "#/components/schemas/Error"},
+								Example: ErrorResponse{Error: ErrorDetail{Code: errCodeBadRequest, Message: "Request #2: batches cannot be nested"}},
+							},
+							"text/plain": {
+								Schema: &OpenAPISchema{
+									Type:    "string",
+									Example: "Request #2: batches cannot be nested",
+								},
+							},
+						},
+					},
+					"405": {
+						Description: "Method not allowed - only POST is supported. Clients accepting application/json get the JSON error object, others plain text",
+						Content: map[string]OpenAPIMediaType{
+							"application/json": {
+								Schema:  &OpenAPISchema{Ref: "#/components/schemas/Error"},
+								Example: ErrorResponse{Error: ErrorDetail{Code: errCodeMethodNotAllowed, Message: "Method not allowed, use POST"}},
+							},
+							"text/plain": {
+								Schema: &OpenAPISchema{
+									Type:    "string",
+									Example: "Method not allowed, use POST",
+								},
+							},
+						},
+					},
+					"417": expectContinueOpenAPIResponse(),
+				},
+			},
+		},
+	}
+}
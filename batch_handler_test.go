@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBatchHandler(t *testing.T) {
+	originalAuth := *enableAuth
+	*enableAuth = false
+	defer func() { *enableAuth = originalAuth }()
+
+	originalMux := batchMux
+	mux := http.NewServeMux()
+	mux.HandleFunc("/rest_payload", RestPayloadHandler)
+	batchMux = mux
+	defer func() { batchMux = originalMux }()
+
+	body := `[
+		{"id": "a", "method": "GET", "url": "/rest_payload?count=2"},
+		{"id": "b", "url": "/rest_payload?count=3&id_start=10"},
+		{"url": "/missing"}
+	]`
+	w := httptest.NewRecorder()
+	BatchHandler(w, httptest.NewRequest("POST", "/batch", bytes.NewBufferString(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var responses []BatchResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &responses); err != nil {
+		t.Fatalf("Failed to parse batch response: %v", err)
+	}
+	if len(responses) != 3 {
+		t.Fatalf("Expected 3 sub-responses, got %d", len(responses))
+	}
+
+	for i, expected := range []struct {
+		id    string
+		count int
+		first int
+	}{{"a", 2, 1}, {"b", 3, 10}} {
+		sub := responses[i]
+		if sub.ID != expected.id || sub.Status != http.StatusOK || sub.Method != http.MethodGet {
+			t.Errorf("Sub-response %d: unexpected id/status/method %q/%d/%s", i, sub.ID, sub.Status, sub.Method)
+		}
+		var items []Item
+		if err := json.Unmarshal(sub.Body, &items); err != nil {
+			t.Fatalf("Sub-response %d: body is not the embedded JSON array: %v", i, err)
+		}
+		if len(items) != expected.count || items[0].ID != expected.first {
+			t.Errorf("Sub-response %d: expected %d items starting at %d, got %+v", i, expected.count, expected.first, items)
+		}
+	}
+
+	// Non-JSON bodies are embedded as strings
+	if responses[2].Status != http.StatusNotFound {
+		t.Errorf("Expected 404 for unknown endpoint, got %d", responses[2].Status)
+	}
+	var text string
+	if err := json.Unmarshal(responses[2].Body, &text); err != nil {
+		t.Errorf("Expected plain text body as JSON string, got %s", responses[2].Body)
+	}
+}
+
+func TestBatchHandlerInvalid(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		body         string
+		expectedCode int
+	}{
+		{"GET not allowed", "GET", "", http.StatusMethodNotAllowed},
+		{"not an array", "POST", `{"url": "/rest_payload"}`, http.StatusBadRequest},
+		{"empty batch", "POST", `[]`, http.StatusBadRequest},
+		{"absolute URL", "POST", `[{"url": "http://example.com/"}]`, http.StatusBadRequest},
+		{"nested batch", "POST", `[{"url": "/batch"}]`, http.StatusBadRequest},
+		{"streaming endpoint", "POST", `[{"url": "/stream_payload?count=5"}]`, http.StatusBadRequest},
+		{"unsupported method", "POST", `[{"method": "PATCH", "url": "/rest_payload"}]`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			BatchHandler(w, httptest.NewRequest(tt.method, "/batch", bytes.NewBufferString(tt.body)))
+			if w.Code != tt.expectedCode {
+				t.Errorf("Expected status %d, got %d", tt.expectedCode, w.Code)
+			}
+		})
+	}
+}
+
+// TestBatchResponseLimit checks that a sub-response exceeding the bytes left
+// in the batch is replaced by a 413 error without using up the budget
+func TestBatchResponseLimit(t *testing.T) {
+	originalAuth := *enableAuth
+	*enableAuth = false
+	defer func() { *enableAuth = originalAuth }()
+
+	originalMux := batchMux
+	mux := http.NewServeMux()
+	mux.HandleFunc("/rest_payload", RestPayloadHandler)
+	batchMux = mux
+	defer func() { batchMux = originalMux }()
+
+	req := httptest.NewRequest("POST", "/batch", nil)
+	remaining := 1000
+	sub := dispatchBatchRequest(req, BatchRequest{URL: "/rest_payload?count=100"}, &remaining)
+	var errResp ErrorResponse
+	if err := json.Unmarshal(sub.Body, &errResp); err != nil || sub.Status != http.StatusRequestEntityTooLarge || errResp.Error.Code != errCodePayloadTooLarge {
+		t.Errorf("Expected a 413 %s sub-response, got %d %s (%v)", errCodePayloadTooLarge, sub.Status, sub.Body, err)
+	}
+	if remaining != 1000 {
+		t.Errorf("Expected the rejected sub-response to leave 1000 bytes, got %d", remaining)
+	}
+
+	// A small sub-response still fits and is counted
+	sub = dispatchBatchRequest(req, BatchRequest{URL: "/rest_payload?count=1"}, &remaining)
+	if sub.Status != http.StatusOK || remaining != 1000-len(sub.Body) {
+		t.Errorf("Expected status 200 counting %d bytes, got %d with %d bytes left", len(sub.Body), sub.Status, remaining)
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// bigHeaderName is the response header added by the big_header parameter
+const bigHeaderName = "X-PayloadBuddy-Big-Header"
+
+// maxBigHeaderBytes caps big_header at the size Go allows for request
+// headers by default, so a proxied response can still be parsed by another
+// payloadBuddy or Go client
+const maxBigHeaderBytes = http.DefaultMaxHeaderBytes
+
+// bigHeaderAlphabet fills big headers with a repeating, readable pattern
+const bigHeaderAlphabet = "abcdefghijklmnopqrstuvwxyz0123456789"
+
+// getBigHeaderSize parses the big_header parameter, the length in bytes of
+// the header value. It is 0 if the parameter is not set.
+func getBigHeaderSize(r *http.Request) (int, error) {
+	val := r.URL.Query().Get("big_header")
+	if val == "" {
+		return 0, nil
+	}
+	size, err := strconv.Atoi(val)
+	if err != nil || size < 1 || size > maxBigHeaderBytes {
+		return 0, fmt.Errorf("big_header must be a header size between 1 and %d bytes", maxBigHeaderBytes)
+	}
+	return size, nil
+}
+
+// bigHeaderValue returns size bytes of the repeating bigHeaderAlphabet, so
+// the same size always yields the same value
+func bigHeaderValue(size int) string {
+	repeats := size/len(bigHeaderAlphabet) + 1
+	return strings.Repeat(bigHeaderAlphabet, repeats)[:size]
+}
+
+// applyBigHeader adds the X-PayloadBuddy-Big-Header requested by
+// big_header, for testing how clients and proxies handle large response
+// headers
+func applyBigHeader(w http.ResponseWriter, r *http.Request) error {
+	size, err := getBigHeaderSize(r)
+	if err != nil || size == 0 {
+		return err
+	}
+	w.Header().Set(bigHeaderName, bigHeaderValue(size))
+	return nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestBigHeader checks that big_header adds a header of exactly the
+// requested length to the payload endpoints
+func TestBigHeader(t *testing.T) {
+	originalAuth := *enableAuth
+	*enableAuth = false
+	defer func() { *enableAuth = originalAuth }()
+
+	handlers := map[string]http.HandlerFunc{
+		"/rest_payload?count=1":              RestPayloadHandler,
+		"/stream_payload?count=1&delay=0s":   StreamingPayloadHandler,
+		"/paginated_payload?total=1&limit=1": PaginatedPayloadHandler,
+	}
+
+	for url, handler := range handlers {
+		t.Run(url, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler(w, httptest.NewRequest("GET", url+"&big_header=20000", nil))
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d", w.Code)
+			}
+			value := w.Header().Get(bigHeaderName)
+			if len(value) != 20000 {
+				t.Errorf("Expected a %d byte header, got %d bytes", 20000, len(value))
+			}
+			if !strings.HasPrefix(value, bigHeaderAlphabet+bigHeaderAlphabet) {
+				t.Errorf("Expected the repeating pattern, got %q...", value[:min(len(value), 40)])
+			}
+
+			w = httptest.NewRecorder()
+			handler(w, httptest.NewRequest("GET", url, nil))
+			if w.Header().Get(bigHeaderName) != "" {
+				t.Error("Expected no big header without big_header")
+			}
+		})
+	}
+
+	// The header survives a real round trip
+	server := httptest.NewServer(http.HandlerFunc(RestPayloadHandler))
+	defer server.Close()
+	resp, err := http.Get(server.URL + "/rest_payload?count=1&big_header=65536")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+	if got := len(resp.Header.Get(bigHeaderName)); got != 65536 {
+		t.Errorf("Expected a 65536 byte header over HTTP, got %d bytes", got)
+	}
+}
+
+func TestBigHeader_InvalidSize(t *testing.T) {
+	for _, size := range []string{"0", "-1", "abc", "1048577"} {
+		t.Run(size, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			RestPayloadHandler(w, httptest.NewRequest("GET", "/rest_payload?count=1&big_header="+size, nil))
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400, got %d", w.Code)
+			}
+			if w.Header().Get(bigHeaderName) != "" {
+				t.Error("Expected no big header for an invalid size")
+			}
+		})
+	}
+}
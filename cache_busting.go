@@ -0,0 +1,36 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"sync/atomic"
+)
+
+// paginationNonce counts the pages served with nonce=true
+var paginationNonce atomic.Int64
+
+// nextPaginationNonce returns the next nonce, starting at 1
+func nextPaginationNonce() int64 {
+	return paginationNonce.Add(1)
+}
+
+// resetPaginationNonce restarts the nonce count
+func resetPaginationNonce() {
+	paginationNonce.Store(0)
+}
+
+// setPageCacheHeaders sets the caching headers of a page. Pages are always
+// revalidated (no-cache); with noCache intermediaries must not store them at
+// all (no-store). A nonce makes every response unique, which Vary: * tells
+// caches, and is echoed in X-PayloadBuddy-Nonce.
+func setPageCacheHeaders(w http.ResponseWriter, noCache bool, nonce int64) {
+	if noCache {
+		w.Header().Set("Cache-Control", "no-store")
+	} else {
+		w.Header().Set("Cache-Control", "no-cache")
+	}
+	if nonce > 0 {
+		w.Header().Set("Vary", "*")
+		w.Header().Set("X-PayloadBuddy-Nonce", strconv.FormatInt(nonce, 10))
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPaginatedPayloadHandler_CacheBusting(t *testing.T) {
+	originalAuth := *enableAuth
+	*enableAuth = false
+	defer func() { *enableAuth = originalAuth }()
+	defer resetPaginationNonce()
+
+	get := func(query string) (*httptest.ResponseRecorder, PaginatedResponse) {
+		w := httptest.NewRecorder()
+		PaginatedPayloadHandler(w, httptest.NewRequest("GET", "/paginated_payload?limit=2"+query, nil))
+		var response PaginatedResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("%s: failed to parse response: %v", query, err)
+		}
+		return w, response
+	}
+
+	// Pages may be stored but must be revalidated by default
+	w, response := get("")
+	if cc := w.Header().Get("Cache-Control"); cc != "no-cache" {
+		t.Errorf("Expected Cache-Control no-cache by default, got %q", cc)
+	}
+	if response.Metadata.Nonce != 0 || w.Header().Get("Vary") == "*" {
+		t.Errorf("Expected no nonce by default, got %d", response.Metadata.Nonce)
+	}
+
+	w, _ = get("&no_cache=true")
+	if cc := w.Header().Get("Cache-Control"); cc != "no-store" {
+		t.Errorf("Expected Cache-Control no-store with no_cache=true, got %q", cc)
+	}
+
+	// The nonce counts up with every page, also past the end of the data
+	resetPaginationNonce()
+	w, first := get("&nonce=true")
+	_, second := get("&nonce=true&offset=20000")
+	if first.Metadata.Nonce != 1 || second.Metadata.Nonce != 2 {
+		t.Errorf("Expected nonces 1 and 2, got %d and %d", first.Metadata.Nonce, second.Metadata.Nonce)
+	}
+	if w.Header().Get("X-PayloadBuddy-Nonce") != "1" || w.Header().Get("Vary") != "*" {
+		t.Errorf("Expected X-PayloadBuddy-Nonce 1 and Vary *, got %q and %q", w.Header().Get("X-PayloadBuddy-Nonce"), w.Header().Get("Vary"))
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// circuitBreakerKey is the simulation_config key that makes a scenario behave
+// like a backend behind a tripped circuit breaker
+const circuitBreakerKey = "circuit_breaker"
+
+// circuitOpenError is the error type of requests rejected by an open circuit
+const circuitOpenError = "circuit_open"
+
+// Circuit breaker states reported in the X-PayloadBuddy-Circuit-State header
+const (
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half-open"
+	CircuitClosed   = "closed"
+)
+
+// circuitBreakerConfig is the parsed circuit_breaker simulation_config value
+type circuitBreakerConfig struct {
+	OpenDuration     time.Duration // How long the circuit stays open before a probe is let through
+	ProbeSuccessRate float64       // Probability that the probe succeeds and closes the circuit
+}
+
+// parseCircuitBreaker validates a circuit_breaker value, an object with a
+// required open_duration and an optional probe_success_rate (default: 1.0)
+func parseCircuitBreaker(value interface{}) (circuitBreakerConfig, error) {
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return circuitBreakerConfig{}, fmt.Errorf("%s must be an object with open_duration and optional probe_success_rate", circuitBreakerKey)
+	}
+
+	config := circuitBreakerConfig{ProbeSuccessRate: 1.0}
+	s, ok := m["open_duration"].(string)
+	if !ok {
+		return circuitBreakerConfig{}, fmt.Errorf("%s.open_duration must be a duration string", circuitBreakerKey)
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return circuitBreakerConfig{}, fmt.Errorf("%s.open_duration must be a positive duration", circuitBreakerKey)
+	}
+	config.OpenDuration = d
+
+	if raw, exists := m["probe_success_rate"]; exists {
+		rate, ok := raw.(float64)
+		if !ok || rate < 0 || rate > 1 {
+			return circuitBreakerConfig{}, fmt.Errorf("%s.probe_success_rate must be between 0.0 and 1.0", circuitBreakerKey)
+		}
+		config.ProbeSuccessRate = rate
+	}
+	return config, nil
+}
+
+// getCircuitBreaker returns the scenario's circuit_breaker configuration.
+// ok is false if the scenario has none.
+func getCircuitBreaker(scenario *Scenario) (config circuitBreakerConfig, ok bool) {
+	if scenario == nil || scenario.ScenarioParams == nil {
+		return circuitBreakerConfig{}, false
+	}
+	value, exists := scenario.ScenarioParams.SimulationConfig[circuitBreakerKey]
+	if !exists {
+		return circuitBreakerConfig{}, false
+	}
+	config, err := parseCircuitBreaker(value)
+	if err != nil {
+		return circuitBreakerConfig{}, false
+	}
+	return config, true
+}
+
+// nextCircuitDecision advances the circuit of a scenario by one request.
+//
+// The circuit starts open with the scenario's first request and rejects
+// every request until open_duration has passed. The next request is the
+// single half-open probe: if it succeeds (with probe_success_rate) the
+// circuit closes and all later requests succeed, otherwise it opens again
+// for another open_duration. The state is shared by all clients; POST /reset
+// opens the circuit again.
+func (state *errorInjectionState) nextCircuitDecision(config circuitBreakerConfig, now time.Time) ErrorDecision {
+	if state.circuitClosed {
+		return ErrorDecision{CircuitState: CircuitClosed}
+	}
+	if state.circuitOpenedAt.IsZero() {
+		state.circuitOpenedAt = now
+	}
+
+	if remaining := config.OpenDuration - now.Sub(state.circuitOpenedAt); remaining > 0 {
+		return ErrorDecision{ErrorType: circuitOpenError, CircuitState: CircuitOpen, RetryAfter: remaining}
+	}
+
+	// This request is the half-open probe
+	if rollProbability(config.ProbeSuccessRate) {
+		state.circuitClosed = true
+		return ErrorDecision{CircuitState: CircuitHalfOpen}
+	}
+	state.circuitOpenedAt = now
+	return ErrorDecision{ErrorType: circuitOpenError, CircuitState: CircuitHalfOpen, RetryAfter: config.OpenDuration}
+}
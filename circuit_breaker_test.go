@@ -0,0 +1,121 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestCircuitBreakerScenario drives a circuit_breaker scenario through
+// open -> half-open -> closed via the paginated and streaming handlers.
+func TestCircuitBreakerScenario(t *testing.T) {
+	originalAuth := *enableAuth
+	*enableAuth = false
+	defer func() { *enableAuth = originalAuth }()
+
+	originalManager := scenarioManager
+	defer func() { scenarioManager = originalManager }()
+
+	const openDuration = 150 * time.Millisecond
+	scenarioManager = &ScenarioManager{
+		scenarios: map[string]*Scenario{
+			"circuit_breaker": {
+				ScenarioType: "circuit_breaker",
+				BaseDelay:    "1ms",
+				ScenarioParams: &ScenarioParameters{
+					SimulationConfig: map[string]interface{}{
+						circuitBreakerKey: map[string]interface{}{"open_duration": "150ms"},
+					},
+				},
+			},
+		},
+		validator: NewScenarioValidator(),
+	}
+
+	request := func(handler http.HandlerFunc) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest("GET", "/?scenario=circuit_breaker&limit=1&count=1", nil))
+		return w
+	}
+	expect := func(stage string, w *httptest.ResponseRecorder, code int, state string) {
+		t.Helper()
+		if w.Code != code {
+			t.Errorf("%s: expected status %d, got %d", stage, code, w.Code)
+		}
+		if got := w.Header().Get("X-PayloadBuddy-Circuit-State"); got != state {
+			t.Errorf("%s: expected circuit state %q, got %q", stage, state, got)
+		}
+	}
+
+	// Open: every request fails fast
+	for _, handler := range []http.HandlerFunc{PaginatedPayloadHandler, StreamingPayloadHandler} {
+		w := request(handler)
+		expect("open", w, http.StatusServiceUnavailable, CircuitOpen)
+		if w.Header().Get("Retry-After") != "1" {
+			t.Errorf("open: expected Retry-After 1, got %q", w.Header().Get("Retry-After"))
+		}
+	}
+
+	// Half-open: the first request after open_duration is the probe
+	time.Sleep(openDuration + 20*time.Millisecond)
+	expect("half-open", request(PaginatedPayloadHandler), http.StatusOK, CircuitHalfOpen)
+
+	// Closed: everything succeeds
+	expect("closed", request(StreamingPayloadHandler), http.StatusOK, CircuitClosed)
+	expect("closed", request(PaginatedPayloadHandler), http.StatusOK, CircuitClosed)
+
+	// A reset opens the circuit again
+	scenarioManager.ResetErrorInjection()
+	expect("after reset", request(PaginatedPayloadHandler), http.StatusServiceUnavailable, CircuitOpen)
+}
+
+// TestCircuitBreakerFailedProbe checks that a failed probe reopens the circuit
+func TestCircuitBreakerFailedProbe(t *testing.T) {
+	config := circuitBreakerConfig{OpenDuration: 10 * time.Second, ProbeSuccessRate: 0}
+	state := &errorInjectionState{}
+	start := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	steps := []struct {
+		at        time.Duration
+		state     string
+		fails     bool
+		retryLeft time.Duration
+	}{
+		{0, CircuitOpen, true, 10 * time.Second},
+		{4 * time.Second, CircuitOpen, true, 6 * time.Second},
+		{10 * time.Second, CircuitHalfOpen, true, 10 * time.Second}, // Probe fails, reopens
+		{15 * time.Second, CircuitOpen, true, 5 * time.Second},
+		{20 * time.Second, CircuitHalfOpen, true, 10 * time.Second},
+	}
+	for _, step := range steps {
+		decision := state.nextCircuitDecision(config, start.Add(step.at))
+		if decision.CircuitState != step.state || (decision.ErrorType != "") != step.fails || decision.RetryAfter != step.retryLeft {
+			t.Errorf("At %v: got %+v, expected state %s, fails %v, retry after %v", step.at, decision, step.state, step.fails, step.retryLeft)
+		}
+	}
+}
+
+func TestParseCircuitBreaker(t *testing.T) {
+	valid := []interface{}{
+		map[string]interface{}{"open_duration": "5s"},
+		map[string]interface{}{"open_duration": "500ms", "probe_success_rate": 0.5},
+	}
+	for _, value := range valid {
+		if _, err := parseCircuitBreaker(value); err != nil {
+			t.Errorf("parseCircuitBreaker(%v) unexpected error: %v", value, err)
+		}
+	}
+	invalid := []interface{}{
+		"5s",
+		map[string]interface{}{},
+		map[string]interface{}{"open_duration": "0s"},
+		map[string]interface{}{"open_duration": 5.0},
+		map[string]interface{}{"open_duration": "5s", "probe_success_rate": 1.5},
+	}
+	for _, value := range invalid {
+		if _, err := parseCircuitBreaker(value); err == nil {
+			t.Errorf("parseCircuitBreaker(%v) expected an error", value)
+		}
+	}
+}
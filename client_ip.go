@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// paramTrustProxy makes clientIP honor the headers set by a reverse proxy
+var paramTrustProxy = flag.Bool("trust-proxy", false, "Take the client IP from X-Real-IP or X-Forwarded-For; only enable behind a reverse proxy that sets them")
+
+// clientIP returns the IP address of the client that sent the request, used
+// to tell clients apart for example in the X-RateLimit-* headers. With
+// -trust-proxy the address reported by a reverse proxy is used.
+func clientIP(r *http.Request) string {
+	return clientIPFrom(r, *paramTrustProxy)
+}
+
+// clientIPFrom returns the client IP of r in canonical form: without port,
+// brackets or IPv6 zone, and IPv4-mapped IPv6 addresses as plain IPv4.
+//
+// With trustProxy the address a single reverse proxy reports wins:
+// X-Real-IP, otherwise the last X-Forwarded-For entry, which the proxy
+// appended itself. Earlier entries come from the client and may be spoofed.
+// Headers without a valid address fall back to RemoteAddr.
+func clientIPFrom(r *http.Request, trustProxy bool) string {
+	if trustProxy {
+		if ip, ok := parseClientIP(r.Header.Get("X-Real-IP")); ok {
+			return ip
+		}
+		if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+			entries := strings.Split(forwarded[len(forwarded)-1], ",")
+			if ip, ok := parseClientIP(entries[len(entries)-1]); ok {
+				return ip
+			}
+		}
+	}
+
+	if ip, ok := parseClientIP(r.RemoteAddr); ok {
+		return ip
+	}
+	// Not an IP address, e.g. "@" for Unix domain sockets
+	return r.RemoteAddr
+}
+
+// parseClientIP parses an address with or without port, such as
+// "192.0.2.1", "192.0.2.1:1234", "2001:db8::1" or "[2001:db8::1]:1234"
+func parseClientIP(addr string) (string, bool) {
+	addr = strings.TrimSpace(addr)
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+	ip, err := netip.ParseAddr(strings.TrimSuffix(strings.TrimPrefix(addr, "["), "]"))
+	if err != nil {
+		return "", false
+	}
+	return ip.Unmap().WithZone("").String(), true
+}
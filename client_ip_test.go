@@ -0,0 +1,55 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name         string
+		remoteAddr   string
+		realIP       string
+		forwardedFor []string
+		trustProxy   bool
+		expected     string
+	}{
+		{name: "IPv4 with port", remoteAddr: "192.0.2.1:1234", expected: "192.0.2.1"},
+		{name: "IPv4 without port", remoteAddr: "192.0.2.1", expected: "192.0.2.1"},
+		{name: "IPv6 with port", remoteAddr: "[2001:db8::1]:1234", expected: "2001:db8::1"},
+		{name: "IPv6 without port", remoteAddr: "2001:db8::1", expected: "2001:db8::1"},
+		{name: "IPv6 in brackets", remoteAddr: "[2001:db8::1]", expected: "2001:db8::1"},
+		{name: "IPv6 loopback", remoteAddr: "[::1]:8080", expected: "::1"},
+		{name: "IPv6 non-canonical", remoteAddr: "[2001:DB8:0:0::1]:1234", expected: "2001:db8::1"},
+		{name: "IPv6 with zone", remoteAddr: "[fe80::1%eth0]:1234", expected: "fe80::1"},
+		{name: "IPv4-mapped IPv6", remoteAddr: "[::ffff:192.0.2.1]:1234", expected: "192.0.2.1"},
+		{name: "Unix socket", remoteAddr: "@", expected: "@"},
+
+		{name: "proxy headers ignored without trust", remoteAddr: "10.0.0.1:1234", realIP: "192.0.2.1", forwardedFor: []string{"198.51.100.1"}, expected: "10.0.0.1"},
+		{name: "X-Real-IP", remoteAddr: "10.0.0.1:1234", realIP: "192.0.2.1", trustProxy: true, expected: "192.0.2.1"},
+		{name: "X-Real-IP IPv6", remoteAddr: "10.0.0.1:1234", realIP: "2001:db8::2", trustProxy: true, expected: "2001:db8::2"},
+		{name: "X-Real-IP wins over X-Forwarded-For", remoteAddr: "10.0.0.1:1234", realIP: "192.0.2.1", forwardedFor: []string{"198.51.100.1"}, trustProxy: true, expected: "192.0.2.1"},
+		{name: "X-Forwarded-For single", remoteAddr: "10.0.0.1:1234", forwardedFor: []string{"198.51.100.1"}, trustProxy: true, expected: "198.51.100.1"},
+		{name: "X-Forwarded-For last entry", remoteAddr: "10.0.0.1:1234", forwardedFor: []string{"203.0.113.9, 198.51.100.1"}, trustProxy: true, expected: "198.51.100.1"},
+		{name: "X-Forwarded-For last header", remoteAddr: "10.0.0.1:1234", forwardedFor: []string{"203.0.113.9", "198.51.100.1"}, trustProxy: true, expected: "198.51.100.1"},
+		{name: "X-Forwarded-For IPv6 with port", remoteAddr: "10.0.0.1:1234", forwardedFor: []string{"[2001:db8::3]:4711"}, trustProxy: true, expected: "2001:db8::3"},
+		{name: "invalid proxy headers", remoteAddr: "10.0.0.1:1234", realIP: "unknown", forwardedFor: []string{"garbage"}, trustProxy: true, expected: "10.0.0.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.realIP != "" {
+				req.Header.Set("X-Real-IP", tt.realIP)
+			}
+			for _, value := range tt.forwardedFor {
+				req.Header.Add("X-Forwarded-For", value)
+			}
+
+			if got := clientIPFrom(req, tt.trustProxy); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
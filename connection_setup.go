@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// setupDelayKey is the simulation_config key for the time to first byte a
+// scenario adds to every response, modelling slow connection establishment
+// (DNS resolution, TCP and TLS handshakes). A ttfb query parameter overrides it.
+const setupDelayKey = "setup_delay"
+
+// maxSetupDelay caps setup_delay
+const maxSetupDelay = 5 * time.Minute
+
+// parseSetupDelay validates a setup_delay value, which must be a positive
+// duration string of at most maxSetupDelay
+func parseSetupDelay(value interface{}) (time.Duration, error) {
+	if s, ok := value.(string); ok {
+		if d, err := time.ParseDuration(s); err == nil && d > 0 && d <= maxSetupDelay {
+			return d, nil
+		}
+	}
+	return 0, fmt.Errorf("%s must be a positive duration of at most %v", setupDelayKey, maxSetupDelay)
+}
+
+// GetSetupDelay returns the scenario's setup_delay, or 0 if it has none
+func (sm *ScenarioManager) GetSetupDelay(scenarioType string) time.Duration {
+	scenario := sm.GetScenario(scenarioType)
+	if scenario == nil || scenario.ScenarioParams == nil {
+		return 0
+	}
+	value, ok := scenario.ScenarioParams.SimulationConfig[setupDelayKey]
+	if !ok {
+		return 0
+	}
+	delay, err := parseSetupDelay(value)
+	if err != nil {
+		return 0
+	}
+	return delay
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSlowConnectScenarioEmbedded(t *testing.T) {
+	sm := NewScenarioManager()
+	if got := sm.GetSetupDelay("slow_connect"); got != 5*time.Second {
+		t.Errorf("Expected the embedded slow_connect scenario to have a 5s setup_delay, got %v", got)
+	}
+	if got := sm.GetSetupDelay("peak_hours"); got != 0 {
+		t.Errorf("Expected no setup_delay for peak_hours, got %v", got)
+	}
+}
+
+// TestSlowConnectScenarioStreaming checks that a slow_connect scenario holds
+// back the first byte, after which items flow at the normal pace.
+func TestSlowConnectScenarioStreaming(t *testing.T) {
+	*enableAuth = false
+	originalManager := scenarioManager
+	defer func() { scenarioManager = originalManager }()
+
+	// Override the embedded scenario with a short setup delay to keep the test fast
+	tempDir := t.TempDir()
+	scenarioJSON := []byte(`{
+		"schema_version": "1.0.0",
+		"scenario_name": "Fast Slow Connect",
+		"scenario_type": "slow_connect",
+		"base_delay": "1ms",
+		"scenario_parameters": {
+			"simulation_config": {"setup_delay": "300ms"}
+		}
+	}`)
+	if err := os.WriteFile(filepath.Join(tempDir, "slow-connect.json"), scenarioJSON, 0644); err != nil {
+		t.Fatalf("Failed to write test scenario file: %v", err)
+	}
+	scenarioManager = &ScenarioManager{
+		scenarios: make(map[string]*Scenario),
+		userPath:  tempDir,
+		validator: NewScenarioValidator(),
+	}
+	scenarioManager.loadUserScenarios()
+
+	server := httptest.NewServer(http.HandlerFunc(StreamingPayloadHandler))
+	defer server.Close()
+
+	setupDelay := 300 * time.Millisecond
+	start := time.Now()
+	resp, err := http.Get(server.URL + "/stream_payload?scenario=slow_connect&count=20")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	firstByte := make([]byte, 1)
+	if _, err := resp.Body.Read(firstByte); err != nil {
+		t.Fatalf("Failed to read first byte: %v", err)
+	}
+	firstByteAfter := time.Since(start)
+
+	rest, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read body: %v", err)
+	}
+	restAfter := time.Since(start) - firstByteAfter
+
+	if firstByteAfter < setupDelay {
+		t.Errorf("Expected first byte after at least %v, got it after %v", setupDelay, firstByteAfter)
+	}
+	if restAfter >= setupDelay {
+		t.Errorf("Expected the remaining items to flow quickly after the first byte, took %v", restAfter)
+	}
+
+	var items []StreamItem
+	if err := json.Unmarshal(append(firstByte, rest...), &items); err != nil {
+		t.Fatalf("Failed to parse JSON response: %v", err)
+	}
+	if len(items) != 20 {
+		t.Errorf("Expected 20 items, got %d", len(items))
+	}
+
+	// An explicit ttfb overrides the scenario's setup_delay
+	start = time.Now()
+	w := httptest.NewRecorder()
+	PaginatedPayloadHandler(w, httptest.NewRequest("GET", "/paginated_payload?scenario=slow_connect&limit=5&ttfb=0s", nil))
+	if elapsed := time.Since(start); elapsed >= setupDelay {
+		t.Errorf("Expected ttfb=0s to skip the setup delay, took %v", elapsed)
+	}
+}
+
+func TestParseSetupDelay(t *testing.T) {
+	for _, value := range []interface{}{"2s", "500ms"} {
+		if _, err := parseSetupDelay(value); err != nil {
+			t.Errorf("parseSetupDelay(%v) unexpected error: %v", value, err)
+		}
+	}
+	for _, value := range []interface{}{"0s", "-1s", "10m", "slow", 5.0} {
+		if _, err := parseSetupDelay(value); err == nil {
+			t.Errorf("parseSetupDelay(%v) expected an error", value)
+		}
+	}
+}
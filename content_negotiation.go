@@ -0,0 +1,97 @@
+package main
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// errNotAcceptable is returned when the Accept header rules out every
+// response format the endpoint can produce
+var errNotAcceptable = errors.New("none of the available response formats is acceptable")
+
+// formatMediaTypes maps response formats to their media types, in the order
+// of server preference used to break ties between equal quality values
+var formatMediaTypes = []struct {
+	format    string
+	mediaType string
+}{
+	{FormatJSON, "application/json"},
+	{FormatMultipart, "multipart/mixed"},
+}
+
+// acceptRange is a single media range of an Accept header with its quality
+type acceptRange struct {
+	mediaType string
+	quality   float64
+}
+
+// parseAccept splits an Accept header into media ranges. Parameters other
+// than q are ignored; a malformed q counts as 1, ranges with q=0 are kept
+// because they explicitly exclude a media type.
+func parseAccept(header string) []acceptRange {
+	var ranges []acceptRange
+	for _, element := range strings.Split(header, ",") {
+		parts := strings.Split(element, ";")
+		mediaType := strings.ToLower(strings.TrimSpace(parts[0]))
+		if mediaType == "" {
+			continue
+		}
+		quality := 1.0
+		for _, param := range parts[1:] {
+			key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if !ok || strings.ToLower(strings.TrimSpace(key)) != "q" {
+				continue
+			}
+			if q, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil && q >= 0 && q <= 1 {
+				quality = q
+			}
+		}
+		ranges = append(ranges, acceptRange{mediaType: mediaType, quality: quality})
+	}
+	return ranges
+}
+
+// mediaTypeQuality returns the quality the Accept ranges assign to
+// mediaType. As in RFC 9110 the most specific matching range wins
+// (type/subtype over type/* over */*); -1 means no range matches.
+func mediaTypeQuality(ranges []acceptRange, mediaType string) float64 {
+	mainType, _, _ := strings.Cut(mediaType, "/")
+	quality, specificity := -1.0, -1
+	for _, ar := range ranges {
+		s := -1
+		switch ar.mediaType {
+		case mediaType:
+			s = 2
+		case mainType + "/*":
+			s = 1
+		case "*/*":
+			s = 0
+		}
+		if s > specificity {
+			quality, specificity = ar.quality, s
+		}
+	}
+	return quality
+}
+
+// negotiateFormat picks the response format with the highest quality in the
+// Accept header. Without an Accept header JSON is used; ties go to the
+// format listed first in formatMediaTypes. errNotAcceptable is returned
+// when every format is excluded.
+func negotiateFormat(accept string) (string, error) {
+	if strings.TrimSpace(accept) == "" {
+		return FormatJSON, nil
+	}
+	ranges := parseAccept(accept)
+	best, bestQuality := "", 0.0
+	for _, f := range formatMediaTypes {
+		if q := mediaTypeQuality(ranges, f.mediaType); q > bestQuality {
+			best, bestQuality = f.format, q
+		}
+	}
+	if best == "" {
+		return "", errNotAcceptable
+	}
+	return best, nil
+}
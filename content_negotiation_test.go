@@ -0,0 +1,68 @@
+package main
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNegotiateFormat(t *testing.T) {
+	tests := []struct {
+		accept  string
+		want    string
+		wantErr bool
+	}{
+		{"", FormatJSON, false},
+		{"*/*", FormatJSON, false},
+		{"application/json", FormatJSON, false},
+		{"multipart/mixed", FormatMultipart, false},
+		{"application/xml;q=0.9, application/json;q=0.8, multipart/mixed;q=0.85", FormatMultipart, false},
+		{"application/xml;q=0.9, multipart/mixed;q=0.7, application/json;q=0.8", FormatJSON, false},
+		{"multipart/*;q=0.5, */*;q=0.1", FormatMultipart, false},
+		{"application/json;q=0, */*", FormatMultipart, false},
+		{"application/json;q=0.5, multipart/mixed;q=0.5", FormatJSON, false},
+		{"application/xml", "", true},
+		{"application/json;q=0, multipart/mixed;q=0", "", true},
+	}
+	for _, tt := range tests {
+		got, err := negotiateFormat(tt.accept)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("negotiateFormat(%q) error = %v, wantErr %v", tt.accept, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("negotiateFormat(%q) = %q, want %q", tt.accept, got, tt.want)
+		}
+	}
+}
+
+func TestPaginatedPayloadHandler_AcceptNegotiation(t *testing.T) {
+	*enableAuth = false
+
+	req := httptest.NewRequest("GET", "/paginated_payload?total=5", nil)
+	req.Header.Set("Accept", "application/xml;q=0.9, multipart/mixed;q=0.8, application/json;q=0.7")
+	w := httptest.NewRecorder()
+	PaginatedPayloadHandler(w, req)
+	if w.Code != 200 {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "multipart/mixed") {
+		t.Errorf("Expected multipart/mixed for the higher q-value, got %q", ct)
+	}
+
+	req = httptest.NewRequest("GET", "/paginated_payload?total=5", nil)
+	req.Header.Set("Accept", "application/xml")
+	w = httptest.NewRecorder()
+	PaginatedPayloadHandler(w, req)
+	if w.Code != 406 {
+		t.Errorf("Expected status 406 for an unsatisfiable Accept header, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest("GET", "/paginated_payload?total=5&format=json", nil)
+	req.Header.Set("Accept", "application/xml")
+	w = httptest.NewRecorder()
+	PaginatedPayloadHandler(w, req)
+	if w.Code != 200 || !strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+		t.Errorf("Expected format=json to override Accept, got %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+}
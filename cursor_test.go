@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// newCursor creates a cursor, failing the test if that is not possible
+func newCursor(t *testing.T, startID, limit int) string {
+	t.Helper()
+	cursor, err := createCursor(startID, limit)
+	if err != nil {
+		t.Fatalf("Failed to create cursor: %v", err)
+	}
+	return cursor
+}
+
+// getCursorPage requests a /paginated_payload page with the given cursor
+func getCursorPage(t *testing.T, cursor string) (*httptest.ResponseRecorder, PaginatedResponse) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	PaginatedPayloadHandler(w, httptest.NewRequest("GET", "/paginated_payload?total=25&cursor="+url.QueryEscape(cursor), nil))
+	var page PaginatedResponse
+	if w.Code == http.StatusOK {
+		if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+	}
+	return w, page
+}
+
+// followCursors pages through the data set from the first cursor and
+// returns the IDs received
+func followCursors(t *testing.T, cursor string) []int {
+	t.Helper()
+	var ids []int
+	for pages := 0; pages < 10; pages++ {
+		w, page := getCursorPage(t, cursor)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Cursor %q: expected status 200, got %d: %s", cursor, w.Code, w.Body.String())
+		}
+		for _, item := range page.Result {
+			ids = append(ids, item.ID)
+		}
+		if page.Metadata.NextCursor == nil {
+			return ids
+		}
+		cursor = *page.Metadata.NextCursor
+	}
+	t.Fatal("Expected the cursors to reach the end of the data set")
+	return nil
+}
+
+func TestCursorPagination(t *testing.T) {
+	originalAuth := *enableAuth
+	*enableAuth = false
+	defer func() { *enableAuth = originalAuth }()
+
+	// The plain cursor is base64 JSON and carries the page size
+	cursor := newCursor(t, 10, 10)
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || string(data) != `{"id":10,"limit":10}` {
+		t.Errorf("Expected a base64 JSON cursor, got %q (%v)", data, err)
+	}
+
+	ids := followCursors(t, newCursor(t, 0, 10))
+	if len(ids) != 25 || ids[0] != 1 || ids[24] != 25 {
+		t.Errorf("Expected IDs 1-25 across the pages, got %v", ids)
+	}
+
+	// Padded standard base64 of {"id":20,"limit":5}, as in the documentation
+	if _, page := getCursorPage(t, "eyJpZCI6MjAsImxpbWl0Ijo1fQ=="); len(page.Result) != 5 || page.Result[0].ID != 21 {
+		t.Errorf("Expected 5 items from ID 21, got %+v", page.Result)
+	}
+
+	for _, cursor := range []string{"not base64!", base64.RawURLEncoding.EncodeToString([]byte("not json")), base64.RawURLEncoding.EncodeToString([]byte(`{"id":-5}`))} {
+		if w, _ := getCursorPage(t, cursor); w.Code != http.StatusBadRequest {
+			t.Errorf("Cursor %q: expected status 400, got %d", cursor, w.Code)
+		}
+	}
+}
+
+func TestEncryptedCursor(t *testing.T) {
+	originalAuth := *enableAuth
+	*enableAuth = false
+	defer func() { *enableAuth = originalAuth }()
+
+	originalKey := *paramCursorKey
+	*paramCursorKey = "test passphrase"
+	defer func() { *paramCursorKey = originalKey }()
+
+	// Round trip
+	cursor := newCursor(t, 10, 10)
+	if start, limit, err := parseCursor(cursor, 100); err != nil || start != 10 || limit != 10 {
+		t.Fatalf("Expected position 10 and limit 10, got %d, %d (%v)", start, limit, err)
+	}
+	if data, _ := base64.RawURLEncoding.DecodeString(cursor); strings.Contains(string(data), "limit") {
+		t.Errorf("Expected an opaque cursor, got %q", data)
+	}
+	if newCursor(t, 10, 10) == cursor {
+		t.Error("Expected a fresh nonce for every cursor")
+	}
+
+	ids := followCursors(t, newCursor(t, 0, 10))
+	if len(ids) != 25 || ids[0] != 1 || ids[24] != 25 {
+		t.Errorf("Expected IDs 1-25 across the pages, got %v", ids)
+	}
+
+	// Modifying any byte is detected
+	data, _ := base64.RawURLEncoding.DecodeString(cursor)
+	for i := range data {
+		modified := append([]byte(nil), data...)
+		modified[i] ^= 0x01
+		if w, _ := getCursorPage(t, base64.RawURLEncoding.EncodeToString(modified)); w.Code != http.StatusBadRequest {
+			t.Fatalf("Byte %d modified: expected status 400, got %d", i, w.Code)
+		}
+	}
+
+	// Plain cursors and cursors of another key are rejected
+	plain := base64.RawURLEncoding.EncodeToString([]byte(`{"id":10,"limit":10}`))
+	*paramCursorKey = "other passphrase"
+	other := newCursor(t, 10, 10)
+	*paramCursorKey = "test passphrase"
+	for name, cursor := range map[string]string{"plain": plain, "other key": other, "truncated": cursor[:8]} {
+		w, _ := getCursorPage(t, cursor)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s cursor: expected status 400, got %d", name, w.Code)
+		}
+		if !strings.Contains(w.Body.String(), errInvalidCursor.Error()) {
+			t.Errorf("%s cursor: unexpected body %q", name, w.Body.String())
+		}
+	}
+}
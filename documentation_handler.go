@@ -2,9 +2,18 @@ package main
 
 import (
 	"encoding/json"
+	"flag"
+	"html"
 	"net/http"
+	"strings"
 )
 
+// defaultSwaggerAssets is the CDN location of the Swagger UI dist files
+const defaultSwaggerAssets = "https://unpkg.com/swagger-ui-dist@5.9.0"
+
+// paramSwaggerAssets is the base URL the Swagger UI page loads its assets from
+var paramSwaggerAssets = flag.String("swagger-assets", defaultSwaggerAssets, "Base URL of the swagger-ui-dist files (swagger-ui.css, swagger-ui-bundle.js, ...) for /swagger, e.g. a local mirror for air-gapped environments")
+
 // DocumentationPlugin implements PayloadPlugin for OpenAPI documentation
 type DocumentationPlugin struct{}
 
@@ -24,6 +33,7 @@ func (d DocumentationPlugin) OpenAPISpec() OpenAPIPathSpec {
 		Path: "/openapi.json",
 		Operation: OpenAPIPath{
 			Get: &OpenAPIOperation{
+				OperationID: "getOpenAPISpec",
 				Summary:     "Get OpenAPI specification",
 				Description: "Returns the complete OpenAPI 3.1.1 specification for all available endpoints",
 				Tags:        []string{"documentation"},
@@ -64,6 +74,7 @@ func (s SwaggerUIPlugin) OpenAPISpec() OpenAPIPathSpec {
 		Path: "/swagger",
 		Operation: OpenAPIPath{
 			Get: &OpenAPIOperation{
+				OperationID: "getSwaggerUI",
 				Summary:     "Swagger UI",
 				Description: "Interactive API documentation using Swagger UI",
 				Tags:        []string{"documentation"},
@@ -125,6 +136,10 @@ func OpenAPIHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Document the JSON error object next to the plain text errors
+	spec.Components.Schemas["Error"] = errorResponseSchema()
+	addJSONErrorContent(&spec)
+
 	// Add authentication security scheme if authentication is enabled
 	if *enableAuth {
 		if spec.Components == nil {
@@ -163,20 +178,23 @@ func OpenAPIHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Encode and send the specification
 	if err := json.NewEncoder(w).Encode(spec); err != nil {
-		http.Error(w, "Failed to encode OpenAPI specification", http.StatusInternalServerError)
+		writeErrorResponse(w, r, http.StatusInternalServerError, errCodeInternal, "Failed to encode OpenAPI specification")
 	}
 }
 
-// SwaggerUIHandler serves the Swagger UI HTML interface
+// SwaggerUIHandler serves the Swagger UI HTML interface. The Swagger UI
+// assets are loaded from the -swagger-assets base URL.
 func SwaggerUIHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/html")
 
+	assets := html.EscapeString(strings.TrimSuffix(*paramSwaggerAssets, "/"))
+
 	html := `<!DOCTYPE html>
 <html lang="en">
 <head>
     <meta charset="UTF-8">
     <title>PayloadBuddy API Documentation</title>
-    <link rel="stylesheet" type="text/css" href="https://unpkg.com/swagger-ui-dist@5.9.0/swagger-ui.css" />
+    <link rel="stylesheet" type="text/css" href="` + assets + `/swagger-ui.css" />
     <style>
         html {
             box-sizing: border-box;
@@ -194,8 +212,8 @@ func SwaggerUIHandler(w http.ResponseWriter, r *http.Request) {
 </head>
 <body>
     <div id="swagger-ui"></div>
-    <script src="https://unpkg.com/swagger-ui-dist@5.9.0/swagger-ui-bundle.js"></script>
-    <script src="https://unpkg.com/swagger-ui-dist@5.9.0/swagger-ui-standalone-preset.js"></script>
+    <script src="` + assets + `/swagger-ui-bundle.js"></script>
+    <script src="` + assets + `/swagger-ui-standalone-preset.js"></script>
     <script>
         window.onload = function() {
             const ui = SwaggerUIBundle({
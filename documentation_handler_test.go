@@ -142,6 +142,98 @@ func TestOpenAPIHandler_PathsAndSchemas(t *testing.T) {
 	}
 }
 
+// TestOpenAPIHandler_OperationIDs checks that every operation has a unique,
+// non-empty operationId for code generators
+func TestOpenAPIHandler_OperationIDs(t *testing.T) {
+	*enableAuth = false
+
+	rr := httptest.NewRecorder()
+	OpenAPIHandler(rr, httptest.NewRequest("GET", "/openapi.json", nil))
+
+	var spec OpenAPISpec
+	if err := json.Unmarshal(rr.Body.Bytes(), &spec); err != nil {
+		t.Fatalf("Failed to parse JSON response: %v", err)
+	}
+
+	seen := make(map[string]string)
+	for path, item := range spec.Paths {
+		for method, op := range map[string]*OpenAPIOperation{"GET": item.Get, "POST": item.Post, "PUT": item.Put, "DELETE": item.Delete} {
+			if op == nil {
+				continue
+			}
+			if op.OperationID == "" {
+				t.Errorf("%s %s has no operationId", method, path)
+				continue
+			}
+			if other, exists := seen[op.OperationID]; exists {
+				t.Errorf("operationId %q is used by both %s and %s %s", op.OperationID, other, method, path)
+			}
+			seen[op.OperationID] = method + " " + path
+		}
+	}
+
+	if got := spec.Paths["/rest_payload"].Get.OperationID; got != "getRestPayload" {
+		t.Errorf("Expected operationId getRestPayload for /rest_payload, got %q", got)
+	}
+	if got := spec.Paths["/stream_payload"].Get.OperationID; got != "getStreamPayload" {
+		t.Errorf("Expected operationId getStreamPayload for /stream_payload, got %q", got)
+	}
+}
+
+// TestOpenAPIHandler_ScenarioExamples checks that the streaming and paginated
+// responses offer a named example per loaded scenario
+func TestOpenAPIHandler_ScenarioExamples(t *testing.T) {
+	*enableAuth = false
+	originalManager := scenarioManager
+	defer func() { scenarioManager = originalManager }()
+
+	scenarioManager = &ScenarioManager{
+		scenarios: map[string]*Scenario{
+			"custom": {
+				ScenarioName:   "Custom Backend",
+				ScenarioType:   "custom",
+				BaseDelay:      "10ms",
+				ServiceNowMode: true,
+				BatchSize:      25,
+			},
+		},
+		validator: NewScenarioValidator(),
+	}
+
+	rr := httptest.NewRecorder()
+	OpenAPIHandler(rr, httptest.NewRequest("GET", "/openapi.json", nil))
+	var spec OpenAPISpec
+	if err := json.Unmarshal(rr.Body.Bytes(), &spec); err != nil {
+		t.Fatalf("Failed to parse JSON response: %v", err)
+	}
+
+	for _, path := range []string{"/stream_payload", "/paginated_payload"} {
+		media := spec.Paths[path].Get.Responses["200"].Content["application/json"]
+		if media.Example != nil {
+			t.Errorf("%s: expected examples instead of a single example", path)
+		}
+		if _, exists := media.Examples[defaultExampleName]; !exists {
+			t.Errorf("%s: missing the %s example", path, defaultExampleName)
+		}
+		example, exists := media.Examples["custom"]
+		if !exists {
+			t.Fatalf("%s: missing the example of the loaded scenario, got %v", path, media.Examples)
+		}
+		if example.Summary != "Custom Backend (scenario=custom)" {
+			t.Errorf("%s: unexpected summary %q", path, example.Summary)
+		}
+		// The scenario runs in ServiceNow mode, so its samples are records
+		if data, _ := json.Marshal(example.Value); !strings.Contains(string(data), `"number":"INC0000001"`) {
+			t.Errorf("%s: expected ServiceNow records in the example, got %s", path, data)
+		}
+	}
+
+	page := spec.Paths["/paginated_payload"].Get.Responses["200"].Content["application/json"].Examples["custom"]
+	if data, _ := json.Marshal(page.Value); !strings.Contains(string(data), `"limit":25`) {
+		t.Errorf("Expected the scenario's page size in the example, got %s", data)
+	}
+}
+
 func TestOpenAPIHandler_WithAuthentication(t *testing.T) {
 	// Enable auth for testing
 	*enableAuth = true
@@ -216,6 +308,25 @@ func TestSwaggerUIHandler_HTMLResponse(t *testing.T) {
 	}
 }
 
+func TestSwaggerUIHandler_AssetBase(t *testing.T) {
+	originalAssets := *paramSwaggerAssets
+	*paramSwaggerAssets = "http://mirror.internal/swagger-ui/"
+	defer func() { *paramSwaggerAssets = originalAssets }()
+
+	rr := httptest.NewRecorder()
+	SwaggerUIHandler(rr, httptest.NewRequest("GET", "/swagger", nil))
+	body := rr.Body.String()
+
+	for _, asset := range []string{"swagger-ui.css", "swagger-ui-bundle.js", "swagger-ui-standalone-preset.js"} {
+		if !strings.Contains(body, "http://mirror.internal/swagger-ui/"+asset) {
+			t.Errorf("Expected %s to be loaded from the configured asset base", asset)
+		}
+	}
+	if strings.Contains(body, "unpkg.com") {
+		t.Error("Expected no CDN references with a configured asset base")
+	}
+}
+
 func TestDocumentationPlugin_Interface(t *testing.T) {
 	plugin := DocumentationPlugin{}
 
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// DryRunSummary describes what a request would generate without generating it.
+// It is returned instead of the payload when a request sets dry_run=true.
+type DryRunSummary struct {
+	DryRun              bool                   `json:"dry_run"`
+	Endpoint            string                 `json:"endpoint"`
+	ItemCount           int                    `json:"item_count"`
+	EstimatedBytes      int64                  `json:"estimated_bytes"`
+	EstimatedDuration   string                 `json:"estimated_duration"`
+	EstimatedDurationMs int64                  `json:"estimated_duration_ms"`
+	EffectiveParameters map[string]interface{} `json:"effective_parameters"`
+}
+
+// isDryRun reports whether the request asks for a dry-run summary
+func isDryRun(r *http.Request) bool {
+	return r.URL.Query().Get("dry_run") == "true"
+}
+
+// writeDryRunSummary sends the dry-run summary as a JSON response
+func writeDryRunSummary(w http.ResponseWriter, r *http.Request, summary DryRunSummary) {
+	summary.DryRun = true
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(summary); err != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, errCodeInternal, "Failed to encode dry-run summary")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+	_, _ = w.Write(buf.Bytes())
+}
+
+// setEstimatedDuration stores the duration in both human-readable and millisecond form
+func (s *DryRunSummary) setEstimatedDuration(d time.Duration) {
+	s.EstimatedDuration = d.String()
+	s.EstimatedDurationMs = d.Milliseconds()
+}
+
+// estimateArrayBytes estimates the size of a JSON array of n items from the
+// encoded size of its first and last item, the separator between items and
+// the fixed overhead of the array brackets
+func estimateArrayBytes(first, last []byte, n, separatorLen, overhead int) int64 {
+	if n <= 0 {
+		return int64(overhead)
+	}
+	avg := float64(len(first)+len(last)) / 2
+	return int64(avg*float64(n)) + int64((n-1)*separatorLen) + int64(overhead)
+}
+
+// estimateStreamDelay sums the expected per-item delay for the items in
+// [start, count) the same way applyDelay would calculate them. Random delays
+// contribute their mean value.
+func estimateStreamDelay(strategy DelayStrategy, baseDelay time.Duration, scenario string, start, count int) time.Duration {
+	// Percentile targets are independent of the item index
+	if scenarioManager != nil && scenario != "" {
+		if dist := scenarioManager.GetLatencyDistribution(scenario); dist != nil {
+			return dist.Mean() * time.Duration(count-start)
+		}
+	}
+
+	var total time.Duration
+	for i := start; i < count; i++ {
+		total += expectedItemDelay(strategy, baseDelay, scenario, i)
+	}
+	return total
+}
+
+// expectedItemDelay returns the expected delay for a single item
+func expectedItemDelay(strategy DelayStrategy, baseDelay time.Duration, scenario string, itemIndex int) time.Duration {
+	if scenarioManager != nil && scenario != "" {
+		delay, scenarioStrategy := scenarioManager.GetScenarioDelay(scenario, itemIndex)
+		if scenarioManager.GetScenarioType(scenario) == "network_issues" && scenarioStrategy == RandomDelay {
+			// 90% base delay, 10% spike uniformly distributed in [0, 3s)
+			return delay*9/10 + 1500*time.Millisecond/10
+		}
+		return expectedStrategyDelay(scenarioStrategy, delay, itemIndex)
+	}
+	return expectedStrategyDelay(strategy, baseDelay, itemIndex)
+}
+
+// expectedStrategyDelay is calculateStrategyDelay with random delays replaced
+// by their mean so estimates are deterministic
+func expectedStrategyDelay(strategy DelayStrategy, baseDelay time.Duration, itemIndex int) time.Duration {
+	if strategy == RandomDelay {
+		return baseDelay
+	}
+	return calculateStrategyDelay(strategy, baseDelay, itemIndex)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestStreamingPayloadHandler_DryRunScenario(t *testing.T) {
+	*enableAuth = false
+	originalManager := scenarioManager
+	defer func() { scenarioManager = originalManager }()
+	scenarioManager = NewScenarioManager()
+
+	req := httptest.NewRequest("GET", "/stream_payload?count=100&scenario=peak_hours&dry_run=true", nil)
+	w := httptest.NewRecorder()
+
+	start := time.Now()
+	StreamingPayloadHandler(w, req)
+	elapsed := time.Since(start)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if elapsed > time.Second {
+		t.Errorf("Dry run should not apply delays, took %v", elapsed)
+	}
+
+	var summary DryRunSummary
+	if err := json.Unmarshal(w.Body.Bytes(), &summary); err != nil {
+		t.Fatalf("Failed to parse dry-run summary: %v", err)
+	}
+
+	if !summary.DryRun {
+		t.Error("Expected dry_run to be true")
+	}
+	if summary.ItemCount != 100 {
+		t.Errorf("Expected item count 100, got %d", summary.ItemCount)
+	}
+	if summary.EstimatedDurationMs != 20000 {
+		t.Errorf("Expected estimated duration of 20000ms (100 x 200ms), got %d", summary.EstimatedDurationMs)
+	}
+	if summary.EstimatedBytes <= 0 {
+		t.Errorf("Expected positive estimated bytes, got %d", summary.EstimatedBytes)
+	}
+	if summary.EffectiveParameters["scenario"] != "peak_hours" || summary.EffectiveParameters["servicenow"] != true {
+		t.Errorf("Expected resolved scenario parameters, got %v", summary.EffectiveParameters)
+	}
+}
+
+func TestStreamingPayloadHandler_DryRunEstimatesSize(t *testing.T) {
+	*enableAuth = false
+	url := "/stream_payload?count=500&delay=0"
+
+	w := httptest.NewRecorder()
+	StreamingPayloadHandler(w, httptest.NewRequest("GET", url, nil))
+	actual := int64(w.Body.Len())
+
+	w = httptest.NewRecorder()
+	StreamingPayloadHandler(w, httptest.NewRequest("GET", url+"&dry_run=true", nil))
+
+	var summary DryRunSummary
+	if err := json.Unmarshal(w.Body.Bytes(), &summary); err != nil {
+		t.Fatalf("Failed to parse dry-run summary: %v", err)
+	}
+
+	// Estimates are based on sample items, allow 5% tolerance
+	if diff := summary.EstimatedBytes - actual; diff > actual/20 || diff < -actual/20 {
+		t.Errorf("Estimated %d bytes, actual response was %d bytes", summary.EstimatedBytes, actual)
+	}
+	if summary.EstimatedDurationMs != 0 {
+		t.Errorf("Expected zero estimated duration without delay, got %d", summary.EstimatedDurationMs)
+	}
+}
+
+func TestRestPayloadHandler_DryRun(t *testing.T) {
+	*enableAuth = false
+	w := httptest.NewRecorder()
+	RestPayloadHandler(w, httptest.NewRequest("GET", "/rest_payload?count=1000", nil))
+	actual := int64(w.Body.Len())
+
+	w = httptest.NewRecorder()
+	RestPayloadHandler(w, httptest.NewRequest("GET", "/rest_payload?count=1000&dry_run=true", nil))
+
+	var summary DryRunSummary
+	if err := json.Unmarshal(w.Body.Bytes(), &summary); err != nil {
+		t.Fatalf("Failed to parse dry-run summary: %v", err)
+	}
+
+	if summary.ItemCount != 1000 {
+		t.Errorf("Expected item count 1000, got %d", summary.ItemCount)
+	}
+	if diff := summary.EstimatedBytes - actual; diff > actual/20 || diff < -actual/20 {
+		t.Errorf("Estimated %d bytes, actual response was %d bytes", summary.EstimatedBytes, actual)
+	}
+}
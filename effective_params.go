@@ -0,0 +1,23 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// effectiveParamsHeader is the response header that reports how the server
+// resolved the request parameters after applying defaults and clamping
+const effectiveParamsHeader = "X-PayloadBuddy-Effective"
+
+// setEffectiveParams reports the resolved parameters in the
+// X-PayloadBuddy-Effective header, encoded like a query string
+// (e.g. "count=100&scenario=peak_hours&servicenow=true").
+// It must be called before the response body is written.
+func setEffectiveParams(w http.ResponseWriter, params map[string]interface{}) {
+	values := url.Values{}
+	for key, value := range params {
+		values.Set(key, fmt.Sprint(value))
+	}
+	w.Header().Set(effectiveParamsHeader, values.Encode())
+}
@@ -0,0 +1,35 @@
+package main
+
+import "fmt"
+
+// emptyPageRateKey is the simulation_config key for the probability that a
+// page in the middle of the data set comes back without items, like
+// backends that filter records after paging
+const emptyPageRateKey = "empty_page_rate"
+
+// parseEmptyPageRate validates an empty_page_rate value, which must be a
+// probability between 0.0 and 1.0
+func parseEmptyPageRate(value interface{}) (float64, error) {
+	rate, ok := value.(float64)
+	if !ok || rate < 0 || rate > 1 {
+		return 0, fmt.Errorf("%s must be a number between 0.0 and 1.0", emptyPageRateKey)
+	}
+	return rate, nil
+}
+
+// GetEmptyPageRate returns the scenario's empty_page_rate, or 0 if it has none
+func (sm *ScenarioManager) GetEmptyPageRate(scenarioType string) float64 {
+	scenario := sm.GetScenario(scenarioType)
+	if scenario == nil || scenario.ScenarioParams == nil {
+		return 0
+	}
+	value, ok := scenario.ScenarioParams.SimulationConfig[emptyPageRateKey]
+	if !ok {
+		return 0
+	}
+	rate, err := parseEmptyPageRate(value)
+	if err != nil {
+		return 0
+	}
+	return rate
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestEmptyPages checks that a scenario with empty_page_rate returns pages
+// without items that still report has_more, but keeps the last page
+func TestEmptyPages(t *testing.T) {
+	originalAuth := *enableAuth
+	*enableAuth = false
+	defer func() { *enableAuth = originalAuth }()
+
+	originalManager := scenarioManager
+	defer func() { scenarioManager = originalManager }()
+
+	scenarioManager = &ScenarioManager{
+		scenarios: map[string]*Scenario{
+			"custom": {
+				ScenarioType: "custom",
+				BaseDelay:    "0ms",
+				ScenarioParams: &ScenarioParameters{
+					SimulationConfig: map[string]interface{}{emptyPageRateKey: 1.0},
+				},
+			},
+		},
+		validator: NewScenarioValidator(),
+	}
+
+	getPage := func(offset string) PaginatedResponse {
+		w := httptest.NewRecorder()
+		PaginatedPayloadHandler(w, httptest.NewRequest("GET", "/paginated_payload?scenario=custom&total=30&limit=10&offset="+offset, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("offset=%s: expected status 200, got %d", offset, w.Code)
+		}
+		var response PaginatedResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("offset=%s: failed to parse response: %v", offset, err)
+		}
+		return response
+	}
+
+	page := getPage("0")
+	if len(page.Result) != 0 {
+		t.Errorf("Expected an empty page, got %d items", len(page.Result))
+	}
+	if !page.Metadata.HasMore {
+		t.Error("Expected the empty page to report has_more")
+	}
+
+	if last := getPage("20"); len(last.Result) != 10 || last.Metadata.HasMore {
+		t.Errorf("Expected the last page to keep its 10 items, got %d items with has_more=%v", len(last.Result), last.Metadata.HasMore)
+	}
+}
+
+func TestEmptyPageRateValidation(t *testing.T) {
+	validator := NewScenarioValidator()
+
+	for _, value := range []interface{}{-0.1, 1.5, "0.5"} {
+		scenario := &Scenario{
+			ScenarioName: "Empty Page Test",
+			ScenarioType: "custom",
+			BaseDelay:    "10ms",
+			ScenarioParams: &ScenarioParameters{
+				SimulationConfig: map[string]interface{}{emptyPageRateKey: value},
+			},
+		}
+		if err := validator.ValidateScenario(scenario); err == nil {
+			t.Errorf("Expected validation error for empty_page_rate=%v", value)
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// recoveryAfterKey is the simulation_config key after how many requests a
+// scenario stops injecting errors for good
+const recoveryAfterKey = "recovery_after"
+
+// defaultErrorTypes is used when error injection is enabled without error_types
+var defaultErrorTypes = []string{"server_error"}
+
+// errorInjectionState tracks injected errors for a single scenario
+type errorInjectionState struct {
+	requests          int
+	consecutiveErrors int
+	recovering        bool
+	circuitOpenedAt   time.Time // Start of the current open period of a circuit_breaker scenario
+	circuitClosed     bool
+}
+
+// ErrorDecision is the outcome of error injection for a single request
+type ErrorDecision struct {
+	ErrorType     string        // Error to return, empty if the request should succeed
+	RecoveryDelay time.Duration // Extra delay for the first success after an error
+	CircuitState  string        // State of a circuit_breaker scenario, empty otherwise
+	RetryAfter    time.Duration // Time until an open circuit lets a probe through
+}
+
+// ServiceNowError is the error envelope the ServiceNow REST API answers
+// failed requests with
+type ServiceNowError struct {
+	Error  ServiceNowErrorDetail `json:"error"`
+	Status string                `json:"status"` // Always "failure"
+}
+
+// ServiceNowErrorDetail describes the error of a ServiceNowError
+type ServiceNowErrorDetail struct {
+	Message string `json:"message"`
+	Detail  string `json:"detail"`
+}
+
+// writeError replies with message and status code. In ServiceNow mode the
+// message is wrapped in a ServiceNowError envelope, otherwise it is sent as
+// plain text like http.Error.
+func writeError(w http.ResponseWriter, message string, code int, serviceNow bool) {
+	if !serviceNow {
+		http.Error(w, message, code)
+		return
+	}
+	body, err := json.Marshal(ServiceNowError{
+		Error:  ServiceNowErrorDetail{Message: http.StatusText(code), Detail: message},
+		Status: "failure",
+	})
+	if err != nil {
+		http.Error(w, message, code)
+		return
+	}
+	w.Header().Del("Content-Length")
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(code)
+	w.Write(append(body, '\n'))
+}
+
+// parseRecoveryAfter validates a recovery_after value, which must be a
+// positive whole number
+func parseRecoveryAfter(value interface{}) (int, error) {
+	n, ok := value.(float64)
+	if !ok || n < 1 || n != float64(int(n)) {
+		return 0, fmt.Errorf("%s must be a positive integer", recoveryAfterKey)
+	}
+	return int(n), nil
+}
+
+// NextErrorDecision decides whether the next request using the scenario fails.
+//
+// Errors are injected with the scenario's error_rate, but never more than
+// consecutive_error_limit times in a row. If simulation_config sets
+// recovery_after, the scenario instead fails its first recovery_after
+// requests (subject to error_rate) and succeeds for good afterwards.
+// The first success after an error is delayed by recovery_delay.
+//
+// A scenario with a circuit_breaker in simulation_config follows the circuit
+// instead, see nextCircuitDecision; error_injection is not needed for it.
+func (sm *ScenarioManager) NextErrorDecision(scenarioType string) ErrorDecision {
+	scenario := sm.GetScenario(scenarioType)
+	if circuit, ok := getCircuitBreaker(scenario); ok {
+		sm.errorsMu.Lock()
+		defer sm.errorsMu.Unlock()
+		return sm.errorState(scenarioType).nextCircuitDecision(circuit, time.Now())
+	}
+	if scenario == nil || scenario.ErrorInjection == nil || !scenario.ErrorInjection.Enabled {
+		return ErrorDecision{}
+	}
+	config := scenario.ErrorInjection
+
+	recoveryAfter := 0
+	if scenario.ScenarioParams != nil {
+		if value, ok := scenario.ScenarioParams.SimulationConfig[recoveryAfterKey]; ok {
+			recoveryAfter, _ = parseRecoveryAfter(value)
+		}
+	}
+
+	sm.errorsMu.Lock()
+	defer sm.errorsMu.Unlock()
+	state := sm.errorState(scenarioType)
+	state.requests++
+
+	inject := false
+	switch {
+	case recoveryAfter > 0:
+		inject = state.requests <= recoveryAfter && rollProbability(config.ErrorRate)
+	case config.ConsecutiveErrorLimit > 0 && state.consecutiveErrors >= config.ConsecutiveErrorLimit:
+		// Force a success after too many errors in a row
+	default:
+		inject = rollProbability(config.ErrorRate)
+	}
+
+	if inject {
+		state.consecutiveErrors++
+		state.recovering = true
+		return ErrorDecision{ErrorType: pickErrorType(config.ErrorTypes)}
+	}
+
+	decision := ErrorDecision{}
+	if state.recovering && config.RecoveryDelay != "" {
+		decision.RecoveryDelay, _ = ParseDelay(config.RecoveryDelay)
+	}
+	state.consecutiveErrors = 0
+	state.recovering = false
+	return decision
+}
+
+// errorState returns the error injection state of a scenario, creating it on
+// first use. The caller must hold errorsMu.
+func (sm *ScenarioManager) errorState(scenarioType string) *errorInjectionState {
+	if sm.errorStates == nil {
+		sm.errorStates = make(map[string]*errorInjectionState)
+	}
+	state, exists := sm.errorStates[scenarioType]
+	if !exists {
+		state = &errorInjectionState{}
+		sm.errorStates[scenarioType] = state
+	}
+	return state
+}
+
+// ResetErrorInjection forgets the error injection progress of all scenarios,
+// so for example error_storm fails its first requests again
+func (sm *ScenarioManager) ResetErrorInjection() {
+	sm.errorsMu.Lock()
+	defer sm.errorsMu.Unlock()
+	sm.errorStates = nil
+}
+
+// rollProbability returns true with the given probability
+func rollProbability(rate float64) bool {
+	if rate >= 1 {
+		return true
+	}
+	if rate <= 0 {
+		return false
+	}
+	randFloat, err := secureRandFloat32()
+	if err != nil {
+		return false
+	}
+	return float64(randFloat) < rate
+}
+
+// pickErrorType selects one of the configured error types at random
+func pickErrorType(errorTypes []string) string {
+	if len(errorTypes) == 0 {
+		errorTypes = defaultErrorTypes
+	}
+	idx, err := secureRandIntn(len(errorTypes))
+	if err != nil {
+		idx = 0
+	}
+	return errorTypes[idx]
+}
+
+// injectScenarioError applies the scenario's error injection to a request.
+// If an error is injected the error response is written, as a ServiceNow
+// error envelope in ServiceNow mode, and true is returned. Otherwise any
+// recovery delay is applied and the request continues.
+func injectScenarioError(w http.ResponseWriter, r *http.Request, scenario string, serviceNow bool) bool {
+	if scenarioManager == nil || scenario == "" {
+		return false
+	}
+
+	decision := scenarioManager.NextErrorDecision(scenario)
+	if decision.CircuitState != "" {
+		w.Header().Set("X-PayloadBuddy-Circuit-State", decision.CircuitState)
+	}
+	if decision.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(decision.RetryAfter.Seconds()))))
+	}
+	if decision.ErrorType == "" {
+		if decision.RecoveryDelay > 0 {
+			_ = sleepContext(r.Context(), decision.RecoveryDelay)
+		}
+		return false
+	}
+
+	writeInjectedError(w, decision.ErrorType, scenario, serviceNow)
+	return true
+}
+
+// resetConnection closes the client connection without completing the
+// response. It returns false if the connection cannot be hijacked (e.g.
+// HTTP/2 or a test recorder), in which case nothing is done.
+func resetConnection(w http.ResponseWriter) bool {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		return false
+	}
+	conn, _, err := hijacker.Hijack()
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// writeInjectedError writes the HTTP response simulating the given error type
+func writeInjectedError(w http.ResponseWriter, errorType, scenario string, serviceNow bool) {
+	w.Header().Set("X-PayloadBuddy-Injected-Error", errorType)
+	message := fmt.Sprintf("Injected %s error (scenario %s)", errorType, scenario)
+
+	switch errorType {
+	case "timeout":
+		writeError(w, message, http.StatusGatewayTimeout, serviceNow)
+	case "authentication_failure":
+		w.Header().Set("WWW-Authenticate", `Basic realm="payloadBuddy"`)
+		writeError(w, message, http.StatusUnauthorized, serviceNow)
+	case "bad_request":
+		writeError(w, message, http.StatusBadRequest, serviceNow)
+	case "rate_limit":
+		w.Header().Set("Retry-After", "1")
+		writeError(w, message, http.StatusTooManyRequests, serviceNow)
+	case circuitOpenError:
+		// Fail fast, Retry-After is set by the caller
+		writeError(w, message, http.StatusServiceUnavailable, serviceNow)
+	case "connection_reset":
+		// Drop the connection without a response where the server allows it
+		if resetConnection(w) {
+			return
+		}
+		writeError(w, message, http.StatusBadGateway, serviceNow)
+	default:
+		writeError(w, message, http.StatusInternalServerError, serviceNow)
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestErrorStormScenario(t *testing.T) {
+	originalAuth := *enableAuth
+	*enableAuth = false
+	defer func() { *enableAuth = originalAuth }()
+
+	originalManager := scenarioManager
+	defer func() { scenarioManager = originalManager }()
+
+	scenarioManager = &ScenarioManager{
+		scenarios: make(map[string]*Scenario),
+		validator: NewScenarioValidator(),
+	}
+	scenarioManager.loadEmbeddedScenarios()
+
+	if scenarioManager.GetScenario("error_storm") == nil {
+		t.Fatal("Expected embedded error_storm scenario")
+	}
+
+	// The embedded scenario recovers after 5 failed requests
+	const recoveryAfter = 5
+	for i := 1; i <= recoveryAfter+3; i++ {
+		handler := PaginatedPayloadHandler
+		if i%2 == 0 {
+			handler = StreamingPayloadHandler
+		}
+
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest("GET", "/?scenario=error_storm&limit=1&count=1", nil))
+
+		if i <= recoveryAfter {
+			if w.Code != http.StatusInternalServerError {
+				t.Errorf("Request %d: expected 500 during error storm, got %d", i, w.Code)
+			}
+			if got := w.Header().Get("X-PayloadBuddy-Injected-Error"); got != "server_error" {
+				t.Errorf("Request %d: expected injected server_error header, got %q", i, got)
+			}
+		} else if w.Code != http.StatusOK {
+			t.Errorf("Request %d: expected 200 after recovery, got %d", i, w.Code)
+		}
+	}
+}
+
+func TestNextErrorDecisionConsecutiveLimit(t *testing.T) {
+	sm := &ScenarioManager{
+		scenarios: map[string]*Scenario{
+			"custom": {
+				ScenarioType: "custom",
+				BaseDelay:    "10ms",
+				ErrorInjection: &ErrorInjectionConfig{
+					Enabled:               true,
+					ErrorRate:             1.0,
+					ErrorTypes:            []string{"rate_limit"},
+					ConsecutiveErrorLimit: 2,
+				},
+			},
+		},
+	}
+
+	// Every third request is forced to succeed
+	expected := []bool{true, true, false, true, true, false}
+	for i, wantError := range expected {
+		decision := sm.NextErrorDecision("custom")
+		if gotError := decision.ErrorType != ""; gotError != wantError {
+			t.Errorf("Request %d: expected error=%v, got %q", i+1, wantError, decision.ErrorType)
+		}
+	}
+
+	// Scenarios without error injection never fail
+	if decision := sm.NextErrorDecision("peak_hours"); decision.ErrorType != "" {
+		t.Errorf("Expected no error for scenario without error injection, got %q", decision.ErrorType)
+	}
+}
+
+func TestWriteInjectedError(t *testing.T) {
+	tests := map[string]int{
+		"timeout":                http.StatusGatewayTimeout,
+		"authentication_failure": http.StatusUnauthorized,
+		"server_error":           http.StatusInternalServerError,
+		"bad_request":            http.StatusBadRequest,
+		"rate_limit":             http.StatusTooManyRequests,
+		"connection_reset":       http.StatusBadGateway, // recorder cannot be hijacked
+	}
+
+	for errorType, expectedStatus := range tests {
+		w := httptest.NewRecorder()
+		writeInjectedError(w, errorType, "custom", false)
+		if w.Code != expectedStatus {
+			t.Errorf("%s: expected status %d, got %d", errorType, expectedStatus, w.Code)
+		}
+	}
+}
+
+func TestServiceNowErrorEnvelope(t *testing.T) {
+	originalAuth := *enableAuth
+	*enableAuth = false
+	defer func() { *enableAuth = originalAuth }()
+
+	originalManager := scenarioManager
+	defer func() { scenarioManager = originalManager }()
+
+	scenarioManager = &ScenarioManager{
+		scenarios: map[string]*Scenario{
+			"custom": {
+				ScenarioType: "custom",
+				BaseDelay:    "10ms",
+				ErrorInjection: &ErrorInjectionConfig{
+					Enabled:    true,
+					ErrorRate:  1.0,
+					ErrorTypes: []string{"rate_limit"},
+				},
+			},
+		},
+		validator: NewScenarioValidator(),
+	}
+
+	w := httptest.NewRecorder()
+	PaginatedPayloadHandler(w, httptest.NewRequest("GET", "/paginated_payload?scenario=custom&servicenow=true", nil))
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status 429, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Expected JSON content type, got %q", ct)
+	}
+	var envelope ServiceNowError
+	if err := json.Unmarshal(w.Body.Bytes(), &envelope); err != nil {
+		t.Fatalf("Expected a ServiceNow error envelope, got %q: %v", w.Body.String(), err)
+	}
+	if envelope.Status != "failure" || envelope.Error.Message != "Too Many Requests" || envelope.Error.Detail == "" {
+		t.Errorf("Unexpected envelope: %+v", envelope)
+	}
+
+	// Outside ServiceNow mode the error stays plain text
+	w = httptest.NewRecorder()
+	StreamingPayloadHandler(w, httptest.NewRequest("GET", "/stream_payload?scenario=custom&servicenow=false", nil))
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status 429, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "text/plain; charset=utf-8" {
+		t.Errorf("Expected plain text content type, got %q", ct)
+	}
+}
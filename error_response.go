@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Error codes of JSON error responses. They name the kind of failure, the
+// message describes the specific problem.
+const (
+	errCodeBadRequest        = "bad_request"
+	errCodeUnauthorized      = "unauthorized"
+	errCodeForbidden         = "forbidden"
+	errCodeNotFound          = "not_found"
+	errCodeMethodNotAllowed  = "method_not_allowed"
+	errCodeNotAcceptable     = "not_acceptable"
+	errCodePayloadTooLarge   = "payload_too_large"
+	errCodeExpectationFailed = "expectation_failed"
+	errCodeInternal          = "internal_error"
+	errCodeBadGateway        = "bad_gateway"
+	errCodeTimeout           = "timeout"
+)
+
+// errorCodeDescriptions documents the error codes in the OpenAPI spec
+var errorCodeDescriptions = map[string]string{
+	errCodeBadRequest:        "A query parameter or the request body is invalid (400)",
+	errCodeUnauthorized:      "Missing or wrong credentials with -auth (401)",
+	errCodeForbidden:         "The scenario is not allowed by -allow-scenarios or -deny-scenarios (403)",
+	errCodeNotFound:          "No recording matches the request (404)",
+	errCodeMethodNotAllowed:  "The endpoint does not accept the HTTP method (405)",
+	errCodeNotAcceptable:     "None of the response formats is acceptable (406)",
+	errCodePayloadTooLarge:   "The request body, or a /batch sub-response, exceeds the endpoint's limit (413)",
+	errCodeExpectationFailed: "Expect: 100-continue was rejected with expect_fail=true (417)",
+	errCodeInternal:          "The response could not be generated (500)",
+	errCodeBadGateway:        "The upstream of a recording failed (502)",
+	errCodeTimeout:           "The request took longer than -request-timeout (503)",
+}
+
+// ErrorResponse is the JSON error object sent to clients that accept JSON
+type ErrorResponse struct {
+	Error ErrorDetail `json:"error"`
+}
+
+// ErrorDetail describes an error by a stable code and a readable message
+type ErrorDetail struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// acceptsJSONError reports whether the client prefers a JSON error over
+// plain text. Clients without an Accept header, or with */*, get plain text
+// as before.
+func acceptsJSONError(r *http.Request) bool {
+	ranges := parseAccept(r.Header.Get("Accept"))
+	jsonQuality := mediaTypeQuality(ranges, "application/json")
+	return jsonQuality > 0 && jsonQuality > mediaTypeQuality(ranges, "text/plain")
+}
+
+// writeErrorResponse replies with the status and a JSON error object if the
+// client accepts JSON, otherwise with the message as plain text like
+// http.Error. Injected ServiceNow errors use writeError instead.
+func writeErrorResponse(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	if !acceptsJSONError(r) {
+		http.Error(w, message, status)
+		return
+	}
+	body, err := json.Marshal(ErrorResponse{Error: ErrorDetail{Code: code, Message: message}})
+	if err != nil {
+		http.Error(w, message, status)
+		return
+	}
+	w.Header().Del("Content-Length")
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	w.Write(append(body, '\n'))
+}
+
+// errorResponseSchema returns the OpenAPI schema of ErrorResponse
+func errorResponseSchema() *OpenAPISchema {
+	codes := make([]string, 0, len(errorCodeDescriptions))
+	for code := range errorCodeDescriptions {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	enum := make([]interface{}, len(codes))
+	lines := make([]string, len(codes))
+	for i, code := range codes {
+		enum[i] = code
+		lines[i] = "- " + code + ": " + errorCodeDescriptions[code]
+	}
+
+	return &OpenAPISchema{
+		Type:        "object",
+		Description: "Error returned instead of plain text when the request's Accept header prefers application/json",
+		Properties: map[string]*OpenAPISchema{
+			"error": {
+				Type: "object",
+				Properties: map[string]*OpenAPISchema{
+					"code": {
+						Type:        "string",
+						Description: "Stable error code:\n" + strings.Join(lines, "\n"),
+						Enum:        enum,
+					},
+					"message": {Type: "string", Description: "Readable description of the problem"},
+				},
+				Required: []string{"code", "message"},
+			},
+		},
+		Required: []string{"error"},
+		Example: ErrorResponse{Error: ErrorDetail{
+			Code:    errCodeBadRequest,
+			Message: "count must be between 1 and 1000",
+		}},
+	}
+}
+
+// addJSONErrorContent documents the JSON error object on every error
+// response with a plain text body
+func addJSONErrorContent(spec *OpenAPISpec) {
+	ref := &OpenAPISchema{Ref: "#/components/schemas/Error"}
+	for _, pathItem := range spec.Paths {
+		for _, op := range []*OpenAPIOperation{pathItem.Get, pathItem.Post, pathItem.Put, pathItem.Delete} {
+			if op == nil {
+				continue
+			}
+			for status, response := range op.Responses {
+				if status < "400" || response.Content == nil {
+					continue
+				}
+				if _, ok := response.Content["text/plain"]; !ok {
+					continue
+				}
+				if _, ok := response.Content["application/json"]; !ok {
+					response.Content["application/json"] = OpenAPIMediaType{Schema: ref}
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestWriteErrorResponse checks that a 400 is a JSON error object for
+// clients that accept JSON and plain text otherwise
+func TestWriteErrorResponse(t *testing.T) {
+	originalAuth := *enableAuth
+	*enableAuth = false
+	defer func() { *enableAuth = originalAuth }()
+
+	req := httptest.NewRequest("GET", "/longpoll?count=0", nil)
+	req.Header.Set("Accept", "application/json")
+	w := httptest.NewRecorder()
+	LongPollHandler(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Expected Content-Type application/json, got %q", ct)
+	}
+	var body map[string]map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Expected a JSON error object, got %q: %v", w.Body.String(), err)
+	}
+	detail, ok := body["error"]
+	if !ok || len(body) != 1 {
+		t.Fatalf("Expected a single error member, got %v", body)
+	}
+	if detail["code"] != errCodeBadRequest {
+		t.Errorf("Expected code %q, got %v", errCodeBadRequest, detail["code"])
+	}
+	if message, _ := detail["message"].(string); !strings.Contains(message, "count must be between") {
+		t.Errorf("Expected the validation message, got %v", detail["message"])
+	}
+
+	// Without a preference for JSON the error stays plain text
+	w = httptest.NewRecorder()
+	LongPollHandler(w, httptest.NewRequest("GET", "/longpoll?count=0", nil))
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("Expected a plain text error without Accept, got %q", ct)
+	}
+}
+
+func TestAcceptsJSONError(t *testing.T) {
+	tests := []struct {
+		accept   string
+		expected bool
+	}{
+		{"", false},
+		{"*/*", false},
+		{"application/json", true},
+		{"application/json, text/plain;q=0.5", true},
+		{"text/plain, application/json;q=0.5", false},
+		{"application/*", true},
+		{"application/json;q=0", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			if got := acceptsJSONError(req); got != tt.expected {
+				t.Errorf("Expected %v for Accept %q, got %v", tt.expected, tt.accept, got)
+			}
+		})
+	}
+}
+
+// TestOpenAPIHandler_ErrorSchema checks that error responses document the
+// JSON error object
+func TestOpenAPIHandler_ErrorSchema(t *testing.T) {
+	w := httptest.NewRecorder()
+	OpenAPIHandler(w, httptest.NewRequest("GET", "/openapi.json", nil))
+
+	var spec OpenAPISpec
+	if err := json.Unmarshal(w.Body.Bytes(), &spec); err != nil {
+		t.Fatalf("Failed to parse spec: %v", err)
+	}
+	schema := spec.Components.Schemas["Error"]
+	if schema == nil || schema.Properties["error"] == nil {
+		t.Fatal("Expected an Error schema with an error property")
+	}
+	if codes := schema.Properties["error"].Properties["code"].Enum; len(codes) != len(errorCodeDescriptions) {
+		t.Errorf("Expected %d documented error codes, got %v", len(errorCodeDescriptions), codes)
+	}
+
+	response := spec.Paths["/longpoll"].Get.Responses["400"]
+	if media, ok := response.Content["application/json"]; !ok || media.Schema.Ref != "#/components/schemas/Error" {
+		t.Errorf("Expected the 400 response to reference the Error schema, got %+v", response.Content)
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// maxContinueDelay bounds the continue_delay parameter
+	maxContinueDelay = 5 * time.Minute
+
+	// continueReadTime is the time left to read the request body after the
+	// delay, the same as the server's ReadTimeout
+	continueReadTime = 30 * time.Second
+)
+
+// expectContinuePaths are the endpoints that read a request body and
+// support continue_delay and expect_fail
+var expectContinuePaths = map[string]bool{
+	"/slow-read": true,
+	"/batch":     true,
+}
+
+// expectsContinue reports whether the client waits for 100 Continue before
+// sending the request body
+func expectsContinue(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Expect"), "100-continue")
+}
+
+// getContinueDelay parses the continue_delay query parameter, how long the
+// 100 Continue response is held back (e.g. "3s"). It returns 0 if unset.
+func getContinueDelay(r *http.Request) (time.Duration, error) {
+	val := r.URL.Query().Get("continue_delay")
+	if val == "" {
+		return 0, nil
+	}
+	delay, err := time.ParseDuration(val)
+	if err != nil || delay < 0 || delay > maxContinueDelay {
+		return 0, fmt.Errorf("continue_delay must be a duration between 0s and %v (e.g. 3s)", maxContinueDelay)
+	}
+	return delay, nil
+}
+
+// expectContinueMiddleware controls the 100 Continue response to requests
+// with "Expect: 100-continue", for testing how clients behave before large
+// uploads. The server sends 100 Continue when the handler starts reading the
+// body, so:
+//
+//   - continue_delay holds it back by sleeping before the handler runs.
+//     Clients that stop waiting send the body anyway, which is then read as
+//     usual; a delay above the client's expect timeout withholds the 100
+//     Continue from its point of view.
+//   - expect_fail=true answers 417 Expectation Failed without reading the
+//     body, so the client has to retry without the expectation.
+//
+// Requests without the expectation and other paths are passed to next
+// unchanged.
+func expectContinueMiddleware(path string, next http.HandlerFunc) http.HandlerFunc {
+	if !expectContinuePaths[path] {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !expectsContinue(r) {
+			next(w, r)
+			return
+		}
+
+		if r.URL.Query().Get("expect_fail") == "true" {
+			writeErrorResponse(w, r, http.StatusExpectationFailed, errCodeExpectationFailed, "Expectation failed, send the request without Expect: 100-continue")
+			return
+		}
+		delay, err := getContinueDelay(r)
+		if err != nil {
+			writeErrorResponse(w, r, http.StatusBadRequest, errCodeBadRequest, err.Error())
+			return
+		}
+		if delay > 0 {
+			// The delay may outlast the server's ReadTimeout; not every
+			// ResponseWriter supports deadlines, in which case the server
+			// default applies
+			http.NewResponseController(w).SetReadDeadline(time.Now().Add(delay + continueReadTime))
+			if err := sleepContext(r.Context(), delay); err != nil {
+				return
+			}
+		}
+		next(w, r)
+	}
+}
+
+// expectContinueOpenAPIParameters documents continue_delay and expect_fail
+// for the endpoints in expectContinuePaths
+func expectContinueOpenAPIParameters() []OpenAPIParameter {
+	return []OpenAPIParameter{
+		{
+			Name:        "continue_delay",
+			In:          "query",
+			Description: fmt.Sprintf("With Expect: 100-continue, hold back the 100 Continue response by this duration (max: %v); clients that stop waiting send the body anyway", maxContinueDelay),
+			Required:    false,
+			Schema: &OpenAPISchema{
+				Type:    "string",
+				Example: "3s",
+			},
+		},
+		{
+			Name:        "expect_fail",
+			In:          "query",
+			Description: "With Expect: 100-continue, answer 417 Expectation Failed without reading the body",
+			Required:    false,
+			Schema: &OpenAPISchema{
+				Type:    "boolean",
+				Example: true,
+			},
+		},
+	}
+}
+
+// expectContinueOpenAPIResponse documents the 417 response to expect_fail
+func expectContinueOpenAPIResponse() OpenAPIResponse {
+	return OpenAPIResponse{
+		Description: "Expectation failed - Expect: 100-continue was sent with expect_fail=true",
+		Content: map[string]OpenAPIMediaType{
+			"text/plain": {
+				Schema: &OpenAPISchema{
+					Type:    "string",
+					Example: "Expectation failed, send the request without Expect: 100-continue",
+				},
+			},
+		},
+	}
+}
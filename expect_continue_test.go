@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingReader counts the bytes the client transport reads from the body
+type countingReader struct {
+	r io.Reader
+	n atomic.Int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n.Add(int64(n))
+	return n, err
+}
+
+// expectContinueResult is what a client saw of an Expect: 100-continue request
+type expectContinueResult struct {
+	status    int
+	body      []byte
+	got100    bool
+	wroteBody time.Duration // time until the body was sent
+	bodyRead  int64         // body bytes taken by the transport
+}
+
+// postExpectContinue posts size bytes with "Expect: 100-continue" and a client
+// that waits up to wait for 100 Continue before sending the body anyway
+func postExpectContinue(t *testing.T, url string, size int, wait time.Duration) expectContinueResult {
+	t.Helper()
+
+	var result expectContinueResult
+	body := &countingReader{r: bytes.NewReader(make([]byte, size))}
+	req, err := http.NewRequest("POST", url, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.ContentLength = int64(size)
+	req.Header.Set("Expect", "100-continue")
+
+	start := time.Now()
+	var got100 atomic.Bool
+	var wroteBody atomic.Int64
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), &httptrace.ClientTrace{
+		Got100Continue: func() { got100.Store(true) },
+		WroteRequest:   func(httptrace.WroteRequestInfo) { wroteBody.Store(int64(time.Since(start))) },
+	}))
+
+	client := &http.Client{Transport: &http.Transport{ExpectContinueTimeout: wait}}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	result.status = resp.StatusCode
+	result.body, _ = io.ReadAll(resp.Body)
+	result.got100 = got100.Load()
+	result.wroteBody = time.Duration(wroteBody.Load())
+	result.bodyRead = body.n.Load()
+	return result
+}
+
+func TestExpectContinue(t *testing.T) {
+	server := httptest.NewServer(expectContinueMiddleware("/slow-read", SlowReadHandler))
+	defer server.Close()
+	const size = 4096
+
+	// Without parameters the 100 Continue is sent and the body read
+	result := postExpectContinue(t, server.URL+"?rate=1048576", size, 5*time.Second)
+	if result.status != http.StatusOK || !result.got100 {
+		t.Fatalf("default: expected 200 after 100 Continue, got %d (100 Continue: %v)", result.status, result.got100)
+	}
+	if result.wroteBody > 2*time.Second {
+		t.Errorf("default: body sent after %v, expected 100 Continue right away", result.wroteBody)
+	}
+	var slowRead SlowReadResult
+	if err := json.Unmarshal(result.body, &slowRead); err != nil || slowRead.BytesRead != size {
+		t.Errorf("default: expected %d bytes read, got %s", size, result.body)
+	}
+
+	// A delay longer than the client waits: the client falls back to sending
+	// the body, which is still read
+	result = postExpectContinue(t, server.URL+"?rate=1048576&continue_delay=500ms", size, 50*time.Millisecond)
+	if result.status != http.StatusOK {
+		t.Fatalf("delay: expected status 200, got %d: %s", result.status, result.body)
+	}
+	if result.wroteBody >= 500*time.Millisecond {
+		t.Errorf("delay: body sent after %v, expected the client to stop waiting first", result.wroteBody)
+	}
+	if err := json.Unmarshal(result.body, &slowRead); err != nil || slowRead.BytesRead != size {
+		t.Errorf("delay: expected %d bytes read, got %s", size, result.body)
+	}
+
+	// A delay the client waits for: the body follows the late 100 Continue
+	result = postExpectContinue(t, server.URL+"?rate=1048576&continue_delay=200ms", size, 5*time.Second)
+	if result.status != http.StatusOK || !result.got100 {
+		t.Fatalf("waited delay: expected 200 after 100 Continue, got %d (100 Continue: %v)", result.status, result.got100)
+	}
+	if result.wroteBody < 200*time.Millisecond {
+		t.Errorf("waited delay: body sent after %v, expected the 100 Continue to be held back", result.wroteBody)
+	}
+
+	// Invalid delays are rejected before the body is sent
+	for _, delay := range []string{"soon", "-1s", "6m"} {
+		result = postExpectContinue(t, server.URL+"?continue_delay="+delay, size, 5*time.Second)
+		if result.status != http.StatusBadRequest || result.bodyRead != 0 {
+			t.Errorf("continue_delay=%s: expected 400 without the body, got %d after %d body bytes", delay, result.status, result.bodyRead)
+		}
+	}
+}
+
+func TestExpectContinueFail(t *testing.T) {
+	for path, handler := range map[string]http.HandlerFunc{"/slow-read": SlowReadHandler, "/batch": BatchHandler} {
+		server := httptest.NewServer(expectContinueMiddleware(path, handler))
+
+		result := postExpectContinue(t, server.URL+"?expect_fail=true", 4096, 5*time.Second)
+		if result.status != http.StatusExpectationFailed {
+			t.Errorf("%s: expected status 417, got %d", path, result.status)
+		}
+		if result.got100 || result.bodyRead != 0 {
+			t.Errorf("%s: expected no 100 Continue and no body, got 100 Continue %v after %d body bytes", path, result.got100, result.bodyRead)
+		}
+		if !strings.Contains(string(result.body), "Expectation failed") {
+			t.Errorf("%s: unexpected body %q", path, result.body)
+		}
+
+		// Without the expectation, expect_fail has no effect
+		resp, err := http.Post(server.URL+"?expect_fail=true", "application/json", strings.NewReader(`[{"url":"/health"}]`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusExpectationFailed {
+			t.Errorf("%s: expected expect_fail to be ignored without Expect: 100-continue", path)
+		}
+		server.Close()
+	}
+}
+
+// TestExpectContinueOtherPaths checks that endpoints without a request body
+// are left unwrapped
+func TestExpectContinueOtherPaths(t *testing.T) {
+	req := httptest.NewRequest("POST", "/rest_payload?count=1&expect_fail=true", strings.NewReader("x"))
+	req.Header.Set("Expect", "100-continue")
+	w := httptest.NewRecorder()
+	expectContinueMiddleware("/rest_payload", RestPayloadHandler)(w, req)
+	if w.Code == http.StatusExpectationFailed {
+		t.Error("expected /rest_payload to ignore expect_fail")
+	}
+}
@@ -0,0 +1,320 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limits for the generate endpoint
+const (
+	defaultGenerateCount = 10
+	maxGenerateCount     = 10000
+	maxGenerateFields    = 100
+)
+
+// generateFieldTypes are the field types the generate endpoint understands
+var generateFieldTypes = []string{"string", "int", "float", "bool", "timestamp"}
+
+// GeneratePlugin implements PayloadPlugin for items of a caller-defined shape
+type GeneratePlugin struct{}
+
+// Path returns the HTTP path for the generate endpoint
+func (p GeneratePlugin) Path() string {
+	return "/generate"
+}
+
+// Handler returns the handler function for the generate endpoint
+func (p GeneratePlugin) Handler() http.HandlerFunc {
+	return GenerateHandler
+}
+
+func init() {
+	registerPlugin(GeneratePlugin{})
+}
+
+// generateField is one name:type pair of a field spec
+type generateField struct {
+	Name string
+	Type string
+}
+
+// parseGenerateFields parses a field spec such as
+// "name:string,age:int,active:bool"
+func parseGenerateFields(spec string) ([]generateField, error) {
+	if spec == "" {
+		return nil, fmt.Errorf("fields is required (e.g. name:string,age:int,active:bool)")
+	}
+	var fields []generateField
+	seen := make(map[string]bool)
+	for _, entry := range strings.Split(spec, ",") {
+		name, fieldType, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		name = strings.TrimSpace(name)
+		fieldType = strings.ToLower(strings.TrimSpace(fieldType))
+		if !ok || name == "" {
+			return nil, fmt.Errorf("field %q must be name:type", entry)
+		}
+		if !isValidGenerateFieldType(fieldType) {
+			return nil, fmt.Errorf("field %q has unknown type %q (valid: %s)", name, fieldType, strings.Join(generateFieldTypes, ", "))
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("field %q is listed more than once", name)
+		}
+		seen[name] = true
+		fields = append(fields, generateField{Name: name, Type: fieldType})
+	}
+	if len(fields) > maxGenerateFields {
+		return nil, fmt.Errorf("fields must not list more than %d fields", maxGenerateFields)
+	}
+	return fields, nil
+}
+
+// isValidGenerateFieldType reports whether fieldType is a known field type
+func isValidGenerateFieldType(fieldType string) bool {
+	for _, valid := range generateFieldTypes {
+		if fieldType == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// value returns the generated value of the field for the item at the given
+// position. Values are derived from the position, so repeated requests
+// return the same data apart from timestamps.
+func (f generateField) value(position int, now time.Time) interface{} {
+	switch f.Type {
+	case "int":
+		return position + 1
+	case "float":
+		return float64(position+1) + 0.5
+	case "bool":
+		return position%2 == 0
+	case "timestamp":
+		return now.Format(time.RFC3339)
+	default:
+		return fmt.Sprintf("%s %d", f.Name, position+1)
+	}
+}
+
+// seededValue returns the value of the field for the item at the given
+// position, derived from the seed, the item number and the field name.
+// Timestamps fall into 2024, so seeded items are identical on every request.
+func (f generateField) seededValue(seed uint64, position int) interface{} {
+	rng := fieldRand(seed, position+1, f.Name)
+	switch f.Type {
+	case "int":
+		return rng.IntN(10000)
+	case "float":
+		return math.Round(rng.Float64()*100000) / 100
+	case "bool":
+		return rng.IntN(2) == 0
+	case "timestamp":
+		return seededTimestamp(rng).Format(time.RFC3339)
+	default:
+		return seededWords(rng)
+	}
+}
+
+// generatedItem is an item with the fields in the order of the field spec
+type generatedItem struct {
+	fields []generateField
+	values []interface{}
+}
+
+// MarshalJSON writes the fields in spec order, unlike a map which would be
+// sorted by key
+func (item generatedItem) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, field := range item.fields {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(field.Name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(item.values[i])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// generateTypedItems creates count items with the given fields, with seeded
+// values if seeded is set
+func generateTypedItems(fields []generateField, count int, seed uint64, seeded bool) []generatedItem {
+	now := time.Now().UTC()
+	items := make([]generatedItem, count)
+	for i := range items {
+		values := make([]interface{}, len(fields))
+		for j, field := range fields {
+			if seeded {
+				values[j] = field.seededValue(seed, i)
+			} else {
+				values[j] = field.value(i, now)
+			}
+		}
+		items[i] = generatedItem{fields: fields, values: values}
+	}
+	return items
+}
+
+// nullValues sets each value of the items to null with probability rate
+func nullValues(items []generatedItem, rate float64) {
+	for _, item := range items {
+		for i := range item.values {
+			if rollProbability(rate) {
+				item.values[i] = nil
+			}
+		}
+	}
+}
+
+// GenerateHandler returns items of a shape defined by the caller, for
+// testing clients against schemas beyond the fixed item structure.
+//
+// Query Parameters:
+//   - fields: Comma separated name:type pairs (e.g., "name:string,age:int,active:bool", required).
+//     Types: string, int, float, bool, timestamp
+//   - count: Number of items to return (default: 10, max: 10000)
+//   - seed: Derive varied values from the seed, item number and field name instead of the position
+//   - null_rate: Probability (0.0-1.0) of each value being null (default: 0)
+//
+// Fields appear in each item in the order they are listed.
+func GenerateHandler(w http.ResponseWriter, r *http.Request) {
+	fields, err := parseGenerateFields(r.URL.Query().Get("fields"))
+	if err != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, errCodeBadRequest, err.Error())
+		return
+	}
+	count := getIntParam(r, "count", defaultGenerateCount)
+	if count < 1 || count > maxGenerateCount {
+		writeErrorResponse(w, r, http.StatusBadRequest, errCodeBadRequest, fmt.Sprintf("count must be between 1 and %d", maxGenerateCount))
+		return
+	}
+
+	var seed uint64
+	seedParam := r.URL.Query().Get("seed")
+	if seedParam != "" {
+		seed, err = strconv.ParseUint(seedParam, 10, 64)
+		if err != nil {
+			writeErrorResponse(w, r, http.StatusBadRequest, errCodeBadRequest, "seed must be a non-negative integer")
+			return
+		}
+	}
+
+	nullRate, err := getRateParam(r, "null_rate", 0)
+	if err != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, errCodeBadRequest, err.Error())
+		return
+	}
+
+	items := generateTypedItems(fields, count, seed, seedParam != "")
+	nullValues(items, nullRate)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(items); err != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, errCodeInternal, "Failed to encode response")
+	}
+}
+
+// OpenAPISpec returns the OpenAPI specification for the generate endpoint
+func (p GeneratePlugin) OpenAPISpec() OpenAPIPathSpec {
+	return OpenAPIPathSpec{
+		Path: "/generate",
+		Operation: OpenAPIPath{
+			Get: &OpenAPIOperation{
+				OperationID: "getGenerated",
+				Summary:     "Generate items with caller-defined fields",
+				Description: "Returns items whose fields and JSON types are given by the fields parameter, for schema-flexibility testing",
+				Tags:        []string{"payload"},
+				Parameters: []OpenAPIParameter{
+					{
+						Name:        "fields",
+						In:          "query",
+						Description: "Comma separated name:type pairs in output order. Types: " + strings.Join(generateFieldTypes, ", "),
+						Required:    true,
+						Schema: &OpenAPISchema{
+							Type:    "string",
+							Example: "name:string,age:int,active:bool",
+						},
+					},
+					{
+						Name:        "count",
+						In:          "query",
+						Description: "Number of items to return (default: 10)",
+						Required:    false,
+						Schema: &OpenAPISchema{
+							Type:    "integer",
+							Minimum: &[]int{1}[0],
+							Maximum: &[]int{maxGenerateCount}[0],
+							Example: 10,
+						},
+					},
+					{
+						Name:        "seed",
+						In:          "query",
+						Description: "Seed for varied but reproducible values: each value is derived from the seed, the item number and the field name, timestamps fall into 2024",
+						Required:    false,
+						Schema: &OpenAPISchema{
+							Type:    "integer",
+							Minimum: &[]int{0}[0],
+							Example: 42,
+						},
+					},
+					{
+						Name:        "null_rate",
+						In:          "query",
+						Description: "Probability (0.0-1.0) of each value being JSON null, independently per field and item (default: 0). Applies after seed, so seeded nulls vary between requests",
+						Required:    false,
+						Schema: &OpenAPISchema{
+							Type:    "number",
+							Example: 0.1,
+						},
+					},
+				},
+				Responses: map[string]OpenAPIResponse{
+					"200": {
+						Description: "Items with the requested fields",
+						Content: map[string]OpenAPIMediaType{
+							"application/json": {
+								Schema: &OpenAPISchema{
+									Type: "array",
+									Items: &OpenAPISchema{
+										Type:        "object",
+										Description: "One property per requested field",
+									},
+									Example: []map[string]interface{}{
+										{"name": "name 1", "age": 1, "active": true},
+									},
+								},
+							},
+						},
+					},
+					"400": {
+						Description: "Bad request - missing or invalid fields, or invalid count, seed or null_rate",
+						Content: map[string]OpenAPIMediaType{
+							"text/plain": {
+								Schema: &OpenAPISchema{
+									Type:    "string",
+									Example: "field \"age\" has unknown type \"integer\" (valid: string, int, float, bool, timestamp)",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
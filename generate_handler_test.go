@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestGenerateHandler checks that every generated field has the requested
+// JSON type and that fields keep the order of the spec
+func TestGenerateHandler(t *testing.T) {
+	w := httptest.NewRecorder()
+	GenerateHandler(w, httptest.NewRequest("GET", "/generate?count=3&fields=name:string,age:int,score:float,active:bool,seen:timestamp", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var items []map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if len(items) != 3 {
+		t.Fatalf("Expected 3 items, got %d", len(items))
+	}
+	for i, item := range items {
+		if len(item) != 5 {
+			t.Errorf("Item %d: expected 5 fields, got %v", i, item)
+		}
+		if _, ok := item["name"].(string); !ok {
+			t.Errorf("Item %d: expected name to be a string, got %T", i, item["name"])
+		}
+		if age, ok := item["age"].(float64); !ok || age != float64(int(age)) {
+			t.Errorf("Item %d: expected age to be an integer, got %v", i, item["age"])
+		}
+		if _, ok := item["score"].(float64); !ok {
+			t.Errorf("Item %d: expected score to be a number, got %T", i, item["score"])
+		}
+		if _, ok := item["active"].(bool); !ok {
+			t.Errorf("Item %d: expected active to be a boolean, got %T", i, item["active"])
+		}
+		seen, ok := item["seen"].(string)
+		if !ok {
+			t.Fatalf("Item %d: expected seen to be a string, got %T", i, item["seen"])
+		}
+		if _, err := time.Parse(time.RFC3339, seen); err != nil {
+			t.Errorf("Item %d: expected seen to be an RFC 3339 timestamp: %v", i, err)
+		}
+	}
+
+	// Fields are written in the order of the spec, not sorted
+	body := w.Body.String()
+	if !(strings.Index(body, `"name"`) < strings.Index(body, `"age"`) && strings.Index(body, `"age"`) < strings.Index(body, `"active"`)) {
+		t.Errorf("Expected fields in spec order, got %s", body)
+	}
+}
+
+func TestGenerateHandler_InvalidParams(t *testing.T) {
+	tests := []string{
+		"/generate",
+		"/generate?fields=name",
+		"/generate?fields=:string",
+		"/generate?fields=age:integer",
+		"/generate?fields=name:string,name:int",
+		"/generate?fields=name:string,",
+		"/generate?fields=name:string&count=0",
+		"/generate?fields=name:string&count=10001",
+	}
+
+	for _, url := range tests {
+		t.Run(url, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			GenerateHandler(w, httptest.NewRequest("GET", url, nil))
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400, got %d", w.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// gzipReadCloser closes both the gzip reader and the underlying body
+type gzipReadCloser struct {
+	*gzip.Reader
+	body io.ReadCloser
+}
+
+func (g gzipReadCloser) Close() error {
+	g.Reader.Close()
+	return g.body.Close()
+}
+
+// gzipRequestMiddleware transparently decompresses request bodies sent with
+// Content-Encoding: gzip, so POST endpoints accept compressed uploads. Size
+// limits of the handlers apply to the decompressed body. A body that is not
+// valid gzip is rejected with 400 Bad Request.
+func gzipRequestMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !strings.EqualFold(strings.TrimSpace(r.Header.Get("Content-Encoding")), "gzip") {
+			next(w, r)
+			return
+		}
+
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			writeErrorResponse(w, r, http.StatusBadRequest, errCodeBadRequest, "Invalid gzip request body: "+err.Error())
+			return
+		}
+		r.Body = gzipReadCloser{Reader: zr, body: r.Body}
+		r.Header.Del("Content-Encoding")
+		r.Header.Del("Content-Length")
+		r.ContentLength = -1
+		next(w, r)
+	}
+}
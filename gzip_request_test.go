@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// gzipBody returns data gzip compressed
+func gzipBody(t *testing.T, data []byte) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		t.Fatalf("gzip write failed: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("gzip close failed: %v", err)
+	}
+	return &buf
+}
+
+// TestGzipRequestMiddleware_Scenario posts a gzip-encoded scenario and
+// checks that the handler receives a body that validates.
+func TestGzipRequestMiddleware_Scenario(t *testing.T) {
+	scenario, err := scaffoldScenario("peak_hours")
+	if err != nil {
+		t.Fatalf("scaffoldScenario failed: %v", err)
+	}
+
+	var validationErr error
+	handler := gzipRequestMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Encoding") != "" {
+			t.Error("Expected Content-Encoding to be removed after decompression")
+		}
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("Reading body failed: %v", err)
+		}
+		_, validationErr = NewScenarioValidator().ValidateJSON(data)
+	})
+
+	req := httptest.NewRequest("POST", "/scenarios", gzipBody(t, scenario))
+	req.Header.Set("Content-Encoding", "gzip")
+	handler(httptest.NewRecorder(), req)
+
+	if validationErr != nil {
+		t.Errorf("Expected the decompressed scenario to validate, got: %v", validationErr)
+	}
+}
+
+func TestGzipRequestMiddleware_SlowRead(t *testing.T) {
+	body := strings.Repeat("x", 2000)
+	req := httptest.NewRequest("POST", "/slow-read", gzipBody(t, []byte(body)))
+	req.Header.Set("Content-Encoding", "gzip")
+	w := httptest.NewRecorder()
+
+	gzipRequestMiddleware(SlowReadHandler)(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var result SlowReadResult
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse JSON response: %v", err)
+	}
+	if result.BytesRead != 2000 {
+		t.Errorf("Expected 2000 decompressed bytes read, got %d", result.BytesRead)
+	}
+}
+
+func TestGzipRequestMiddleware_InvalidBody(t *testing.T) {
+	called := false
+	req := httptest.NewRequest("POST", "/batch", strings.NewReader("not gzip"))
+	req.Header.Set("Content-Encoding", "gzip")
+	w := httptest.NewRecorder()
+
+	gzipRequestMiddleware(func(w http.ResponseWriter, r *http.Request) { called = true })(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid gzip body, got %d", w.Code)
+	}
+	if called {
+		t.Error("Expected the handler not to be called")
+	}
+}
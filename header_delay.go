@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	// maxHeaderDelay bounds the header_delay parameter
+	maxHeaderDelay = 5 * time.Minute
+
+	// headerDelayWriteTime is the time left to write the response after the
+	// delay, the same as the server's WriteTimeout
+	headerDelayWriteTime = 30 * time.Second
+)
+
+// headerDelayPaths are the non-streaming payload endpoints that support
+// header_delay. /stream_payload sends its headers early by design.
+var headerDelayPaths = map[string]bool{
+	"/rest_payload":      true,
+	"/paginated_payload": true,
+}
+
+// getHeaderDelay parses the header_delay query parameter, how long the
+// response headers are held back (e.g. "5s"). It returns 0 if unset.
+func getHeaderDelay(r *http.Request) (time.Duration, error) {
+	val := r.URL.Query().Get("header_delay")
+	if val == "" {
+		return 0, nil
+	}
+	delay, err := time.ParseDuration(val)
+	if err != nil || delay < 0 || delay > maxHeaderDelay {
+		return 0, fmt.Errorf("header_delay must be a duration between 0s and %v (e.g. 5s)", maxHeaderDelay)
+	}
+	return delay, nil
+}
+
+// headerDelayWriter waits once before the status line and headers are
+// written, whether by WriteHeader, the first Write or a Flush
+type headerDelayWriter struct {
+	http.ResponseWriter
+	ctx    context.Context
+	delay  time.Duration
+	waited bool
+}
+
+// wait sleeps for the delay before the first header write. A canceled
+// request ends the wait early; the following write then fails as usual.
+func (d *headerDelayWriter) wait() {
+	if !d.waited {
+		d.waited = true
+		_ = sleepContext(d.ctx, d.delay)
+	}
+}
+
+func (d *headerDelayWriter) WriteHeader(status int) {
+	d.wait()
+	d.ResponseWriter.WriteHeader(status)
+}
+
+func (d *headerDelayWriter) Write(p []byte) (int, error) {
+	d.wait()
+	return d.ResponseWriter.Write(p)
+}
+
+func (d *headerDelayWriter) Flush() {
+	d.wait()
+	if flusher, ok := d.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap gives http.ResponseController access to the underlying writer
+func (d *headerDelayWriter) Unwrap() http.ResponseWriter {
+	return d.ResponseWriter
+}
+
+// headerDelayMiddleware holds back the response headers of the
+// non-streaming payload endpoints by the header_delay parameter, for testing
+// clients and proxies with a response header timeout. Unlike ttfb, which
+// applies inside the handler, the delay is added right before the headers
+// go out, on top of any time the handler takes. Other paths are left
+// unwrapped.
+func headerDelayMiddleware(path string, next http.HandlerFunc) http.HandlerFunc {
+	if !headerDelayPaths[path] {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		delay, err := getHeaderDelay(r)
+		if err != nil {
+			writeErrorResponse(w, r, http.StatusBadRequest, errCodeBadRequest, err.Error())
+			return
+		}
+		if delay == 0 {
+			next(w, r)
+			return
+		}
+
+		// The delay may outlast the server's WriteTimeout; not every
+		// ResponseWriter supports deadlines, in which case the server default
+		// applies
+		http.NewResponseController(w).SetWriteDeadline(time.Now().Add(delay + headerDelayWriteTime))
+		next(&headerDelayWriter{ResponseWriter: w, ctx: r.Context(), delay: delay}, r)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestHeaderDelayMiddleware checks that header_delay holds back the response
+// headers, which clients observe as the time until http.Get returns
+func TestHeaderDelayMiddleware(t *testing.T) {
+	originalAuth := *enableAuth
+	*enableAuth = false
+	defer func() { *enableAuth = originalAuth }()
+
+	const delay = 300 * time.Millisecond
+	for path, handler := range map[string]http.HandlerFunc{
+		"/rest_payload":      RestPayloadHandler,
+		"/paginated_payload": PaginatedPayloadHandler,
+	} {
+		server := httptest.NewServer(headerDelayMiddleware(path, handler))
+
+		started := time.Now()
+		resp, err := http.Get(server.URL + path + "?count=5&limit=5&header_delay=300ms")
+		if err != nil {
+			t.Fatalf("%s: request failed: %v", path, err)
+		}
+		elapsed := time.Since(started)
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("%s: expected status 200, got %d", path, resp.StatusCode)
+		}
+		if elapsed < delay {
+			t.Errorf("%s: expected headers after at least %v, got them after %v", path, delay, elapsed)
+		}
+
+		// A client with a shorter response header timeout gives up
+		client := &http.Client{Transport: &http.Transport{ResponseHeaderTimeout: 50 * time.Millisecond}}
+		if resp, err := client.Get(server.URL + path + "?count=5&limit=5&header_delay=300ms"); err == nil {
+			resp.Body.Close()
+			t.Errorf("%s: expected a response header timeout", path)
+		}
+		client.CloseIdleConnections()
+
+		// Without header_delay the headers are not held back
+		started = time.Now()
+		resp, err = client.Get(server.URL + path + "?count=5&limit=5")
+		if err != nil {
+			t.Fatalf("%s: request without header_delay failed: %v", path, err)
+		}
+		resp.Body.Close()
+		if elapsed := time.Since(started); elapsed >= delay {
+			t.Errorf("%s: expected prompt headers without header_delay, took %v", path, elapsed)
+		}
+		server.Close()
+	}
+}
+
+func TestHeaderDelayInvalid(t *testing.T) {
+	handler := headerDelayMiddleware("/rest_payload", RestPayloadHandler)
+	for _, value := range []string{"soon", "-1s", "6m"} {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest("GET", "/rest_payload?count=1&header_delay="+value, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("header_delay=%s: expected status 400, got %d", value, w.Code)
+		}
+	}
+
+	// Streaming is not wrapped, its headers go out right away
+	called := false
+	next := func(w http.ResponseWriter, r *http.Request) { called = true }
+	w := httptest.NewRecorder()
+	headerDelayMiddleware("/stream_payload", next)(w, httptest.NewRequest("GET", "/stream_payload?header_delay=soon", nil))
+	if !called || w.Code != http.StatusOK {
+		t.Errorf("expected /stream_payload to be left unwrapped, got status %d", w.Code)
+	}
+}
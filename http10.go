@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"net/http"
+	"strconv"
+)
+
+// paramHTTP10 makes the payload endpoints answer like an HTTP/1.0 server
+var paramHTTP10 = flag.Bool("http10", false, "Answer the payload endpoints HTTP/1.0-style: buffered with Content-Length and Connection: close, never chunked")
+
+// http10Paths are the payload endpoints that support HTTP/1.0-style responses
+var http10Paths = map[string]bool{
+	"/rest_payload":      true,
+	"/stream_payload":    true,
+	"/paginated_payload": true,
+}
+
+// bufferedResponseWriter collects a complete response in memory. Flush is
+// a no-op, so streaming handlers run unchanged but nothing is sent early.
+type bufferedResponseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponseWriter) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferedResponseWriter) WriteHeader(status int) {
+	if b.status == 0 {
+		b.status = status
+	}
+}
+
+func (b *bufferedResponseWriter) Write(p []byte) (int, error) {
+	b.WriteHeader(http.StatusOK)
+	return b.body.Write(p)
+}
+
+func (b *bufferedResponseWriter) Flush() {}
+
+// sendBuffered writes a buffered response to w with Content-Length instead
+// of chunked transfer encoding. Trailers cannot be sent without chunking and
+// are dropped.
+func sendBuffered(w http.ResponseWriter, buffered *bufferedResponseWriter) {
+	header := w.Header()
+	header.Del("Transfer-Encoding")
+	header.Del("Trailer")
+	header.Set("Content-Length", strconv.Itoa(buffered.body.Len()))
+	if buffered.status == 0 {
+		buffered.status = http.StatusOK
+	}
+	w.WriteHeader(buffered.status)
+	_, _ = w.Write(buffered.body.Bytes())
+}
+
+// isHTTP10Request reports whether the response should be HTTP/1.0-style,
+// either for every request (-http10) or for this one (proto=1.0)
+func isHTTP10Request(r *http.Request) bool {
+	return *paramHTTP10 || r.URL.Query().Get("proto") == "1.0"
+}
+
+// http10Middleware answers payload endpoints the way an HTTP/1.0 server
+// would, for testing older clients and proxies: the response is buffered
+// completely and sent with Content-Length and Connection: close instead of
+// chunked transfer encoding. Trailers cannot be sent without chunking and
+// are dropped. Other paths are left unwrapped.
+func http10Middleware(path string, next http.HandlerFunc) http.HandlerFunc {
+	if !http10Paths[path] {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !isHTTP10Request(r) {
+			next(w, r)
+			return
+		}
+
+		buffered := &bufferedResponseWriter{header: w.Header()}
+		next(buffered, r)
+
+		w.Header().Set("Connection", "close")
+		sendBuffered(w, buffered)
+	}
+}
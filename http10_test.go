@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestHTTP10Middleware checks that HTTP/1.0-style responses are sent with
+// Content-Length and Connection: close instead of chunked encoding
+func TestHTTP10Middleware(t *testing.T) {
+	originalAuth := *enableAuth
+	*enableAuth = false
+	defer func() { *enableAuth = originalAuth }()
+
+	originalHTTP10 := *paramHTTP10
+	defer func() { *paramHTTP10 = originalHTTP10 }()
+
+	server := httptest.NewServer(http10Middleware("/stream_payload", StreamingPayloadHandler))
+	defer server.Close()
+
+	get := func(query string) (*http.Response, []byte) {
+		resp, err := http.Get(server.URL + "/stream_payload?count=5&delay=0s" + query)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("Failed to read body: %v", err)
+		}
+		return resp, body
+	}
+
+	// Streaming responses are chunked by default
+	resp, _ := get("")
+	if len(resp.TransferEncoding) == 0 || resp.TransferEncoding[0] != "chunked" {
+		t.Errorf("Expected chunked encoding by default, got %v", resp.TransferEncoding)
+	}
+
+	checkHTTP10 := func(resp *http.Response, body []byte) {
+		t.Helper()
+		if len(resp.TransferEncoding) != 0 {
+			t.Errorf("Expected no chunked encoding, got %v", resp.TransferEncoding)
+		}
+		if resp.ContentLength != int64(len(body)) {
+			t.Errorf("Expected Content-Length %d, got %d", len(body), resp.ContentLength)
+		}
+		if !resp.Close {
+			t.Error("Expected the connection to be closed after the response")
+		}
+		var items []StreamItem
+		if err := json.Unmarshal(body, &items); err != nil || len(items) != 5 {
+			t.Errorf("Expected the complete stream of 5 items, got %d (%v)", len(items), err)
+		}
+	}
+
+	resp, body := get("&proto=1.0")
+	checkHTTP10(resp, body)
+
+	*paramHTTP10 = true
+	resp, body = get("")
+	checkHTTP10(resp, body)
+
+	// Other endpoints are left unwrapped
+	w := httptest.NewRecorder()
+	http10Middleware("/longpoll", func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := w.(*bufferedResponseWriter); ok {
+			t.Error("Expected /longpoll to keep the original ResponseWriter")
+		}
+	})(w, httptest.NewRequest("GET", "/longpoll", nil))
+}
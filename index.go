@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// IndexEndpoint describes one registered endpoint on the landing page
+type IndexEndpoint struct {
+	Path    string   `json:"path"`
+	Methods []string `json:"methods"`
+	Summary string   `json:"summary,omitempty"`
+	Auth    bool     `json:"auth"` // Basic auth is required
+}
+
+// Index is the JSON form of the landing page
+type Index struct {
+	Name          string          `json:"name"`
+	Version       string          `json:"version"`
+	Documentation string          `json:"documentation"`
+	OpenAPI       string          `json:"openapi"`
+	Endpoints     []IndexEndpoint `json:"endpoints"`
+}
+
+// isDocumentationPath reports whether path is a documentation endpoint,
+// which is served without authentication for better UX
+func isDocumentationPath(path string) bool {
+	return path == "/swagger" || path == "/openapi.json"
+}
+
+// registerIndex adds the landing page at the root path. Like the
+// documentation it is served without authentication. The {$} pattern only
+// matches "/" itself, so unknown paths still get 404 Not Found.
+func registerIndex(mux *http.ServeMux) {
+	mux.HandleFunc("/{$}", rateLimitHeadersMiddleware(requestTimeoutMiddleware("/", *paramRequestTimeout, IndexHandler)))
+	fmt.Println("Registered endpoint: / (no auth)")
+}
+
+// buildIndex lists the registered plugins sorted by path, with the methods
+// and summary of their OpenAPI spec
+func buildIndex() Index {
+	index := Index{
+		Name:          "payloadBuddy",
+		Version:       Version,
+		Documentation: "/swagger",
+		OpenAPI:       "/openapi.json",
+		Endpoints:     make([]IndexEndpoint, 0, len(plugins)),
+	}
+	for _, p := range plugins {
+		endpoint := IndexEndpoint{
+			Path: p.Path(),
+			Auth: *enableAuth && !isDocumentationPath(p.Path()),
+		}
+		operations := p.OpenAPISpec().Operation
+		for _, op := range []struct {
+			method    string
+			operation *OpenAPIOperation
+		}{
+			{http.MethodGet, operations.Get},
+			{http.MethodPost, operations.Post},
+			{http.MethodPut, operations.Put},
+			{http.MethodDelete, operations.Delete},
+		} {
+			if op.operation == nil {
+				continue
+			}
+			endpoint.Methods = append(endpoint.Methods, op.method)
+			if endpoint.Summary == "" {
+				endpoint.Summary = op.operation.Summary
+			}
+		}
+		index.Endpoints = append(index.Endpoints, endpoint)
+	}
+	sort.Slice(index.Endpoints, func(i, j int) bool { return index.Endpoints[i].Path < index.Endpoints[j].Path })
+	return index
+}
+
+// IndexHandler serves the landing page listing the registered endpoints.
+// Browsers, which prefer text/html in their Accept header, get an HTML page
+// linking to /swagger; other clients such as curl get the JSON index.
+func IndexHandler(w http.ResponseWriter, r *http.Request) {
+	index := buildIndex()
+
+	w.Header().Add("Vary", "Accept")
+	ranges := parseAccept(r.Header.Get("Accept"))
+	if mediaTypeQuality(ranges, "text/html") <= mediaTypeQuality(ranges, "application/json") {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(index); err != nil {
+			writeErrorResponse(w, r, http.StatusInternalServerError, errCodeInternal, "Failed to encode index")
+		}
+		return
+	}
+
+	var rows strings.Builder
+	for _, endpoint := range index.Endpoints {
+		auth := ""
+		if endpoint.Auth {
+			auth = " (auth)"
+		}
+		fmt.Fprintf(&rows, "        <li><code>%s</code> <a href=\"%s\">%s</a> - %s%s</li>\n",
+			strings.Join(endpoint.Methods, ", "), html.EscapeString(endpoint.Path), html.EscapeString(endpoint.Path), html.EscapeString(endpoint.Summary), auth)
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	fmt.Fprintf(w, `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <title>payloadBuddy %s</title>
+</head>
+<body>
+    <h1>payloadBuddy %s</h1>
+    <p>Interactive documentation: <a href="/swagger">/swagger</a>, OpenAPI specification: <a href="/openapi.json">/openapi.json</a></p>
+    <ul>
+%s    </ul>
+</body>
+</html>
+`, html.EscapeString(index.Version), html.EscapeString(index.Version), rows.String())
+}
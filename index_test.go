@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestIndex(t *testing.T) {
+	originalAuth := *enableAuth
+	originalUser, originalPass := authUsername, authPassword
+	defer func() {
+		*enableAuth = originalAuth
+		authUsername, authPassword = originalUser, originalPass
+	}()
+
+	// The landing page needs no credentials even with -auth
+	*enableAuth = true
+	authUsername, authPassword = "user", "secret"
+	mux := http.NewServeMux()
+	registerPlugins(mux)
+
+	get := func(path, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("/", "*/*")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Expected a JSON index for curl, got %q", ct)
+	}
+	var index Index
+	if err := json.Unmarshal(w.Body.Bytes(), &index); err != nil {
+		t.Fatalf("Failed to parse index: %v", err)
+	}
+	if index.Documentation != "/swagger" || index.Version != Version {
+		t.Errorf("Unexpected index header: %+v", index)
+	}
+	endpoints := make(map[string]IndexEndpoint)
+	for _, endpoint := range index.Endpoints {
+		endpoints[endpoint.Path] = endpoint
+	}
+	if len(endpoints) != len(plugins) {
+		t.Errorf("Expected %d endpoints, got %d", len(plugins), len(endpoints))
+	}
+	rest, ok := endpoints["/rest_payload"]
+	if !ok || !rest.Auth || len(rest.Methods) != 1 || rest.Methods[0] != "GET" || rest.Summary == "" {
+		t.Errorf("Expected /rest_payload as an authenticated GET endpoint, got %+v", rest)
+	}
+	if endpoints["/swagger"].Auth {
+		t.Error("Expected /swagger to need no authentication")
+	}
+
+	// Browsers get an HTML page linking to the documentation
+	w = get("/", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "text/html" {
+		t.Fatalf("Expected an HTML page, got %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	for _, link := range []string{`href="/swagger"`, `href="/rest_payload"`} {
+		if !strings.Contains(w.Body.String(), link) {
+			t.Errorf("Expected the page to contain %s", link)
+		}
+	}
+
+	// Other unknown paths still 404
+	if w := get("/unknown", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown path, got %d", w.Code)
+	}
+}
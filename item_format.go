@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Timestamp formats supported by the timestamp_format query parameter
+const (
+	TimestampRFC3339 = "rfc3339" // RFC 3339 string (default)
+	TimestampEpoch   = "epoch"   // Unix epoch in milliseconds
+)
+
+// defaultTimestampField is the JSON key used for item timestamps by default
+const defaultTimestampField = "timestamp"
+
+// TimestampOptions controls the JSON key and format of item timestamps.
+// If Template is set (from a scenario's item_template) it renders the whole
+// item instead, and Field, Format and ShuffleFields are ignored.
+// ShuffleFields emits the item keys in a random order per item, StringIDs
+// the id as a JSON string. NullRate is the probability of each field other
+// than the id being null.
+type TimestampOptions struct {
+	Field         string
+	Format        string
+	Template      *template.Template
+	ShuffleFields bool
+	StringIDs     bool
+	NullRate      float64
+}
+
+// maxIDStart bounds the id_start query parameter
+const maxIDStart = 1000000000
+
+// getIDStart parses the id_start query parameter, the ID of the first item
+// in the data set. Handlers pass their historical default: 1 for
+// /rest_payload and /paginated_payload, 0 for /stream_payload.
+func getIDStart(r *http.Request, defaultStart int) (int, error) {
+	val := r.URL.Query().Get("id_start")
+	if val == "" {
+		return defaultStart, nil
+	}
+	idStart, err := strconv.Atoi(val)
+	if err != nil || idStart < 0 || idStart > maxIDStart {
+		return 0, fmt.Errorf("id_start must be between 0 and %d", maxIDStart)
+	}
+	return idStart, nil
+}
+
+// getTimestampOptions parses the timestamp_field, timestamp_format,
+// shuffle_fields, string_ids and null_rate query parameters. It returns an
+// error if the format is unknown, null_rate is out of range or the field name
+// would collide with another item field.
+func getTimestampOptions(r *http.Request) (TimestampOptions, error) {
+	opts := TimestampOptions{
+		Field:  r.URL.Query().Get("timestamp_field"),
+		Format: strings.ToLower(r.URL.Query().Get("timestamp_format")),
+		// Exposes clients that depend on a fixed key order
+		ShuffleFields: r.URL.Query().Get("shuffle_fields") == "true",
+		StringIDs:     isStringIDs(r),
+	}
+	if opts.Field == "" {
+		opts.Field = defaultTimestampField
+	}
+	if opts.Format == "" {
+		opts.Format = TimestampRFC3339
+	}
+
+	// Exposes clients that crash on unexpected nulls
+	nullRate, err := getRateParam(r, "null_rate", 0)
+	if err != nil {
+		return opts, err
+	}
+	opts.NullRate = nullRate
+
+	if opts.Format != TimestampRFC3339 && opts.Format != TimestampEpoch {
+		return opts, fmt.Errorf("timestamp_format must be one of: %s, %s", TimestampRFC3339, TimestampEpoch)
+	}
+
+	switch opts.Field {
+	case "id", "value", "sys_id", "number", "state":
+		return opts, fmt.Errorf("timestamp_field must not collide with item field %q", opts.Field)
+	}
+
+	return opts, nil
+}
+
+// IsDefault reports whether the options match the standard struct encoding,
+// in which case items can be marshaled directly without the map conversion
+func (o TimestampOptions) IsDefault() bool {
+	return o.Field == defaultTimestampField && o.Format == TimestampRFC3339 && o.Template == nil && !o.ShuffleFields && !o.StringIDs && o.NullRate == 0
+}
+
+// isStringIDs reports whether the string_ids parameter asks for item IDs as
+// JSON strings, the way ServiceNow returns numeric-looking values
+func isStringIDs(r *http.Request) bool {
+	return r.URL.Query().Get("string_ids") == "true"
+}
+
+// formatTimestamp renders a timestamp according to the configured format
+func (o TimestampOptions) formatTimestamp(t time.Time) interface{} {
+	if o.Format == TimestampEpoch {
+		return t.UnixMilli()
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+// itemMap converts a generated item into a map so the timestamp can be
+// emitted under a custom key and in a custom format, and fields can be null
+func (o TimestampOptions) itemMap(item StreamItem) map[string]interface{} {
+	m := map[string]interface{}{
+		"id":    item.ID,
+		"value": item.Value,
+	}
+	if o.StringIDs {
+		m["id"] = strconv.Itoa(item.ID)
+	}
+	m[o.Field] = o.formatTimestamp(item.Timestamp)
+
+	// ServiceNow fields keep their omitempty behavior
+	if item.SysID != "" {
+		m["sys_id"] = item.SysID
+	}
+	if item.Number != "" {
+		m["number"] = item.Number
+	}
+	if item.State != "" {
+		m["state"] = item.State
+	}
+
+	for key := range m {
+		if key != "id" && rollProbability(o.NullRate) {
+			m[key] = nil
+		}
+	}
+	return m
+}
+
+// marshalShuffled encodes m as a JSON object with its keys in random order.
+// encoding/json always sorts map keys, so the object is assembled by hand.
+func marshalShuffled(m map[string]interface{}) ([]byte, error) {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	// Fisher-Yates shuffle; on a random source failure the keys stay in
+	// map iteration order, which is random enough
+	for i := len(keys) - 1; i > 0; i-- {
+		j, err := secureRandIntn(i + 1)
+		if err != nil {
+			break
+		}
+		keys[i], keys[j] = keys[j], keys[i]
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(m[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// getFixedTimestamp parses the fixed_timestamp query parameter, an RFC 3339
+// time used for every item instead of the current time. It returns the zero
+// time if the parameter is not set.
+func getFixedTimestamp(r *http.Request) (time.Time, error) {
+	val := r.URL.Query().Get("fixed_timestamp")
+	if val == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, val)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("fixed_timestamp must be an RFC 3339 time (e.g. 2024-01-15T10:00:00Z)")
+	}
+	return t, nil
+}
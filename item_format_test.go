@@ -0,0 +1,285 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// TestIDStartConsistency checks that all payload handlers start their IDs at
+// the same value for a given id_start
+func TestIDStartConsistency(t *testing.T) {
+	originalAuth := *enableAuth
+	*enableAuth = false
+	defer func() { *enableAuth = originalAuth }()
+
+	firstID := func(handler http.HandlerFunc, url string) int {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest("GET", url, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected status 200, got %d", url, w.Code)
+		}
+
+		var ids []struct {
+			ID int `json:"id"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &ids); err != nil {
+			// Paginated responses wrap the items in an object
+			var page struct {
+				Result []struct {
+					ID int `json:"id"`
+				} `json:"result"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+				t.Fatalf("%s: failed to parse JSON response: %v", url, err)
+			}
+			ids = page.Result
+		}
+		if len(ids) == 0 {
+			t.Fatalf("%s: expected items", url)
+		}
+		return ids[0].ID
+	}
+
+	for _, idStart := range []string{"0", "1", "1000"} {
+		stream := firstID(StreamingPayloadHandler, "/stream_payload?count=3&delay=0&id_start="+idStart)
+		page := firstID(PaginatedPayloadHandler, "/paginated_payload?total=3&id_start="+idStart)
+		rest := firstID(RestPayloadHandler, "/rest_payload?count=3&id_start="+idStart)
+		if stream != page || page != rest {
+			t.Errorf("id_start=%s: first IDs differ: stream=%d, paginated=%d, rest=%d", idStart, stream, page, rest)
+		}
+	}
+
+	// Defaults are unchanged: streaming starts at 0, the others at 1
+	if id := firstID(StreamingPayloadHandler, "/stream_payload?count=1&delay=0"); id != 0 {
+		t.Errorf("Expected default streaming first ID 0, got %d", id)
+	}
+	if id := firstID(PaginatedPayloadHandler, "/paginated_payload?total=1"); id != 1 {
+		t.Errorf("Expected default paginated first ID 1, got %d", id)
+	}
+}
+
+func TestIDStartValidation(t *testing.T) {
+	originalAuth := *enableAuth
+	*enableAuth = false
+	defer func() { *enableAuth = originalAuth }()
+
+	handlers := map[string]http.HandlerFunc{
+		"/stream_payload":    StreamingPayloadHandler,
+		"/paginated_payload": PaginatedPayloadHandler,
+		"/rest_payload":      RestPayloadHandler,
+	}
+	for path, handler := range handlers {
+		for _, value := range []string{"-1", "abc", "1000000001"} {
+			w := httptest.NewRecorder()
+			handler(w, httptest.NewRequest("GET", path+"?id_start="+value, nil))
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("%s?id_start=%s: expected status 400, got %d", path, value, w.Code)
+			}
+		}
+	}
+}
+
+// objectKeys returns the keys of a JSON object in document order
+func objectKeys(t *testing.T, data []byte) []string {
+	t.Helper()
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if _, err := dec.Token(); err != nil {
+		t.Fatalf("Failed to read object start: %v", err)
+	}
+	var keys []string
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			t.Fatalf("Failed to read key: %v", err)
+		}
+		keys = append(keys, tok.(string))
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			t.Fatalf("Failed to read value: %v", err)
+		}
+	}
+	return keys
+}
+
+// TestShuffleFields checks that shuffle_fields varies the key order across
+// items while every item keeps all of its keys.
+func TestShuffleFields(t *testing.T) {
+	*enableAuth = false
+	w := httptest.NewRecorder()
+	StreamingPayloadHandler(w, httptest.NewRequest("GET", "/stream_payload?count=50&delay=0ms&servicenow=true&shuffle_fields=true", nil))
+
+	var items []json.RawMessage
+	if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil {
+		t.Fatalf("Failed to parse JSON response: %v", err)
+	}
+	if len(items) != 50 {
+		t.Fatalf("Expected 50 items, got %d", len(items))
+	}
+
+	orders := make(map[string]bool)
+	for _, item := range items {
+		keys := objectKeys(t, item)
+		orders[strings.Join(keys, ",")] = true
+		sorted := append([]string(nil), keys...)
+		sort.Strings(sorted)
+		if got := strings.Join(sorted, ","); got != "id,number,state,sys_id,timestamp,value" {
+			t.Errorf("Expected all item keys, got %v", keys)
+		}
+	}
+	// 50 items with 6 keys share a single order with negligible probability
+	if len(orders) < 2 {
+		t.Errorf("Expected the key order to vary across items, got %d distinct orders", len(orders))
+	}
+
+	// Paginated items are shuffled as well
+	w = httptest.NewRecorder()
+	PaginatedPayloadHandler(w, httptest.NewRequest("GET", "/paginated_payload?limit=5&shuffle_fields=true", nil))
+	var page struct {
+		Result []json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+		t.Fatalf("Failed to parse paginated response: %v", err)
+	}
+	for _, item := range page.Result {
+		if keys := objectKeys(t, item); len(keys) != 3 {
+			t.Errorf("Expected 3 keys per paginated item, got %v", keys)
+		}
+	}
+}
+
+func TestStringIDs(t *testing.T) {
+	originalAuth := *enableAuth
+	*enableAuth = false
+	defer func() { *enableAuth = originalAuth }()
+
+	tests := []struct {
+		handler http.HandlerFunc
+		url     string
+	}{
+		{RestPayloadHandler, "/rest_payload?count=3&string_ids=true"},
+		{RestPayloadHandler, "/rest_payload?count=3&string_ids=true&pretty=true"},
+		{StreamingPayloadHandler, "/stream_payload?count=3&delay=0&string_ids=true"},
+		{StreamingPayloadHandler, "/stream_payload?count=3&delay=0&servicenow=true&string_ids=true"},
+		{PaginatedPayloadHandler, "/paginated_payload?limit=3&string_ids=true"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		tt.handler(w, httptest.NewRequest("GET", tt.url, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected status 200, got %d", tt.url, w.Code)
+		}
+
+		var items []map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil {
+			var page struct {
+				Result []map[string]interface{} `json:"result"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+				t.Fatalf("%s: failed to parse JSON response: %v", tt.url, err)
+			}
+			items = page.Result
+		}
+		if len(items) != 3 {
+			t.Fatalf("%s: expected 3 items, got %d", tt.url, len(items))
+		}
+		for _, item := range items {
+			if _, ok := item["id"].(string); !ok {
+				t.Errorf("%s: expected a quoted id, got %#v", tt.url, item["id"])
+			}
+		}
+	}
+
+	// Without the flag IDs stay numeric
+	w := httptest.NewRecorder()
+	RestPayloadHandler(w, httptest.NewRequest("GET", "/rest_payload?count=1&id_start=7", nil))
+	if got := strings.TrimSpace(w.Body.String()); got != `[{"id":7,"name":"Object 7"}]` {
+		t.Errorf("Expected a numeric id, got %s", got)
+	}
+	w = httptest.NewRecorder()
+	RestPayloadHandler(w, httptest.NewRequest("GET", "/rest_payload?count=1&id_start=7&string_ids=true", nil))
+	if got := strings.TrimSpace(w.Body.String()); got != `[{"id":"7","name":"Object 7"}]` {
+		t.Errorf("Expected a quoted id, got %s", got)
+	}
+}
+
+func TestNullRate(t *testing.T) {
+	originalAuth := *enableAuth
+	*enableAuth = false
+	defer func() { *enableAuth = originalAuth }()
+
+	// items requests url and returns its items, from a bare array or a page
+	items := func(handler http.HandlerFunc, url string) []map[string]interface{} {
+		t.Helper()
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest("GET", url, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected status 200, got %d: %s", url, w.Code, w.Body.String())
+		}
+		var items []map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil {
+			var page struct {
+				Result []map[string]interface{} `json:"result"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+				t.Fatalf("%s: failed to parse JSON response: %v", url, err)
+			}
+			items = page.Result
+		}
+		if len(items) == 0 {
+			t.Fatalf("%s: expected items", url)
+		}
+		return items
+	}
+
+	tests := []struct {
+		handler http.HandlerFunc
+		url     string
+		keepID  bool
+	}{
+		{StreamingPayloadHandler, "/stream_payload?count=10&delay=0&servicenow=true", true},
+		{StreamingPayloadHandler, "/stream_payload?count=10&delay=0&timestamp_format=epoch", true},
+		{PaginatedPayloadHandler, "/paginated_payload?limit=10&servicenow=true", true},
+		{GenerateHandler, "/generate?count=10&fields=name:string,age:int,active:bool", false},
+	}
+	for _, tt := range tests {
+		for _, item := range items(tt.handler, tt.url+"&null_rate=1.0") {
+			for key, value := range item {
+				if key == "id" && tt.keepID {
+					if value == nil {
+						t.Errorf("%s: expected the id to stay set, got %v", tt.url, item)
+					}
+				} else if value != nil {
+					t.Errorf("%s: expected %s to be null, got %v", tt.url, key, item)
+				}
+			}
+		}
+		for _, item := range items(tt.handler, tt.url+"&null_rate=0") {
+			for key, value := range item {
+				if value == nil {
+					t.Errorf("%s: expected no nulls, got null %s in %v", tt.url, key, item)
+				}
+			}
+		}
+	}
+
+	invalid := []struct {
+		handler http.HandlerFunc
+		url     string
+	}{
+		{StreamingPayloadHandler, "/stream_payload?null_rate=1.5"},
+		{PaginatedPayloadHandler, "/paginated_payload?null_rate=-1"},
+		{GenerateHandler, "/generate?fields=a:int&null_rate=x"},
+	}
+	for _, tt := range invalid {
+		w := httptest.NewRecorder()
+		tt.handler(w, httptest.NewRequest("GET", tt.url, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", tt.url, w.Code)
+		}
+	}
+}
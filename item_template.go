@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"text/template"
+	"time"
+)
+
+// itemTemplateKey is the simulation_config key holding a text/template that
+// renders each item, replacing the built-in item shape
+const itemTemplateKey = "item_template"
+
+// itemTemplateData is the data available to item templates
+type itemTemplateData struct {
+	ID          int
+	Value       string
+	Timestamp   string // RFC 3339 with nanoseconds
+	TimestampMs int64  // Unix epoch milliseconds
+	SysID       string // ServiceNow fields, empty unless servicenow=true
+	Number      string
+	State       string
+}
+
+// itemTemplateFuncs are available in item templates. json encodes a value
+// as JSON, e.g. {{json .Value}} for a quoted and escaped string.
+var itemTemplateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// parseItemTemplate validates an item_template value. The template must
+// parse and render valid JSON for a sample ServiceNow item.
+func parseItemTemplate(value interface{}) (*template.Template, error) {
+	text, ok := value.(string)
+	if !ok || text == "" {
+		return nil, fmt.Errorf("%s must be a non-empty string", itemTemplateKey)
+	}
+
+	tmpl, err := template.New(itemTemplateKey).Funcs(itemTemplateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("%s does not parse: %v", itemTemplateKey, err)
+	}
+
+	sample := StreamItem{
+		ID:        1,
+		Value:     "ServiceNow Record 1",
+		Timestamp: time.Now(),
+		SysID:     generateSysID(),
+		Number:    "INC0000001",
+		State:     "In Progress",
+	}
+	if _, err := renderItemTemplate(tmpl, sample); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// renderItemTemplate renders a single item with the template and checks
+// that the result is valid JSON
+func renderItemTemplate(tmpl *template.Template, item StreamItem) ([]byte, error) {
+	var buf bytes.Buffer
+	err := tmpl.Execute(&buf, itemTemplateData{
+		ID:          item.ID,
+		Value:       item.Value,
+		Timestamp:   item.Timestamp.Format(time.RFC3339Nano),
+		TimestampMs: item.Timestamp.UnixMilli(),
+		SysID:       item.SysID,
+		Number:      item.Number,
+		State:       item.State,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s failed to render: %v", itemTemplateKey, err)
+	}
+	if !json.Valid(buf.Bytes()) {
+		return nil, fmt.Errorf("%s does not render valid JSON: %s", itemTemplateKey, buf.String())
+	}
+	return buf.Bytes(), nil
+}
+
+// GetItemTemplate returns the item template configured through
+// scenario_parameters.simulation_config.item_template, or nil if the
+// scenario uses the built-in item shape
+func (sm *ScenarioManager) GetItemTemplate(scenarioType string) *template.Template {
+	scenario := sm.GetScenario(scenarioType)
+	if scenario == nil || scenario.ScenarioParams == nil {
+		return nil
+	}
+	value, ok := scenario.ScenarioParams.SimulationConfig[itemTemplateKey]
+	if !ok {
+		return nil
+	}
+	tmpl, err := parseItemTemplate(value)
+	if err != nil {
+		return nil
+	}
+	return tmpl
+}
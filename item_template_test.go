@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestItemTemplate(t *testing.T) {
+	originalAuth := *enableAuth
+	*enableAuth = false
+	defer func() { *enableAuth = originalAuth }()
+
+	originalManager := scenarioManager
+	defer func() { scenarioManager = originalManager }()
+
+	scenarioManager = &ScenarioManager{
+		scenarios: map[string]*Scenario{
+			"custom": {
+				ScenarioName: "Templated",
+				ScenarioType: "custom",
+				BaseDelay:    "0ms",
+				ScenarioParams: &ScenarioParameters{
+					SimulationConfig: map[string]interface{}{
+						itemTemplateKey: `{"id":{{.ID}},"ticket":"{{.Number}}","label":{{json .Value}}}`,
+					},
+				},
+			},
+		},
+		validator: NewScenarioValidator(),
+	}
+
+	expected := []string{
+		`{"id":1,"ticket":"INC0000001","label":"ServiceNow Record 1"}`,
+		`{"id":2,"ticket":"INC0000002","label":"ServiceNow Record 2"}`,
+	}
+
+	// Streaming renders every item with the template
+	w := httptest.NewRecorder()
+	StreamingPayloadHandler(w, httptest.NewRequest("GET", "/stream_payload?scenario=custom&count=2&id_start=1&servicenow=true", nil))
+	var streamed []json.RawMessage
+	if err := json.Unmarshal(w.Body.Bytes(), &streamed); err != nil {
+		t.Fatalf("Failed to parse streamed JSON: %v\n%s", err, w.Body.String())
+	}
+	assertRawItems(t, "stream", streamed, expected)
+
+	// Paginated results use the template as well
+	w = httptest.NewRecorder()
+	PaginatedPayloadHandler(w, httptest.NewRequest("GET", "/paginated_payload?scenario=custom&total=2&servicenow=true", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var page struct {
+		Result []json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+		t.Fatalf("Failed to parse paginated JSON: %v", err)
+	}
+	assertRawItems(t, "paginated", page.Result, expected)
+}
+
+func assertRawItems(t *testing.T, name string, got []json.RawMessage, expected []string) {
+	t.Helper()
+	if len(got) != len(expected) {
+		t.Fatalf("%s: expected %d items, got %d", name, len(expected), len(got))
+	}
+	for i := range expected {
+		if string(got[i]) != expected[i] {
+			t.Errorf("%s item %d: expected %s, got %s", name, i, expected[i], got[i])
+		}
+	}
+}
+
+func TestItemTemplateValidation(t *testing.T) {
+	tests := map[string]interface{}{
+		"not a string":  42,
+		"empty":         "",
+		"parse error":   `{"id":{{.ID}`,
+		"unknown field": `{"id":{{.Missing}}}`,
+		"invalid JSON":  `{"id":{{.ID}},}`,
+	}
+	for name, value := range tests {
+		if _, err := parseItemTemplate(value); err == nil {
+			t.Errorf("%s: expected validation error", name)
+		}
+	}
+
+	if _, err := parseItemTemplate(`{"id":{{.ID}},"at":{{.TimestampMs}}}`); err != nil {
+		t.Errorf("Expected valid template to pass, got: %v", err)
+	}
+}
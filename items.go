@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"text/template"
+	"time"
+)
+
+// Value formats of plain (non-ServiceNow) items. The endpoints keep their
+// historical values; ServiceNow items look the same everywhere.
+const (
+	defaultItemValueFormat = "Item %d"
+	streamItemValueFormat  = "streamed data %d"
+	serviceNowValueFormat  = "ServiceNow Record %d"
+)
+
+// serviceNowStates are the ServiceNow states items cycle through by ID
+var serviceNowStates = []string{"New", "In Progress", "Resolved", "Closed"}
+
+// maxCardinality bounds the cardinality query parameter
+const maxCardinality = 1000000
+
+// itemOptions describes how the payload handlers generate items. It is
+// parsed once per request by parseItemOptions; handlers set the fields that
+// do not come from the query themselves.
+type itemOptions struct {
+	IDStart        int                // ID of the item at position 0
+	ServiceNow     bool               // Generate ServiceNow-style fields
+	FixedTimestamp time.Time          // Timestamp of every item, zero for the current time
+	ValueFormat    string             // Format of plain item values (default: defaultItemValueFormat)
+	Size           sizeOptions        // Pads values to varying lengths
+	Padding        string             // Appended to every value, e.g. for page_growth
+	ValueTemplate  *template.Template // Renders every value instead of ValueFormat, from value_template
+	Descending     bool               // List items newest first, counting down from the end of Total
+	Total          int                // Size of the data set, only used with Descending
+	Deleted        int                // Oldest items removed from the data set, which skips their IDs
+	Cardinality    int                // Number of distinct values and states, 0 for one per item
+}
+
+// parseItemOptions parses the item parameters shared by the payload
+// handlers: id_start, servicenow, fixed_timestamp, value_template,
+// cardinality and the size parameters size_variation, value_size and seed.
+// Handlers pass their historical id_start default and the scenario's
+// ServiceNow default.
+func parseItemOptions(r *http.Request, defaultIDStart int, defaultServiceNow bool) (itemOptions, error) {
+	opts := itemOptions{ServiceNow: defaultServiceNow}
+
+	idStart, err := getIDStart(r, defaultIDStart)
+	if err != nil {
+		return opts, err
+	}
+	opts.IDStart = idStart
+
+	// ServiceNow mode: use the default unless explicitly overridden
+	if serviceNowParam := r.URL.Query().Get("servicenow"); serviceNowParam != "" {
+		opts.ServiceNow = serviceNowParam == "true"
+	}
+
+	fixedTimestamp, err := getFixedTimestamp(r)
+	if err != nil {
+		return opts, err
+	}
+	opts.FixedTimestamp = fixedTimestamp
+
+	valueTemplate, err := getValueTemplate(r)
+	if err != nil {
+		return opts, err
+	}
+	opts.ValueTemplate = valueTemplate
+
+	if val := r.URL.Query().Get("cardinality"); val != "" {
+		cardinality, err := strconv.Atoi(val)
+		if err != nil || cardinality < 1 || cardinality > maxCardinality {
+			return opts, fmt.Errorf("cardinality must be between 1 and %d", maxCardinality)
+		}
+		opts.Cardinality = cardinality
+	}
+
+	size, err := getSizeOptions(r)
+	if err != nil {
+		return opts, err
+	}
+	opts.Size = size
+
+	return opts, nil
+}
+
+// itemID returns the ID of the item at the given position
+func (o itemOptions) itemID(position int) int {
+	if o.Descending {
+		return o.IDStart + o.Deleted + o.Total - 1 - position
+	}
+	return o.IDStart + o.Deleted + position
+}
+
+// valueID returns the ID whose value and state the item with the given ID
+// shows. With a cardinality, items cycle through that many values and
+// states, while their IDs, numbers and sys_ids stay unique.
+func (o itemOptions) valueID(id int) int {
+	if o.Cardinality <= 0 {
+		return id
+	}
+	offset := (id - o.IDStart) % o.Cardinality
+	if offset < 0 {
+		offset += o.Cardinality
+	}
+	return o.IDStart + offset
+}
+
+// serviceNowNumber returns the ticket number of the item with the given ID.
+// Numbers count records from INC0000001 on every endpoint, independent of
+// id_start, and stay with their record in drifting data sets.
+func (o itemOptions) serviceNowNumber(id int) string {
+	return fmt.Sprintf("INC%07d", id-o.IDStart+1)
+}
+
+// itemValue returns the value of the item with the given ID and position,
+// before padding. With a seed, values other than templated ones get seeded
+// words appended.
+func (o itemOptions) itemValue(id, position int) string {
+	if o.ValueTemplate != nil {
+		return renderValueTemplate(o.ValueTemplate, id, position)
+	}
+
+	var value string
+	switch {
+	case o.ServiceNow:
+		value = fmt.Sprintf(serviceNowValueFormat, id)
+	case o.ValueFormat != "":
+		value = fmt.Sprintf(o.ValueFormat, id)
+	default:
+		value = fmt.Sprintf(defaultItemValueFormat, id)
+	}
+	if o.Size.Seeded {
+		value = seededValue(o.Size.Seed, id, value)
+	}
+	return value
+}
+
+// sysID returns the sys_id of the item with the given ID, reproducible with
+// a seed and random otherwise
+func (o itemOptions) sysID(id int) string {
+	if o.Size.Seeded {
+		return seededSysID(o.Size.Seed, id)
+	}
+	return generateSysID()
+}
+
+// generateItem creates the item at the given absolute position
+func generateItem(opts itemOptions, position int) StreamItem {
+	id := opts.itemID(position)
+	timestamp := opts.FixedTimestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+	valueID, valuePosition := opts.valueID(id), position
+	if opts.Cardinality > 0 {
+		valuePosition = position % opts.Cardinality
+	}
+	value := opts.Size.pad(opts.itemValue(valueID, valuePosition), valueID) + opts.Padding
+
+	if opts.ServiceNow {
+		return StreamItem{
+			ID:        id,
+			Value:     value,
+			Timestamp: timestamp,
+			SysID:     opts.sysID(id),
+			Number:    opts.serviceNowNumber(id),
+			State:     serviceNowStates[valueID%len(serviceNowStates)],
+		}
+	}
+
+	return StreamItem{
+		ID:        id,
+		Value:     value,
+		Timestamp: timestamp,
+	}
+}
+
+// generateItems creates count items starting at the absolute position start
+func generateItems(opts itemOptions, start, count int) []StreamItem {
+	items := make([]StreamItem, count)
+	for i := range items {
+		items[i] = generateItem(opts, start+i)
+	}
+	return items
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestGenerateItemsPlain(t *testing.T) {
+	fixed := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	items := generateItems(itemOptions{IDStart: 1, FixedTimestamp: fixed}, 10, 3)
+
+	if len(items) != 3 {
+		t.Fatalf("Expected 3 items, got %d", len(items))
+	}
+	for i, item := range items {
+		id := 11 + i
+		if item.ID != id {
+			t.Errorf("Item %d: expected ID %d, got %d", i, id, item.ID)
+		}
+		if item.Value != "Item "+strconv.Itoa(id) {
+			t.Errorf("Item %d: expected value %q, got %q", i, "Item "+strconv.Itoa(id), item.Value)
+		}
+		if !item.Timestamp.Equal(fixed) {
+			t.Errorf("Item %d: expected fixed timestamp, got %v", i, item.Timestamp)
+		}
+		if item.SysID != "" || item.Number != "" || item.State != "" {
+			t.Errorf("Item %d: expected no ServiceNow fields, got %+v", i, item)
+		}
+	}
+
+	// Streaming keeps its historical value format
+	if item := generateItem(itemOptions{ValueFormat: streamItemValueFormat}, 5); item.Value != "streamed data 5" {
+		t.Errorf("Expected streaming value, got %q", item.Value)
+	}
+}
+
+func TestGenerateItemsServiceNow(t *testing.T) {
+	items := generateItems(itemOptions{IDStart: 1, ServiceNow: true, Padding: " xx"}, 0, 4)
+
+	states := []string{"New", "In Progress", "Resolved", "Closed"}
+	for i, item := range items {
+		id := 1 + i
+		if item.ID != id {
+			t.Errorf("Item %d: expected ID %d, got %d", i, id, item.ID)
+		}
+		if want := "ServiceNow Record " + strconv.Itoa(id) + " xx"; item.Value != want {
+			t.Errorf("Item %d: expected value %q, got %q", i, want, item.Value)
+		}
+		if len(item.SysID) != 32 {
+			t.Errorf("Item %d: expected a 32 character sys_id, got %q", i, item.SysID)
+		}
+		if want := "INC000000" + strconv.Itoa(i+1); item.Number != want {
+			t.Errorf("Item %d: expected number %q, got %q", i, want, item.Number)
+		}
+		if item.State != states[id%4] {
+			t.Errorf("Item %d: expected state %q, got %q", i, states[id%4], item.State)
+		}
+		if item.Timestamp.IsZero() {
+			t.Errorf("Item %d: expected the current time as timestamp", i)
+		}
+	}
+
+	// Descending data sets count down from the end
+	if item := generateItem(itemOptions{IDStart: 1, Descending: true, Total: 100}, 0); item.ID != 100 {
+		t.Errorf("Expected newest ID 100 first, got %d", item.ID)
+	}
+}
+
+func TestParseItemOptions(t *testing.T) {
+	opts, err := parseItemOptions(httptest.NewRequest("GET", "/?id_start=7&servicenow=false&fixed_timestamp=2024-01-15T10:00:00Z", nil), 1, true)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if opts.IDStart != 7 || opts.ServiceNow || opts.FixedTimestamp.IsZero() {
+		t.Errorf("Unexpected options: %+v", opts)
+	}
+
+	// Defaults come from the caller
+	opts, err = parseItemOptions(httptest.NewRequest("GET", "/", nil), 0, true)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if opts.IDStart != 0 || !opts.ServiceNow || !opts.FixedTimestamp.IsZero() {
+		t.Errorf("Unexpected default options: %+v", opts)
+	}
+
+	for _, query := range []string{"id_start=-1", "fixed_timestamp=yesterday", "cardinality=0", "cardinality=many"} {
+		if _, err := parseItemOptions(httptest.NewRequest("GET", "/?"+query, nil), 1, false); err == nil {
+			t.Errorf("%s: expected an error", query)
+		}
+	}
+}
+
+func TestCardinality(t *testing.T) {
+	originalAuth := *enableAuth
+	*enableAuth = false
+	defer func() { *enableAuth = originalAuth }()
+
+	for _, cardinality := range []int{1, 3, 7} {
+		opts := itemOptions{IDStart: 1, ServiceNow: true, Cardinality: cardinality}
+		items := generateItems(opts, 0, 50)
+		values := make(map[string]bool)
+		states := make(map[string]bool)
+		sysIDs := make(map[string]bool)
+		for _, item := range items {
+			values[item.Value] = true
+			states[item.State] = true
+			sysIDs[item.SysID] = true
+		}
+		if len(values) != cardinality {
+			t.Errorf("cardinality=%d: expected %d distinct values, got %d", cardinality, cardinality, len(values))
+		}
+		if want := min(cardinality, len(serviceNowStates)); len(states) != want {
+			t.Errorf("cardinality=%d: expected %d distinct states, got %d", cardinality, want, len(states))
+		}
+		if len(sysIDs) != len(items) {
+			t.Errorf("cardinality=%d: expected unique sys_ids, got %d distinct", cardinality, len(sysIDs))
+		}
+		// Values cycle in order
+		if items[cardinality].Value != items[0].Value {
+			t.Errorf("cardinality=%d: expected item %d to repeat the first value, got %q", cardinality, cardinality, items[cardinality].Value)
+		}
+	}
+
+	// The same applies across pages and with padding
+	values := make(map[string]bool)
+	for _, offset := range []string{"0", "10", "20"} {
+		w := httptest.NewRecorder()
+		PaginatedPayloadHandler(w, httptest.NewRequest("GET", "/paginated_payload?limit=10&offset="+offset+"&cardinality=4&size_variation=50&seed=1", nil))
+		var page PaginatedResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+			t.Fatalf("Failed to parse paginated response: %v", err)
+		}
+		for _, item := range page.Result {
+			values[item.Value] = true
+		}
+	}
+	if len(values) != 4 {
+		t.Errorf("Expected 4 distinct values across pages, got %d", len(values))
+	}
+}
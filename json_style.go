@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// JSONStyle selects the whitespace between JSON tokens
+type JSONStyle string
+
+// Styles supported by the json_style query parameter. The empty style is the
+// endpoint's default: compact, with one item per line on /stream_payload.
+const (
+	JSONStyleDefault JSONStyle = ""
+	JSONStyleCompact JSONStyle = "compact" // No whitespace between tokens
+	JSONStyleSpaced  JSONStyle = "spaced"  // One space after every , and :
+	JSONStylePretty  JSONStyle = "pretty"  // Indented, the same as pretty=true
+)
+
+// getJSONStyle parses the json_style query parameter. Without it,
+// pretty=true selects JSONStylePretty.
+func getJSONStyle(r *http.Request) (JSONStyle, error) {
+	style := JSONStyle(strings.ToLower(r.URL.Query().Get("json_style")))
+	switch style {
+	case JSONStyleDefault:
+		if isPretty(r) {
+			return JSONStylePretty, nil
+		}
+		return JSONStyleDefault, nil
+	case JSONStyleCompact, JSONStyleSpaced, JSONStylePretty:
+		return style, nil
+	default:
+		return JSONStyleDefault, fmt.Errorf("json_style must be one of: %s, %s, %s", JSONStyleCompact, JSONStyleSpaced, JSONStylePretty)
+	}
+}
+
+// inline reports whether the style writes a whole document on one line,
+// including the framing of streamed items
+func (s JSONStyle) inline() bool {
+	return s == JSONStyleCompact || s == JSONStyleSpaced
+}
+
+// arraySeparator returns the bytes written between two array elements
+func (s JSONStyle) arraySeparator() string {
+	switch s {
+	case JSONStyleSpaced:
+		return ", "
+	case JSONStylePretty:
+		return ",\n"
+	}
+	return ","
+}
+
+// format applies the style to a compact JSON value. Pretty values are
+// indented as elements of a top-level array.
+func (s JSONStyle) format(data []byte) ([]byte, error) {
+	switch s {
+	case JSONStyleSpaced:
+		return appendSpacedJSON(nil, data), nil
+	case JSONStylePretty:
+		return indentArrayElement(data)
+	}
+	return data, nil
+}
+
+// appendSpacedJSON appends compact JSON src to dst with a space after every
+// comma and colon between tokens, the separators of Python's json.dumps.
+// Commas and colons inside strings are left alone.
+func appendSpacedJSON(dst, src []byte) []byte {
+	inString, escaped := false, false
+	for _, c := range src {
+		dst = append(dst, c)
+		switch {
+		case escaped:
+			escaped = false
+		case inString && c == '\\':
+			escaped = true
+		case c == '"':
+			inString = !inString
+		case !inString && (c == ',' || c == ':'):
+			dst = append(dst, ' ')
+		}
+	}
+	return dst
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestJSONStyle(t *testing.T) {
+	originalAuth := *enableAuth
+	*enableAuth = false
+	defer func() { *enableAuth = originalAuth }()
+
+	const fixed = "&fixed_timestamp=2024-01-15T10:00:00Z"
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		url     string
+		want    string
+	}{
+		{"rest compact", RestPayloadHandler, "/rest_payload?count=2&json_style=compact",
+			`[{"id":1,"name":"Object 1"},{"id":2,"name":"Object 2"}]` + "\n"},
+		{"rest spaced", RestPayloadHandler, "/rest_payload?count=2&json_style=spaced",
+			`[{"id": 1, "name": "Object 1"}, {"id": 2, "name": "Object 2"}]` + "\n"},
+		{"rest pretty", RestPayloadHandler, "/rest_payload?count=1&json_style=pretty",
+			"[\n  {\n    \"id\": 1,\n    \"name\": \"Object 1\"\n  }\n]\n"},
+		{"rest spaced strings", RestPayloadHandler, "/rest_payload?count=1&json_style=spaced&value_template=a,b:%7B%7B.ID%7D%7D",
+			`[{"id": 1, "name": "a,b:1"}]` + "\n"},
+		{"stream default", StreamingPayloadHandler, "/stream_payload?count=2&delay=0" + fixed,
+			"[\n" + `{"id":0,"value":"streamed data 0","timestamp":"2024-01-15T10:00:00Z"}` + ",\n" + `{"id":1,"value":"streamed data 1","timestamp":"2024-01-15T10:00:00Z"}` + "\n]"},
+		{"stream compact", StreamingPayloadHandler, "/stream_payload?count=2&delay=0&json_style=compact" + fixed,
+			`[{"id":0,"value":"streamed data 0","timestamp":"2024-01-15T10:00:00Z"},{"id":1,"value":"streamed data 1","timestamp":"2024-01-15T10:00:00Z"}]`},
+		{"stream spaced", StreamingPayloadHandler, "/stream_payload?count=2&delay=0&json_style=spaced" + fixed,
+			`[{"id": 0, "value": "streamed data 0", "timestamp": "2024-01-15T10:00:00Z"}, {"id": 1, "value": "streamed data 1", "timestamp": "2024-01-15T10:00:00Z"}]`},
+		{"paginated spaced", PaginatedPayloadHandler, "/paginated_payload?limit=1&total=2&json_style=spaced" + fixed,
+			`{"result": [{"id": 1, "value": "Item 1", "timestamp": "2024-01-15T10:00:00Z"}], "metadata": {"total_count": 2, "limit": 1, "has_more": true, "next_offset": 1}}` + "\n"},
+		{"paginated compact", PaginatedPayloadHandler, "/paginated_payload?limit=1&total=2&json_style=compact&pretty=true" + fixed,
+			`{"result":[{"id":1,"value":"Item 1","timestamp":"2024-01-15T10:00:00Z"}],"metadata":{"total_count":2,"limit":1,"has_more":true,"next_offset":1}}` + "\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			tt.handler(w, httptest.NewRequest("GET", tt.url, nil))
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+			}
+			if got := w.Body.String(); got != tt.want {
+				t.Errorf("Unexpected bytes\n got: %q\nwant: %q", got, tt.want)
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+	RestPayloadHandler(w, httptest.NewRequest("GET", "/rest_payload?json_style=tabs", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unknown style, got %d", w.Code)
+	}
+}
+
+// TestJSONStyleStreamEnvelope checks the single-line object envelope,
+// whose metadata varies in duration
+func TestJSONStyleStreamEnvelope(t *testing.T) {
+	originalAuth := *enableAuth
+	*enableAuth = false
+	defer func() { *enableAuth = originalAuth }()
+
+	w := httptest.NewRecorder()
+	StreamingPayloadHandler(w, httptest.NewRequest("GET", "/stream_payload?count=2&delay=0&envelope=object&json_style=spaced", nil))
+	body := w.Body.String()
+	if !strings.HasPrefix(body, `{"result": [{"id": 0, `) || !strings.Contains(body, `}], "metadata": {"total_count": 2, `) {
+		t.Errorf("Unexpected spaced envelope: %q", body)
+	}
+	if strings.Count(body, "\n") != 1 || !strings.HasSuffix(body, "}\n") {
+		t.Errorf("Expected a single line, got %q", body)
+	}
+	if !json.Valid([]byte(body)) {
+		t.Errorf("Expected valid JSON, got %q", body)
+	}
+}
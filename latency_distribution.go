@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// latencyPercentilesKey is the simulation_config key holding percentile targets
+const latencyPercentilesKey = "latency_percentiles"
+
+// LatencyPercentile is a single target point of a latency distribution,
+// e.g. Quantile 0.95 and Delay 500ms for "p95": "500ms"
+type LatencyPercentile struct {
+	Quantile float64
+	Delay    time.Duration
+}
+
+// LatencyDistribution samples delays that approximately reproduce a set of
+// percentile targets. Between targets the inverse CDF is linear, starting at
+// zero for p0. Above the highest target the slope of the last segment is
+// continued up to p100.
+type LatencyDistribution struct {
+	points []LatencyPercentile
+}
+
+// parseLatencyPercentiles builds a distribution from a latency_percentiles
+// object such as {"p50": "100ms", "p95": "500ms", "p99": "2s"}. Values may be
+// delay strings or plain numbers of milliseconds.
+func parseLatencyPercentiles(value interface{}) (*LatencyDistribution, error) {
+	targets, ok := value.(map[string]interface{})
+	if !ok || len(targets) == 0 {
+		return nil, fmt.Errorf("%s must be a non-empty object", latencyPercentilesKey)
+	}
+
+	points := make([]LatencyPercentile, 0, len(targets))
+	for key, raw := range targets {
+		if !strings.HasPrefix(key, "p") {
+			return nil, fmt.Errorf("invalid percentile key %q (expected e.g. p95)", key)
+		}
+		percent, err := strconv.ParseFloat(key[1:], 64)
+		if err != nil || percent <= 0 || percent >= 100 {
+			return nil, fmt.Errorf("invalid percentile key %q (expected e.g. p95)", key)
+		}
+
+		var delay time.Duration
+		switch v := raw.(type) {
+		case string:
+			delay, err = ParseDelay(v)
+			if err != nil {
+				return nil, fmt.Errorf("percentile %s: %v", key, err)
+			}
+		case float64:
+			delay = time.Duration(v * float64(time.Millisecond))
+		default:
+			return nil, fmt.Errorf("percentile %s must be a delay string or milliseconds", key)
+		}
+		if delay < 0 {
+			return nil, fmt.Errorf("percentile %s must not be negative", key)
+		}
+
+		points = append(points, LatencyPercentile{Quantile: percent / 100, Delay: delay})
+	}
+
+	sort.Slice(points, func(i, j int) bool { return points[i].Quantile < points[j].Quantile })
+	for i := 1; i < len(points); i++ {
+		if points[i].Quantile == points[i-1].Quantile {
+			return nil, fmt.Errorf("duplicate percentile p%g", points[i].Quantile*100)
+		}
+		if points[i].Delay < points[i-1].Delay {
+			return nil, fmt.Errorf("percentile delays must not decrease (p%g > p%g)",
+				points[i-1].Quantile*100, points[i].Quantile*100)
+		}
+	}
+
+	return &LatencyDistribution{points: points}, nil
+}
+
+// curve returns the distribution's inverse CDF anchors including p0 and p100
+func (d *LatencyDistribution) curve() []LatencyPercentile {
+	curve := make([]LatencyPercentile, 0, len(d.points)+2)
+	curve = append(curve, LatencyPercentile{Quantile: 0, Delay: 0})
+	curve = append(curve, d.points...)
+
+	last := curve[len(curve)-1]
+	prev := curve[len(curve)-2]
+	slope := float64(last.Delay-prev.Delay) / (last.Quantile - prev.Quantile)
+	return append(curve, LatencyPercentile{
+		Quantile: 1,
+		Delay:    last.Delay + time.Duration(math.Round(slope*(1-last.Quantile))),
+	})
+}
+
+// DelayAt returns the delay at quantile q in [0, 1]
+func (d *LatencyDistribution) DelayAt(q float64) time.Duration {
+	curve := d.curve()
+	for i := 1; i < len(curve); i++ {
+		lo, hi := curve[i-1], curve[i]
+		if q <= hi.Quantile {
+			frac := (q - lo.Quantile) / (hi.Quantile - lo.Quantile)
+			return lo.Delay + time.Duration(math.Round(frac*float64(hi.Delay-lo.Delay)))
+		}
+	}
+	return curve[len(curve)-1].Delay
+}
+
+// Sample draws a random delay from the distribution. If no random number can
+// be obtained the median is returned.
+func (d *LatencyDistribution) Sample() time.Duration {
+	q, err := secureRandFloat32()
+	if err != nil {
+		return d.DelayAt(0.5)
+	}
+	return d.DelayAt(float64(q))
+}
+
+// Mean returns the expected value of a sampled delay
+func (d *LatencyDistribution) Mean() time.Duration {
+	curve := d.curve()
+	var mean float64
+	for i := 1; i < len(curve); i++ {
+		lo, hi := curve[i-1], curve[i]
+		mean += (hi.Quantile - lo.Quantile) * float64(lo.Delay+hi.Delay) / 2
+	}
+	return time.Duration(math.Round(mean))
+}
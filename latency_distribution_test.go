@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+// TestLatencyDistributionPercentiles draws delays at evenly spaced quantiles,
+// as uniform random samples would in the limit, and checks that the measured
+// percentiles match the configured targets
+func TestLatencyDistributionPercentiles(t *testing.T) {
+	tempDir := t.TempDir()
+
+	scenarioJSON := []byte(`{
+		"schema_version": "1.0.0",
+		"scenario_name": "Realistic API Latency",
+		"scenario_type": "custom",
+		"base_delay": "100ms",
+		"scenario_parameters": {
+			"simulation_config": {
+				"latency_percentiles": {"p50": "100ms", "p95": "500ms", "p99": 1500}
+			}
+		}
+	}`)
+	if err := os.WriteFile(filepath.Join(tempDir, "latency.json"), scenarioJSON, 0644); err != nil {
+		t.Fatalf("Failed to write test scenario file: %v", err)
+	}
+
+	sm := &ScenarioManager{
+		scenarios: make(map[string]*Scenario),
+		userPath:  tempDir,
+		validator: NewScenarioValidator(),
+	}
+	sm.loadUserScenarios()
+
+	dist := sm.GetLatencyDistribution("custom")
+	if dist == nil {
+		t.Fatal("Expected latency distribution for custom scenario")
+	}
+	if sm.GetLatencyDistribution("custom") != dist {
+		t.Error("Expected the distribution parsed once at load time")
+	}
+
+	const samples = 10000
+	delays := make([]time.Duration, samples)
+	for i := range delays {
+		delays[i] = dist.DelayAt((float64(i) + 0.5) / samples)
+	}
+	if !sort.SliceIsSorted(delays, func(i, j int) bool { return delays[i] < delays[j] }) {
+		t.Error("Expected delays to grow with the quantile")
+	}
+
+	tests := []struct {
+		quantile float64
+		expected time.Duration
+	}{
+		{0.50, 100 * time.Millisecond},
+		{0.95, 500 * time.Millisecond},
+		{0.99, 1500 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		measured := delays[int(tt.quantile*samples)]
+		tolerance := tt.expected / 10
+		if measured < tt.expected-tolerance || measured > tt.expected+tolerance {
+			t.Errorf("p%g: expected ~%v, measured %v", tt.quantile*100, tt.expected, measured)
+		}
+	}
+
+	// Random samples stay within the distribution
+	maxDelay := dist.DelayAt(1)
+	for i := 0; i < 1000; i++ {
+		if delay := dist.Sample(); delay < 0 || delay > maxDelay {
+			t.Fatalf("Expected samples between 0 and %v, got %v", maxDelay, delay)
+		}
+	}
+
+	// Scenarios without percentile targets have no distribution
+	sm.loadEmbeddedScenarios()
+	if sm.GetLatencyDistribution("peak_hours") != nil {
+		t.Error("Expected no latency distribution for peak_hours")
+	}
+}
+
+func TestLatencyDistributionDelayAt(t *testing.T) {
+	dist, err := parseLatencyPercentiles(map[string]interface{}{"p50": "100ms", "p90": "300ms"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		quantile float64
+		expected time.Duration
+	}{
+		{0, 0},
+		{0.25, 50 * time.Millisecond},
+		{0.5, 100 * time.Millisecond},
+		{0.7, 200 * time.Millisecond},
+		{0.9, 300 * time.Millisecond},
+		{1, 350 * time.Millisecond}, // slope of the last segment continued
+	}
+	for _, tt := range tests {
+		if got := dist.DelayAt(tt.quantile); got != tt.expected {
+			t.Errorf("DelayAt(%v): expected %v, got %v", tt.quantile, tt.expected, got)
+		}
+	}
+
+	// 0.5*50ms + 0.4*200ms + 0.1*325ms
+	if mean := dist.Mean(); mean != 137500*time.Microsecond {
+		t.Errorf("Expected mean 137.5ms, got %v", mean)
+	}
+}
+
+func TestLatencyPercentilesValidation(t *testing.T) {
+	validator := NewScenarioValidator()
+
+	tests := []struct {
+		name        string
+		percentiles string
+		expectError bool
+	}{
+		{"valid delay strings", `{"p50": "100ms", "p99": "1s"}`, false},
+		{"valid milliseconds", `{"p50": 100, "p99.9": 2000}`, false},
+		{"empty", `{}`, true},
+		{"not an object", `"100ms"`, true},
+		{"invalid key", `{"median": "100ms"}`, true},
+		{"out of range key", `{"p100": "100ms"}`, true},
+		{"invalid delay", `{"p50": "fast"}`, true},
+		{"decreasing delays", `{"p50": "500ms", "p95": "100ms"}`, true},
+		{"duplicate percentile", `{"p50": "100ms", "p50.0": "100ms"}`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var percentiles interface{}
+			if err := json.Unmarshal([]byte(tt.percentiles), &percentiles); err != nil {
+				t.Fatalf("Invalid test input: %v", err)
+			}
+
+			scenario := &Scenario{
+				ScenarioName: "Latency Test",
+				ScenarioType: "custom",
+				BaseDelay:    "100ms",
+				ScenarioParams: &ScenarioParameters{
+					SimulationConfig: map[string]interface{}{latencyPercentilesKey: percentiles},
+				},
+			}
+
+			err := validator.ValidateScenario(scenario)
+			if tt.expectError && err == nil {
+				t.Error("Expected validation error")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Unexpected validation error: %v", err)
+			}
+		})
+	}
+}
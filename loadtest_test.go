@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunLoadTest(t *testing.T) {
+	originalAuth := *enableAuth
+	*enableAuth = false
+	defer func() { *enableAuth = originalAuth }()
+
+	server := httptest.NewServer(http.HandlerFunc(RestPayloadHandler))
+	defer server.Close()
+
+	result := runLoadTest(context.Background(), server.Client(), server.URL+"/rest_payload?count=10", 4, 200*time.Millisecond)
+	if result.Requests == 0 {
+		t.Fatal("Expected the load test to send requests")
+	}
+	if result.Errors != 0 || result.StatusCodes[http.StatusOK] != result.Requests {
+		t.Errorf("Expected %d successful requests, got %d errors and status codes %v", result.Requests, result.Errors, result.StatusCodes)
+	}
+	if len(result.Latencies) != result.Requests || result.Bytes == 0 {
+		t.Errorf("Expected a latency and body bytes per request, got %d latencies and %d bytes", len(result.Latencies), result.Bytes)
+	}
+	if p50, p99 := result.percentile(50), result.percentile(99); p50 <= 0 || p99 < p50 {
+		t.Errorf("Expected ordered percentiles, got p50 %v and p99 %v", p50, p99)
+	}
+
+	var out bytes.Buffer
+	printLoadTestResult(&out, server.URL, 4, result)
+	for _, want := range []string{"Requests:     ", "Status codes: 200=", "p50 ", "p99 "} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected %q in the summary:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestLoadTestPercentile(t *testing.T) {
+	result := loadTestResult{}
+	for i := 1; i <= 100; i++ {
+		result.Latencies = append(result.Latencies, time.Duration(i)*time.Millisecond)
+	}
+	for p, want := range map[float64]time.Duration{50: 50 * time.Millisecond, 90: 90 * time.Millisecond, 99: 99 * time.Millisecond, 100: 100 * time.Millisecond, 0: time.Millisecond} {
+		if got := result.percentile(p); got != want {
+			t.Errorf("p%v: expected %v, got %v", p, want, got)
+		}
+	}
+}
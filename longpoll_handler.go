@@ -0,0 +1,209 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Limits for the long-poll endpoint
+const (
+	defaultLongPollTimeout = 30 * time.Second
+	maxLongPollTimeout     = 5 * time.Minute
+	maxLongPollCount       = 1000
+
+	// longPollWriteSlack extends the write deadline beyond the poll timeout,
+	// leaving time to send the response
+	longPollWriteSlack = 10 * time.Second
+)
+
+// LongPollPlugin implements PayloadPlugin for testing long-poll clients
+type LongPollPlugin struct{}
+
+// Path returns the HTTP path for the long-poll endpoint
+func (p LongPollPlugin) Path() string {
+	return "/longpoll"
+}
+
+// Handler returns the handler function for the long-poll endpoint
+func (p LongPollPlugin) Handler() http.HandlerFunc {
+	return LongPollHandler
+}
+
+func init() {
+	registerPlugin(LongPollPlugin{})
+}
+
+// LongPollResult is returned once data became available
+type LongPollResult struct {
+	Items    []StreamItem `json:"items"`
+	WaitedMs int64        `json:"waited_ms"`
+}
+
+// getLongPollDuration parses a non-negative duration parameter of at most
+// maxLongPollTimeout. ok is false if the parameter is not set.
+func getLongPollDuration(r *http.Request, name string) (d time.Duration, ok bool, err error) {
+	val := r.URL.Query().Get(name)
+	if val == "" {
+		return 0, false, nil
+	}
+	d, err = time.ParseDuration(val)
+	if err != nil || d < 0 || d > maxLongPollTimeout {
+		return 0, false, fmt.Errorf("%s must be a duration between 0s and %v (e.g. 30s)", name, maxLongPollTimeout)
+	}
+	return d, true, nil
+}
+
+// LongPollHandler holds the request open until new data is available or the
+// poll times out, like a long-poll event API.
+//
+// Query Parameters:
+//   - timeout: How long to wait for data (e.g., "30s", default: 30s, max: 5m)
+//   - ready_after: Simulated time until new data is available (default: never, the poll times out)
+//   - count: Number of items returned once data is available (default: 1, max: 1000)
+//
+// The response is 200 with the items if data became available within the
+// timeout, 204 No Content otherwise. A client that disconnects ends the poll
+// without a response.
+func LongPollHandler(w http.ResponseWriter, r *http.Request) {
+	timeout, ok, err := getLongPollDuration(r, "timeout")
+	if err != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, errCodeBadRequest, err.Error())
+		return
+	}
+	if !ok {
+		timeout = defaultLongPollTimeout
+	}
+	readyAfter, ready, err := getLongPollDuration(r, "ready_after")
+	if err != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, errCodeBadRequest, err.Error())
+		return
+	}
+	count := getIntParam(r, "count", 1)
+	if count < 1 || count > maxLongPollCount {
+		writeErrorResponse(w, r, http.StatusBadRequest, errCodeBadRequest, fmt.Sprintf("count must be between 1 and %d", maxLongPollCount))
+		return
+	}
+
+	// Polls may outlast the server's WriteTimeout; not every ResponseWriter
+	// supports deadlines, in which case the server default applies
+	http.NewResponseController(w).SetWriteDeadline(time.Now().Add(timeout + longPollWriteSlack))
+
+	wait := timeout
+	if ready && readyAfter < timeout {
+		wait = readyAfter
+	} else {
+		ready = false
+	}
+
+	start := time.Now()
+	if err := sleepContext(r.Context(), wait); err != nil {
+		// The client went away, nobody is left to answer
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	if !ready {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	result := LongPollResult{
+		Items:    generateItems(itemOptions{IDStart: 1}, 0, count),
+		WaitedMs: time.Since(start).Milliseconds(),
+	}
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, errCodeInternal, "Failed to encode response")
+	}
+}
+
+// OpenAPISpec returns the OpenAPI specification for the long-poll endpoint
+func (p LongPollPlugin) OpenAPISpec() OpenAPIPathSpec {
+	return OpenAPIPathSpec{
+		Path: "/longpoll",
+		Operation: OpenAPIPath{
+			Get: &OpenAPIOperation{
+				OperationID: "getLongPoll",
+				Summary:     "Long-poll for data",
+				Description: "Holds the connection open until simulated data is available (ready_after) or the timeout elapses, to test long-poll clients",
+				Tags:        []string{"resilience"},
+				Parameters: []OpenAPIParameter{
+					{
+						Name:        "timeout",
+						In:          "query",
+						Description: "How long to wait for data before answering 204 (default: 30s, max: 5m)",
+						Required:    false,
+						Schema: &OpenAPISchema{
+							Type:    "string",
+							Example: "30s",
+						},
+					},
+					{
+						Name:        "ready_after",
+						In:          "query",
+						Description: "Simulated time until new data is available. Without it, or if it is not shorter than timeout, the poll times out",
+						Required:    false,
+						Schema: &OpenAPISchema{
+							Type:    "string",
+							Example: "5s",
+						},
+					},
+					{
+						Name:        "count",
+						In:          "query",
+						Description: "Number of items returned once data is available (default: 1)",
+						Required:    false,
+						Schema: &OpenAPISchema{
+							Type:    "integer",
+							Minimum: &[]int{1}[0],
+							Maximum: &[]int{maxLongPollCount}[0],
+							Example: 1,
+						},
+					},
+				},
+				Responses: map[string]OpenAPIResponse{
+					"200": {
+						Description: "Data became available within the timeout",
+						Content: map[string]OpenAPIMediaType{
+							"application/json": {
+								Schema: &OpenAPISchema{
+									Type: "object",
+									Properties: map[string]*OpenAPISchema{
+										"items": {
+											Type: "array",
+											Items: &OpenAPISchema{
+												Type: "object",
+												Properties: map[string]*OpenAPISchema{
+													"id":        {Type: "integer"},
+													"value":     {Type: "string"},
+													"timestamp": {Type: "string", Format: "date-time"},
+												},
+											},
+										},
+										"waited_ms": {Type: "integer", Description: "How long the poll was held open"},
+									},
+								},
+							},
+						},
+					},
+					"204": {
+						Description: "The timeout elapsed without new data",
+					},
+					"400": {
+						Description: "Bad request - invalid timeout, ready_after or count",
+						Content: map[string]OpenAPIMediaType{
+							"text/plain": {
+								Schema: &OpenAPISchema{
+									Type:    "string",
+									Example: "timeout must be a duration between 0s and 5m0s (e.g. 30s)",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLongPollHandler_DataReady(t *testing.T) {
+	w := httptest.NewRecorder()
+	start := time.Now()
+	LongPollHandler(w, httptest.NewRequest("GET", "/longpoll?timeout=5s&ready_after=50ms&count=3", nil))
+	elapsed := time.Since(start)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if elapsed < 50*time.Millisecond || elapsed > 2*time.Second {
+		t.Errorf("Expected the poll to be held ~50ms, took %v", elapsed)
+	}
+	var result LongPollResult
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if len(result.Items) != 3 || result.Items[0].ID != 1 {
+		t.Errorf("Expected 3 items starting at ID 1, got %+v", result.Items)
+	}
+	if result.WaitedMs < 50 {
+		t.Errorf("Expected waited_ms >= 50, got %d", result.WaitedMs)
+	}
+}
+
+func TestLongPollHandler_Timeout(t *testing.T) {
+	for _, url := range []string{
+		"/longpoll?timeout=50ms",
+		"/longpoll?timeout=50ms&ready_after=1s",
+	} {
+		w := httptest.NewRecorder()
+		start := time.Now()
+		LongPollHandler(w, httptest.NewRequest("GET", url, nil))
+		elapsed := time.Since(start)
+
+		if w.Code != http.StatusNoContent {
+			t.Errorf("%s: expected status 204, got %d", url, w.Code)
+		}
+		if w.Body.Len() != 0 {
+			t.Errorf("%s: expected an empty body, got %q", url, w.Body.String())
+		}
+		if elapsed < 50*time.Millisecond || elapsed > 2*time.Second {
+			t.Errorf("%s: expected the poll to time out after ~50ms, took %v", url, elapsed)
+		}
+	}
+}
+
+func TestLongPollHandler_ClientDisconnect(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	w := httptest.NewRecorder()
+	start := time.Now()
+	LongPollHandler(w, httptest.NewRequest("GET", "/longpoll?timeout=1m", nil).WithContext(ctx))
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected the poll to end with the request context, took %v", elapsed)
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("Expected no response after a disconnect, got %q", w.Body.String())
+	}
+}
+
+func TestLongPollHandler_InvalidParams(t *testing.T) {
+	for _, url := range []string{
+		"/longpoll?timeout=abc",
+		"/longpoll?timeout=-1s",
+		"/longpoll?timeout=10m",
+		"/longpoll?ready_after=soon",
+		"/longpoll?count=0",
+	} {
+		w := httptest.NewRecorder()
+		LongPollHandler(w, httptest.NewRequest("GET", url, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", url, w.Code)
+		}
+	}
+}
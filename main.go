@@ -1,11 +1,16 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
 	"strconv"
+	"syscall"
 	"time"
 )
 
@@ -35,8 +40,17 @@ func registerPlugin(p PayloadPlugin) {
 
 // Setup the variables from the command line flags.
 var (
-	paramPort   = flag.String("port", "8080", "Port to run the HTTP server on")
-	paramVerify = flag.String("verify", "", "Validate a scenario file against the JSON schema and exit")
+	paramPort             = flag.String("port", "8080", "Port to run the HTTP server on")
+	paramVerify           = flag.String("verify", "", "Validate a scenario file against the JSON schema and exit")
+	paramScaffold         = flag.String("scaffold", "", "Print a minimal valid scenario of the given scenario_type and exit")
+	paramScenarioURL      = flag.String("scenario-url", "", "Load additional scenarios from a URL serving a scenario or a JSON array of scenarios")
+	paramStrictScenarios  = flag.Bool("strict-scenarios", false, "Exit with an error if a user scenario fails validation or the compatibility check instead of skipping it")
+	paramSchemaValidation = flag.Bool("schema-validation", false, "Also validate scenarios against the embedded JSON schema ("+scenarioSchemaFile+"), for -verify and at startup")
+	paramTLSCert          = flag.String("tls-cert", "", "TLS certificate file; serves HTTPS (with HTTP/2) when set together with -tls-key")
+	paramTLSKey           = flag.String("tls-key", "", "TLS private key file; serves HTTPS (with HTTP/2) when set together with -tls-cert")
+	paramH2C              = flag.Bool("h2c", false, "Accept HTTP/2 over cleartext (h2c) connections in addition to HTTP/1.1")
+	paramUnixSocket       = flag.String("unix-socket", "", "Listen on this Unix domain socket path instead of the TCP port")
+	paramNoKeepAlive      = flag.Bool("no-keepalive", false, "Disable HTTP keep-alive: answer with Connection: close so every request uses a fresh connection")
 )
 
 // Setup the port for the HTTP server.
@@ -58,24 +72,63 @@ func verifyScenarioFile(filePath string) {
 	validator.ValidateScenarioFile(filePath)
 }
 
-// registerPlugins registers all plugins with conditional authentication middleware
-func registerPlugins() {
+// serverMux routes all requests of the server. It is deliberately not
+// http.DefaultServeMux, which imported packages such as net/http/pprof
+// register handlers on as a side effect.
+var serverMux = http.NewServeMux()
+
+// registerPlugins registers all plugins on mux with conditional authentication middleware.
+// Every endpoint reports rate limit headers, including rejected requests, is
+// subject to the request timeout unless it is long-lived, and accepts gzip
+// compressed request bodies. Payload endpoints can answer HTTP/1.0-style
+// (see http10Middleware), force their transfer encoding (see
+// transferModeMiddleware) and hold back their headers (see
+// headerDelayMiddleware); endpoints with request bodies can hold back or
+// refuse 100 Continue (see expectContinueMiddleware). The landing page at /
+// lists the plugins (see registerIndex); the pprof handlers are added when
+// -pprof is set.
+func registerPlugins(mux *http.ServeMux) {
 	for _, p := range plugins {
 		path := p.Path()
 		// Exclude documentation endpoints from authentication for better UX
-		if path == "/swagger" || path == "/openapi.json" {
-			http.HandleFunc(path, p.Handler())
+		if isDocumentationPath(path) {
+			mux.HandleFunc(path, rateLimitHeadersMiddleware(requestTimeoutMiddleware(path, *paramRequestTimeout, gzipRequestMiddleware(p.Handler()))))
 			fmt.Printf("Registered endpoint: %s (no auth)\n", path)
 		} else {
-			http.HandleFunc(path, basicAuthMiddleware(p.Handler()))
+			mux.HandleFunc(path, rateLimitHeadersMiddleware(requestTimeoutMiddleware(path, *paramRequestTimeout, basicAuthMiddleware(headerDelayMiddleware(path, expectContinueMiddleware(path, transferModeMiddleware(path, http10Middleware(path, gzipRequestMiddleware(p.Handler())))))))))
 			fmt.Printf("Registered endpoint: %s\n", path)
 		}
 	}
+	registerIndex(mux)
+	registerPprof(mux)
+}
+
+// tlsEnabled reports whether a certificate and key were configured
+func tlsEnabled() bool {
+	return *paramTLSCert != "" && *paramTLSKey != ""
 }
 
 // printStartupInfo prints application startup information and usage examples
 func printStartupInfo(port string) {
-	fmt.Printf("\nStarting payloadBuddy %s on http://localhost:%s\n", Version, port)
+	scheme := "http"
+	if tlsEnabled() {
+		scheme = "https"
+	}
+	if *paramUnixSocket != "" {
+		fmt.Printf("\nStarting payloadBuddy %s on unix socket %s\n", Version, *paramUnixSocket)
+		fmt.Printf("Connect with: curl --unix-socket %s %s://localhost/rest_payload\n", *paramUnixSocket, scheme)
+	} else {
+		fmt.Printf("\nStarting payloadBuddy %s on %s://localhost:%s\n", Version, scheme, port)
+	}
+	if tlsEnabled() {
+		fmt.Println("TLS enabled: HTTP/2 is negotiated via ALPN")
+	}
+	if *paramH2C {
+		fmt.Println("h2c enabled: HTTP/2 accepted over cleartext connections")
+	}
+	if *paramNoKeepAlive {
+		fmt.Println("Keep-alive disabled: every request uses a fresh connection")
+	}
 
 	// Print authentication info if enabled
 	printAuthenticationInfo()
@@ -86,7 +139,7 @@ func printStartupInfo(port string) {
 
 // initializeServer registers plugins and prepares server startup
 func initializeServer() string {
-	registerPlugins()
+	registerPlugins(serverMux)
 	port := setupPort(*paramPort)
 	printStartupInfo(port)
 	return port
@@ -129,6 +182,20 @@ func getScenarioUsageContext(scenarioType string) string {
 		return " • Best for: both (random delays simulate real network conditions)"
 	case "database_load":
 		return " • Best for: streaming (progressive degradation), pagination (single delay per page)"
+	case "recovery":
+		return " • Best for: streaming (delays ramp down to baseline), pagination (post-incident delay per page)"
+	case "error_storm":
+		return " • Best for: circuit breakers and retry logic (outage, then recovery)"
+	case "pagination_drift":
+		return " • Best for: pagination only (data set grows between pages)"
+	case "pagination_shrink":
+		return " • Best for: pagination only (data set shrinks between pages, late pages are empty)"
+	case "slow_connect":
+		return " • Best for: connect and first-byte timeouts (slow start, then normal streaming)"
+	case "circuit_breaker":
+		return " • Best for: resilience libraries (open, half-open probe, closed)"
+	case "token_expiry":
+		return " • Best for: token refresh logic (401 until the client sends new credentials)"
 	default:
 		return ""
 	}
@@ -167,6 +234,20 @@ func printServiceNowScenarios() {
 				fmt.Printf("  - %s: Random network delays\n", scenarioType)
 			case "database_load":
 				fmt.Printf("  - %s: Progressive database load simulation\n", scenarioType)
+			case "recovery":
+				fmt.Printf("  - %s: Gradual recovery with ramp-down delays\n", scenarioType)
+			case "error_storm":
+				fmt.Printf("  - %s: Errors for the first requests, then recovery\n", scenarioType)
+			case "pagination_drift":
+				fmt.Printf("  - %s: Data set grows between pages\n", scenarioType)
+			case "pagination_shrink":
+				fmt.Printf("  - %s: Data set shrinks between pages\n", scenarioType)
+			case "slow_connect":
+				fmt.Printf("  - %s: Slow connection setup before the first byte\n", scenarioType)
+			case "circuit_breaker":
+				fmt.Printf("  - %s: Circuit open, then a half-open probe closes it\n", scenarioType)
+			case "token_expiry":
+				fmt.Printf("  - %s: Credentials expire after a few requests\n", scenarioType)
 			default:
 				fmt.Printf("  - %s: Custom scenario\n", scenarioType)
 			}
@@ -177,26 +258,98 @@ func printServiceNowScenarios() {
 	}
 }
 
+// newHTTPServer creates the HTTP server with proper timeouts to prevent
+// resource exhaustion. HTTP/1.1 and HTTP/2 over TLS are always enabled;
+// h2c additionally accepts HTTP/2 over cleartext connections.
+func newHTTPServer(addr string, h2c bool) *http.Server {
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	protocols.SetHTTP2(true)
+	protocols.SetUnencryptedHTTP2(h2c)
+
+	return &http.Server{
+		Addr:         addr,
+		Handler:      serverMux,
+		ReadTimeout:  30 * time.Second,
+		WriteTimeout: 30 * time.Second,
+		IdleTimeout:  120 * time.Second,
+		Protocols:    protocols,
+	}
+}
+
+// disableKeepAlives makes server close every connection after one request
+// and tell clients so with a Connection: close header, to test clients under
+// connection churn. HTTP/2 connections are not affected by the header.
+func disableKeepAlives(server *http.Server) {
+	server.SetKeepAlivesEnabled(false)
+	next := server.Handler
+	server.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Connection", "close")
+		next.ServeHTTP(w, r)
+	})
+}
+
+// newListener opens the listener the server accepts connections on: a Unix
+// domain socket if socketPath is set, the TCP address otherwise. A stale
+// socket file left behind by a previous run is removed first. Closing the
+// listener removes the socket file again.
+func newListener(addr, socketPath string) (net.Listener, error) {
+	if socketPath == "" {
+		return net.Listen("tcp", addr)
+	}
+
+	if info, err := os.Stat(socketPath); err == nil && info.Mode()&os.ModeSocket != 0 {
+		if err := os.Remove(socketPath); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket %s: %v", socketPath, err)
+		}
+	}
+	return net.Listen("unix", socketPath)
+}
+
 // startHTTPServer starts the HTTP server with proper configuration
 func startHTTPServer(port string) {
 	addr := ":" + port
 
 	fmt.Println("\nPress Ctrl+C to stop the server")
 
-	// Start the HTTP server with proper timeouts to prevent resource exhaustion
-	server := &http.Server{
-		Addr:         addr,
-		Handler:      nil, // Use DefaultServeMux
-		ReadTimeout:  30 * time.Second,
-		WriteTimeout: 30 * time.Second,
-		IdleTimeout:  120 * time.Second,
+	server := newHTTPServer(addr, *paramH2C)
+	if *paramNoKeepAlive {
+		disableKeepAlives(server)
+	}
+
+	listener, err := newListener(addr, *paramUnixSocket)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Server failed to start: %v\n", err)
+		os.Exit(1)
 	}
 
-	if err := server.ListenAndServe(); err != nil {
+	// Shut down gracefully on Ctrl+C or SIGTERM so the listener, and with it
+	// a Unix socket file, is cleaned up
+	shutdownDone := make(chan struct{})
+	go func() {
+		defer close(shutdownDone)
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		<-signals
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "Server shutdown: %v\n", err)
+		}
+	}()
+
+	if tlsEnabled() {
+		err = server.ServeTLS(listener, *paramTLSCert, *paramTLSKey)
+	} else {
+		err = server.Serve(listener)
+	}
+	if !errors.Is(err, http.ErrServerClosed) {
 		// Print error to stderr and exit with non-zero code.
 		fmt.Fprintf(os.Stderr, "Server failed to start: %v\n", err)
 		os.Exit(1)
 	}
+	<-shutdownDone
 }
 
 // main is the entry point for the payloadBuddy application.
@@ -212,8 +365,70 @@ func main() {
 		return
 	}
 
+	// Print a scenario skeleton if requested
+	if *paramScaffold != "" {
+		printScenarioScaffold(*paramScaffold)
+		return
+	}
+
+	// Run a load test against a server instead of serving
+	if *paramLoadTest != "" {
+		runLoadTestCommand(*paramLoadTest, *paramLoadTestConcurrency, *paramLoadTestDuration)
+		return
+	}
+
 	// Initialize scenario manager
-	scenarioManager = NewScenarioManager()
+	if *paramStrictScenarios {
+		sm, err := NewStrictScenarioManager()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid user scenarios (-strict-scenarios):\n%v\n", err)
+			os.Exit(1)
+		}
+		scenarioManager = sm
+	} else {
+		scenarioManager = NewScenarioManager()
+	}
+	if *paramScenarioURL != "" {
+		scenarioManager.LoadRemoteScenarios(*paramScenarioURL)
+	}
+
+	// TLS needs both a certificate and a key
+	if (*paramTLSCert == "") != (*paramTLSKey == "") {
+		fmt.Fprintln(os.Stderr, "Both -tls-cert and -tls-key must be set to enable TLS")
+		os.Exit(1)
+	}
+
+	// Rate limit headers count per client IP over a fixed window
+	if *paramRateLimit < 1 || *paramRateLimitWindow <= 0 {
+		fmt.Fprintln(os.Stderr, "-rate-limit and -rate-limit-window must be positive")
+		os.Exit(1)
+	}
+	rateLimitCounter = NewRateLimitCounter(*paramRateLimit, *paramRateLimitWindow)
+
+	scenarioAccess = NewScenarioAccess(*paramAllowScenarios, *paramDenyScenarios)
+
+	if *paramRequestTimeout < 0 {
+		fmt.Fprintln(os.Stderr, "-request-timeout must not be negative")
+		os.Exit(1)
+	}
+
+	if *paramRestDefaultCount < 1 || *paramRestDefaultCount > maxRestCount {
+		fmt.Fprintf(os.Stderr, "-rest-default-count must be between 1 and %d\n", maxRestCount)
+		os.Exit(1)
+	}
+	if *paramStreamDefaultDelay < 0 {
+		fmt.Fprintln(os.Stderr, "-stream-default-delay must not be negative")
+		os.Exit(1)
+	}
+	if *paramPaginatedDefaultLimit < 1 || *paramPaginatedDefaultLimit > maxPageSize {
+		fmt.Fprintf(os.Stderr, "-paginated-default-limit must be between 1 and %d\n", maxPageSize)
+		os.Exit(1)
+	}
+
+	if err := setupRecording(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 
 	// Setup authentication if enabled
 	setupAuthentication()
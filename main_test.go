@@ -1,6 +1,15 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -98,11 +107,19 @@ func TestPluginRegistration_InitFunctions(t *testing.T) {
 	// the expected plugins are registered
 
 	expectedPlugins := map[string]bool{
-		"/rest_payload":      false,
-		"/stream_payload":    false,
-		"/paginated_payload": false,
-		"/openapi.json":      false,
-		"/swagger":           false,
+		"/rest_payload":           false,
+		"/stream_payload":         false,
+		"/paginated_payload":      false,
+		"/openapi.json":           false,
+		"/swagger":                false,
+		"/reset":                  false,
+		"/batch":                  false,
+		"/redirect":               false,
+		"/time":                   false,
+		"/longpoll":               false,
+		"/generate":               false,
+		"/scenarios/examples.zip": false,
+		"/slow-read":              false,
 	}
 
 	// Check that all expected plugins are registered
@@ -153,8 +170,9 @@ func TestPayloadPluginInterface_Compliance(t *testing.T) {
 		if spec.Path != path {
 			t.Errorf("Plugin %T: OpenAPISpec path %q doesn't match Path() %q", plugin, spec.Path, path)
 		}
-		if spec.Operation.Get == nil {
-			t.Errorf("Plugin %T: OpenAPISpec missing GET operation", plugin)
+		op := spec.Operation
+		if op.Get == nil && op.Post == nil && op.Put == nil && op.Delete == nil {
+			t.Errorf("Plugin %T: OpenAPISpec has no operation", plugin)
 		}
 	}
 }
@@ -523,3 +541,149 @@ func TestPrintServiceNowScenarios_FallbackLogic(t *testing.T) {
 	// This should trigger the fallback logic in printServiceNowScenarios
 	printServiceNowScenarios()
 }
+
+// TestNewHTTPServer_HTTP2TLS checks that the server negotiates HTTP/2 over TLS
+// and that streaming responses still arrive complete over h2
+func TestNewHTTPServer_HTTP2TLS(t *testing.T) {
+	*enableAuth = false
+	ts := httptest.NewUnstartedServer(nil)
+	ts.Config = newHTTPServer("", false)
+	ts.Config.Handler = http.HandlerFunc(StreamingPayloadHandler)
+	ts.EnableHTTP2 = true
+	ts.StartTLS()
+	defer ts.Close()
+
+	resp, err := ts.Client().Get(ts.URL + "/stream_payload?count=5&delay=0&batch_size=1")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.ProtoMajor != 2 {
+		t.Errorf("Expected HTTP/2, got %s", resp.Proto)
+	}
+
+	var items []StreamItem
+	if err := json.NewDecoder(resp.Body).Decode(&items); err != nil {
+		t.Fatalf("Failed to decode streamed JSON over HTTP/2: %v", err)
+	}
+	if len(items) != 5 {
+		t.Errorf("Expected 5 items, got %d", len(items))
+	}
+}
+
+// TestNewHTTPServer_H2C checks that cleartext HTTP/2 is only accepted with h2c enabled
+func TestNewHTTPServer_H2C(t *testing.T) {
+	*enableAuth = false
+	protocols := new(http.Protocols)
+	protocols.SetUnencryptedHTTP2(true)
+	client := &http.Client{Transport: &http.Transport{Protocols: protocols}}
+
+	for _, h2c := range []bool{true, false} {
+		ts := httptest.NewUnstartedServer(nil)
+		ts.Config = newHTTPServer("", h2c)
+		ts.Config.Handler = http.HandlerFunc(RestPayloadHandler)
+		ts.Start()
+
+		resp, err := client.Get(ts.URL + "/rest_payload?count=1")
+		if h2c {
+			if err != nil {
+				t.Errorf("h2c request failed: %v", err)
+			} else {
+				if resp.ProtoMajor != 2 {
+					t.Errorf("Expected HTTP/2 with h2c enabled, got %s", resp.Proto)
+				}
+				resp.Body.Close()
+			}
+		} else if err == nil {
+			resp.Body.Close()
+			t.Error("Expected cleartext HTTP/2 to be rejected without h2c")
+		}
+
+		ts.Close()
+	}
+}
+
+// TestDisableKeepAlives checks that responses carry Connection: close and
+// that consecutive requests do not reuse a connection
+func TestDisableKeepAlives(t *testing.T) {
+	*enableAuth = false
+	ts := httptest.NewUnstartedServer(nil)
+	ts.Config = newHTTPServer("", false)
+	ts.Config.Handler = http.HandlerFunc(RestPayloadHandler)
+	disableKeepAlives(ts.Config)
+	ts.Start()
+	defer ts.Close()
+
+	for i := 0; i < 2; i++ {
+		reused := false
+		trace := &httptrace.ClientTrace{GotConn: func(info httptrace.GotConnInfo) { reused = info.Reused }}
+		req, err := http.NewRequestWithContext(httptrace.WithClientTrace(context.Background(), trace), "GET", ts.URL+"/rest_payload?count=1", nil)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+		resp, err := ts.Client().Do(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		// The client consumes the Connection header and reports it as Close
+		if !resp.Close {
+			t.Errorf("Request %d: expected a Connection: close header", i+1)
+		}
+		if reused {
+			t.Errorf("Request %d: expected a fresh connection, got a reused one", i+1)
+		}
+	}
+}
+
+// TestNewListener_UnixSocket serves /rest_payload over a Unix domain socket
+// and checks that the socket file is removed when the server stops
+func TestNewListener_UnixSocket(t *testing.T) {
+	*enableAuth = false
+
+	// Socket paths are limited to ~100 bytes, so avoid the long t.TempDir()
+	dir, err := os.MkdirTemp("", "pb")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	socketPath := filepath.Join(dir, "payloadbuddy.sock")
+
+	listener, err := newListener("", socketPath)
+	if err != nil {
+		t.Fatalf("Failed to listen on unix socket: %v", err)
+	}
+
+	server := newHTTPServer("", false)
+	server.Handler = http.HandlerFunc(RestPayloadHandler)
+	go server.Serve(listener)
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socketPath)
+		},
+	}}
+	resp, err := client.Get("http://unix/rest_payload?count=3")
+	if err != nil {
+		t.Fatalf("Request over unix socket failed: %v", err)
+	}
+	var items []Item
+	err = json.NewDecoder(resp.Body).Decode(&items)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(items) != 3 {
+		t.Errorf("Expected 3 items, got %d", len(items))
+	}
+
+	if err := server.Close(); err != nil {
+		t.Fatalf("Failed to close server: %v", err)
+	}
+	if _, err := os.Stat(socketPath); !os.IsNotExist(err) {
+		t.Errorf("Expected socket file to be removed on shutdown, stat error: %v", err)
+	}
+}
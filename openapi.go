@@ -36,7 +36,9 @@ type OpenAPIPath struct {
 type OpenAPIOperation struct {
 	Summary     string                     `json:"summary,omitempty"`
 	Description string                     `json:"description,omitempty"`
+	OperationID string                     `json:"operationId,omitempty"` // Unique name of the operation for code generators
 	Parameters  []OpenAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *OpenAPIRequestBody        `json:"requestBody,omitempty"`
 	Responses   map[string]OpenAPIResponse `json:"responses"`
 	Tags        []string                   `json:"tags,omitempty"`
 	Security    []map[string][]string      `json:"security,omitempty"`
@@ -52,20 +54,37 @@ type OpenAPIParameter struct {
 	Example     interface{}    `json:"example,omitempty"`
 }
 
+// OpenAPIRequestBody represents the request body accepted by an operation
+type OpenAPIRequestBody struct {
+	Description string                      `json:"description,omitempty"`
+	Required    bool                        `json:"required,omitempty"`
+	Content     map[string]OpenAPIMediaType `json:"content"`
+}
+
 // OpenAPIResponse represents a response from an API operation
 type OpenAPIResponse struct {
 	Description string                      `json:"description"`
 	Content     map[string]OpenAPIMediaType `json:"content,omitempty"`
 }
 
-// OpenAPIMediaType represents a media type (e.g., application/json)
+// OpenAPIMediaType represents a media type (e.g., application/json).
+// Example and Examples are mutually exclusive.
 type OpenAPIMediaType struct {
-	Schema  *OpenAPISchema `json:"schema,omitempty"`
-	Example interface{}    `json:"example,omitempty"`
+	Schema   *OpenAPISchema            `json:"schema,omitempty"`
+	Example  interface{}               `json:"example,omitempty"`
+	Examples map[string]OpenAPIExample `json:"examples,omitempty"`
+}
+
+// OpenAPIExample represents a named example of a media type
+type OpenAPIExample struct {
+	Summary     string      `json:"summary,omitempty"`
+	Description string      `json:"description,omitempty"`
+	Value       interface{} `json:"value"`
 }
 
 // OpenAPISchema represents a data schema
 type OpenAPISchema struct {
+	Ref         string                    `json:"$ref,omitempty"`
 	Type        string                    `json:"type,omitempty"`
 	Format      string                    `json:"format,omitempty"`
 	Items       *OpenAPISchema            `json:"items,omitempty"`
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// defaultExampleName names the static example of a media type once scenario
+// examples are added next to it
+const defaultExampleName = "default"
+
+// withScenarioExamples replaces the single example of media with named
+// examples, so Swagger UI offers them in a dropdown: the static example as
+// "default" plus one per loaded scenario that requests may use, built by
+// example. Without loaded scenarios media is returned unchanged.
+func withScenarioExamples(media OpenAPIMediaType, example func(scenarioType string) interface{}) OpenAPIMediaType {
+	if scenarioManager == nil {
+		return media
+	}
+	scenarioTypes := scenarioManager.ListScenarios()
+	if len(scenarioTypes) == 0 {
+		return media
+	}
+	sort.Strings(scenarioTypes)
+
+	examples := map[string]OpenAPIExample{
+		defaultExampleName: {Summary: "Without a scenario", Value: media.Example},
+	}
+	for _, scenarioType := range scenarioTypes {
+		scenario := scenarioManager.GetScenario(scenarioType)
+		examples[scenarioType] = OpenAPIExample{
+			Summary:     fmt.Sprintf("%s (scenario=%s)", scenario.ScenarioName, scenarioType),
+			Description: scenario.Description,
+			Value:       example(scenarioType),
+		}
+	}
+	media.Example = nil
+	media.Examples = examples
+	return media
+}
+
+// streamScenarioExample returns the first items a stream using the scenario
+// sends
+func streamScenarioExample(scenarioType string) interface{} {
+	_, _, serviceNowMode, _, _ := scenarioManager.GetScenarioConfig(scenarioType)
+	opts := itemOptions{IDStart: 1, ServiceNow: serviceNowMode, ValueFormat: streamItemValueFormat}
+	return generateItems(opts, 0, 2)
+}
+
+// paginatedScenarioExample returns the start of the first page of a
+// paginated request using the scenario, with the scenario's page size and
+// total count
+func paginatedScenarioExample(scenarioType string) interface{} {
+	_, pageSize, serviceNowMode, _, defaultCount := scenarioManager.GetScenarioConfig(scenarioType)
+	items := generateItems(itemOptions{IDStart: 1, ServiceNow: serviceNowMode}, 0, 2)
+	result := make([]PaginatedItem, len(items))
+	for i, item := range items {
+		result[i] = PaginatedItem(item)
+	}
+	metadata := PaginationMetadata{
+		TotalCount: defaultCount,
+		Limit:      pageSize,
+		Offset:     0,
+		HasMore:    defaultCount > pageSize,
+	}
+	if metadata.HasMore {
+		metadata.NextOffset = &pageSize
+	}
+	return PaginatedResponse{Result: result, Metadata: metadata}
+}
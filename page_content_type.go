@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"mime"
+)
+
+// alternateContentTypeKey is the simulation_config key for a Content-Type
+// that /paginated_payload sends on every other page, like misbehaving
+// backends that switch content types mid-pagination
+const alternateContentTypeKey = "alternate_content_type"
+
+// parseAlternateContentType validates an alternate_content_type value, which
+// must be a media type such as "text/plain" or "text/html; charset=utf-8"
+func parseAlternateContentType(value interface{}) (string, error) {
+	contentType, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("%s must be a media type string", alternateContentTypeKey)
+	}
+	if _, _, err := mime.ParseMediaType(contentType); err != nil {
+		return "", fmt.Errorf("%s must be a valid media type: %v", alternateContentTypeKey, err)
+	}
+	return contentType, nil
+}
+
+// GetAlternateContentType returns the scenario's alternate_content_type, or
+// "" if it has none
+func (sm *ScenarioManager) GetAlternateContentType(scenarioType string) string {
+	scenario := sm.GetScenario(scenarioType)
+	if scenario == nil || scenario.ScenarioParams == nil {
+		return ""
+	}
+	value, ok := scenario.ScenarioParams.SimulationConfig[alternateContentTypeKey]
+	if !ok {
+		return ""
+	}
+	contentType, err := parseAlternateContentType(value)
+	if err != nil {
+		return ""
+	}
+	return contentType
+}
+
+// pageContentType returns the Content-Type of the page starting at
+// startIndex: application/json on even pages (the first page is page 0) and
+// alternate, if set, on odd pages. The body is JSON either way.
+func pageContentType(alternate string, startIndex, pageSize int) string {
+	if alternate == "" || pageSize <= 0 || (startIndex/pageSize)%2 == 0 {
+		return "application/json"
+	}
+	return alternate
+}
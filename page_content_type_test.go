@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAlternateContentType(t *testing.T) {
+	originalAuth := *enableAuth
+	*enableAuth = false
+	defer func() { *enableAuth = originalAuth }()
+
+	originalManager := scenarioManager
+	defer func() { scenarioManager = originalManager }()
+
+	scenarioManager = &ScenarioManager{
+		scenarios: map[string]*Scenario{
+			"custom": {
+				ScenarioType: "custom",
+				BaseDelay:    "0ms",
+				ScenarioParams: &ScenarioParameters{
+					SimulationConfig: map[string]interface{}{alternateContentTypeKey: "text/plain"},
+				},
+			},
+		},
+		validator: NewScenarioValidator(),
+	}
+
+	expected := []string{"application/json", "text/plain", "application/json"}
+	for page, want := range expected {
+		w := httptest.NewRecorder()
+		url := fmt.Sprintf("/paginated_payload?scenario=custom&total=100&limit=10&offset=%d", page*10)
+		PaginatedPayloadHandler(w, httptest.NewRequest("GET", url, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("page %d: expected status 200, got %d", page, w.Code)
+		}
+		if got := w.Header().Get("Content-Type"); got != want {
+			t.Errorf("page %d: expected Content-Type %q, got %q", page, want, got)
+		}
+
+		// Only the label changes, the body is JSON on every page
+		var response PaginatedResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil || len(response.Result) != 10 {
+			t.Errorf("page %d: expected 10 JSON items, got %d (%v)", page, len(response.Result), err)
+		}
+	}
+
+	// page/size pagination counts pages the same way
+	w := httptest.NewRecorder()
+	PaginatedPayloadHandler(w, httptest.NewRequest("GET", "/paginated_payload?scenario=custom&page=2&size=10", nil))
+	if got := w.Header().Get("Content-Type"); got != "text/plain" {
+		t.Errorf("page=2: expected Content-Type text/plain, got %q", got)
+	}
+}
+
+func TestAlternateContentTypeValidation(t *testing.T) {
+	validator := NewScenarioValidator()
+
+	for _, value := range []interface{}{"", "not a type", 42.0} {
+		scenario := &Scenario{
+			ScenarioName: "Content Type Test",
+			ScenarioType: "custom",
+			BaseDelay:    "10ms",
+			ScenarioParams: &ScenarioParameters{
+				SimulationConfig: map[string]interface{}{alternateContentTypeKey: value},
+			},
+		}
+		if err := validator.ValidateScenario(scenario); err == nil {
+			t.Errorf("Expected validation error for alternate_content_type=%v", value)
+		}
+	}
+}
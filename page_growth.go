@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// pageGrowthKey is the simulation_config key for how fast paginated items
+// grow with their page position, modelling denormalized joins that make
+// later records heavier
+const pageGrowthKey = "page_growth"
+
+// Limits for page_growth
+const (
+	maxPageGrowth        = 1000
+	maxPageGrowthPadding = 64 << 10
+)
+
+// parsePageGrowth validates a page_growth value, which must be a positive
+// number of at most maxPageGrowth
+func parsePageGrowth(value interface{}) (float64, error) {
+	growth, ok := value.(float64)
+	if !ok || growth <= 0 || growth > maxPageGrowth {
+		return 0, fmt.Errorf("%s must be a positive number of at most %d", pageGrowthKey, maxPageGrowth)
+	}
+	return growth, nil
+}
+
+// GetPageGrowth returns the scenario's page_growth, or 0 if it has none
+func (sm *ScenarioManager) GetPageGrowth(scenarioType string) float64 {
+	scenario := sm.GetScenario(scenarioType)
+	if scenario == nil || scenario.ScenarioParams == nil {
+		return 0
+	}
+	value, ok := scenario.ScenarioParams.SimulationConfig[pageGrowthKey]
+	if !ok {
+		return 0
+	}
+	growth, err := parsePageGrowth(value)
+	if err != nil {
+		return 0
+	}
+	return growth
+}
+
+// pagePadding returns the padding appended to the value of every item on a
+// page starting at startIndex: growth bytes for each item before the page,
+// capped at maxPageGrowthPadding. The first page is never padded.
+func pagePadding(growth float64, startIndex int) string {
+	n := min(int(growth*float64(startIndex)), maxPageGrowthPadding)
+	if n <= 0 {
+		return ""
+	}
+	return " " + strings.Repeat("x", n)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPageGrowth(t *testing.T) {
+	originalAuth := *enableAuth
+	*enableAuth = false
+	defer func() { *enableAuth = originalAuth }()
+
+	originalManager := scenarioManager
+	defer func() { scenarioManager = originalManager }()
+
+	scenarioManager = &ScenarioManager{
+		scenarios: map[string]*Scenario{
+			"custom": {
+				ScenarioType: "custom",
+				BaseDelay:    "0ms",
+				ScenarioParams: &ScenarioParameters{
+					SimulationConfig: map[string]interface{}{pageGrowthKey: 2.0},
+				},
+			},
+		},
+		validator: NewScenarioValidator(),
+	}
+
+	pageBytes := func(offset int) int {
+		w := httptest.NewRecorder()
+		url := fmt.Sprintf("/paginated_payload?scenario=custom&total=1000&limit=10&offset=%d&fixed_timestamp=2024-01-15T10:00:00Z", offset)
+		PaginatedPayloadHandler(w, httptest.NewRequest("GET", url, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("offset=%d: expected status 200, got %d", offset, w.Code)
+		}
+		return w.Body.Len()
+	}
+
+	first, later := pageBytes(0), pageBytes(500)
+	// 10 items padded with 2 bytes for each of the 500 preceding items
+	if later < first+10*1000 {
+		t.Errorf("Expected the page at offset 500 to be at least 10000 bytes larger than the first (%d bytes), got %d bytes", first, later)
+	}
+
+	// Without page_growth the page size does not depend on the offset
+	if padding := pagePadding(0, 500); padding != "" {
+		t.Errorf("Expected no padding without page_growth, got %d bytes", len(padding))
+	}
+	if padding := pagePadding(maxPageGrowth, 1000000); len(padding) != maxPageGrowthPadding+1 {
+		t.Errorf("Expected padding capped at %d bytes, got %d", maxPageGrowthPadding, len(padding)-1)
+	}
+}
+
+func TestPageGrowthValidation(t *testing.T) {
+	validator := NewScenarioValidator()
+
+	for _, value := range []interface{}{0.0, -1.0, 1001.0, "2"} {
+		scenario := &Scenario{
+			ScenarioName: "Growth Test",
+			ScenarioType: "custom",
+			BaseDelay:    "10ms",
+			ScenarioParams: &ScenarioParameters{
+				SimulationConfig: map[string]interface{}{pageGrowthKey: value},
+			},
+		}
+		if err := validator.ValidateScenario(scenario); err == nil {
+			t.Errorf("Expected validation error for page_growth=%v", value)
+		}
+	}
+}
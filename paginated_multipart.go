@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+)
+
+// Response formats supported by the paginated endpoint's format parameter
+const (
+	FormatJSON      = "json"      // A single JSON document (default)
+	FormatMultipart = "multipart" // multipart/mixed with one JSON part per item
+)
+
+// getResponseFormat parses the format query parameter. Without it the
+// format is negotiated from the Accept header.
+func getResponseFormat(r *http.Request) (string, error) {
+	format := strings.ToLower(r.URL.Query().Get("format"))
+	switch format {
+	case "":
+		return negotiateFormat(r.Header.Get("Accept"))
+	case FormatJSON, FormatMultipart:
+		return format, nil
+	default:
+		return "", fmt.Errorf("format must be one of: %s, %s", FormatJSON, FormatMultipart)
+	}
+}
+
+// writeMultipartResponse sends a page as a multipart/mixed batch. Every item
+// becomes its own application/json part identified by Content-ID "item-<id>".
+// The pagination metadata follows as a final part with Content-ID "metadata"
+// so batch clients can still find the next page.
+func writeMultipartResponse(w http.ResponseWriter, response PaginatedResponse, opts TimestampOptions, status int) error {
+	mw := multipart.NewWriter(w)
+	w.Header().Set("Content-Type", "multipart/mixed; boundary="+mw.Boundary())
+	w.WriteHeader(status)
+
+	for _, item := range response.Result {
+		data, err := marshalStreamItem(StreamItem(item), opts)
+		if err != nil {
+			return err
+		}
+		if err := writeJSONPart(mw, "item-"+strconv.Itoa(item.ID), data); err != nil {
+			return err
+		}
+	}
+
+	metadata, err := json.Marshal(response.Metadata)
+	if err != nil {
+		return err
+	}
+	if err := writeJSONPart(mw, "metadata", metadata); err != nil {
+		return err
+	}
+
+	return mw.Close()
+}
+
+// writeJSONPart writes a single application/json part
+func writeJSONPart(mw *multipart.Writer, contentID string, data []byte) error {
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", "application/json")
+	header.Set("Content-ID", contentID)
+
+	part, err := mw.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	_, err = part.Write(data)
+	return err
+}
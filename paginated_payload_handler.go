@@ -1,13 +1,21 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"net/http"
-	"strings"
 	"time"
 )
 
+// maxPageSize bounds limit, size and the -paginated-default-limit flag
+const maxPageSize = 1000
+
+// paramPaginatedDefaultLimit is the page size when limit or size is omitted
+var paramPaginatedDefaultLimit = flag.Int("paginated-default-limit", 100, "Items per /paginated_payload page when limit or size is omitted and no scenario sets it")
+
 // PaginatedItem represents a single object in a paginated response
 type PaginatedItem struct {
 	ID        int       `json:"id"`
@@ -29,6 +37,7 @@ type PaginationMetadata struct {
 	NextOffset *int    `json:"next_offset,omitempty"` // For limit/offset pagination
 	NextPage   *int    `json:"next_page,omitempty"`   // For page/size pagination
 	NextCursor *string `json:"next_cursor,omitempty"` // For cursor-based pagination
+	Nonce      int64   `json:"nonce,omitempty"`       // With nonce=true, unique per response
 }
 
 // PaginatedResponse represents the complete paginated API response
@@ -37,18 +46,41 @@ type PaginatedResponse struct {
 	Metadata PaginationMetadata `json:"metadata"`
 }
 
-// PaginatedPayloadHandler handles paginated REST API responses
+// PaginatedPayloadHandler handles paginated REST API responses. With -record
+// or -replay the responses of a real upstream are proxied or replayed instead.
 //
 // Query Parameters:
 //   - total: Total number of items available (default: 10000, scenario-configurable)
-//   - limit: Number of items per page for limit/offset pagination (default: 100, scenario-configurable)
+//   - limit: Number of items per page for limit/offset pagination (default: -paginated-default-limit, 100, scenario-configurable)
 //   - offset: Starting position for limit/offset pagination (default: 0)
 //   - page: Page number for page/size pagination (default: 1)
-//   - size: Items per page for page/size pagination (default: 100, scenario-configurable)
-//   - cursor: Cursor token for cursor-based pagination
+//   - size: Items per page for page/size pagination (default: -paginated-default-limit, 100, scenario-configurable)
+//   - cursor: Cursor token for cursor-based pagination, encrypted with -cursor-key (see createCursor)
 //   - servicenow: Generate ServiceNow-style fields (default: false, scenario-configurable)
 //   - delay: Delay before response (e.g., "100ms", "1s")
-//   - scenario: ServiceNow scenarios ("peak_hours", "maintenance", "network_issues", "database_load")
+//   - scenario: ServiceNow scenarios ("peak_hours", "maintenance", "network_issues", "database_load", "recovery", "error_storm", "pagination_drift", "pagination_shrink", "slow_connect", "circuit_breaker", "token_expiry"), "<scenario>,<scenario>,..." to layer several, or "random:<scenario>=<weight>,..." to pick one per request
+//   - timestamp_field: JSON key for the item timestamp (default: "timestamp")
+//   - timestamp_format: Timestamp format ("rfc3339" or "epoch" milliseconds, default: "rfc3339")
+//   - shuffle_fields: Emit item keys in a random order per item (default: false)
+//   - string_ids: Emit the item id as a JSON string (default: false)
+//   - null_rate: Probability (0.0-1.0) of each item field except id being null (default: 0)
+//   - size_variation: Pad item values to a random length within this percentage around value_size (default: 0, off)
+//   - value_size: Base value length in bytes for size_variation (default: 256)
+//   - seed: Derives values, sys_ids and size_variation lengths from the seed, item ID and field name, reproducibly (default: none, or a random seed reported in X-PayloadBuddy-Seed if the scenario sets random_seed)
+//   - cardinality: Cycle item values and ServiceNow states through this many distinct values (default: one per item)
+//   - ttfb: Additional time to first byte, independent of delay (e.g., "2s", default: the scenario's setup_delay)
+//   - header_delay: Hold back the response headers by this duration (e.g., "5s"), see headerDelayMiddleware
+//   - format: Response format ("json" or "multipart" for multipart/mixed with one part per item). Without it the format is negotiated from the Accept header q-values, defaulting to "json"
+//   - id_start: ID of the first item in the data set (default: 1)
+//   - value_template: Go template for item values (e.g., "User-{{.ID}}")
+//   - fixed_timestamp: RFC 3339 time used for all item timestamps; enables Last-Modified and If-Modified-Since
+//   - pretty: Indent the JSON response for readability (default: false, compact; ignored for multipart)
+//   - json_style: Whitespace between tokens ("compact", "spaced" or "pretty", overrides pretty; ignored for multipart)
+//   - partial_status: Answer pages with has_more=true with 206 Partial Content and a Content-Range header (default: false)
+//   - force_has_more: Report has_more as "true" or "false" regardless of the page position, to test clients when the flag lies
+//   - nonce: Add an auto-incrementing nonce to the metadata and the X-PayloadBuddy-Nonce header, with Vary: * (default: false)
+//   - no_cache: Send Cache-Control: no-store instead of no-cache (default: false)
+//   - big_header: Add an X-PayloadBuddy-Big-Header response header of this many bytes (default: none, max: 1 MiB)
 //
 // Pagination Types:
 //   - Limit/Offset: Use 'limit' and 'offset' parameters
@@ -62,54 +94,143 @@ type PaginatedResponse struct {
 //   - /paginated_payload?scenario=peak_hours&servicenow=true
 //   - /paginated_payload?scenario=database_load&limit=25
 func PaginatedPayloadHandler(w http.ResponseWriter, r *http.Request) {
+	// Recorded upstream responses replace the generated data
+	if paginatedRecording != nil {
+		paginatedRecording.ServeHTTP(w, r)
+		return
+	}
+
 	// Parse scenario parameter
-	scenario := strings.ToLower(r.URL.Query().Get("scenario"))
+	scenario, err := getScenarioParam(r)
+	if err != nil {
+		writeScenarioParamError(w, r, err)
+		return
+	}
+	applyScenarioHeaders(w, scenario)
+	if err := applyBigHeader(w, r); err != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, errCodeBadRequest, err.Error())
+		return
+	}
 
 	// Get scenario-based defaults if scenario manager is available and scenario is specified
-	var defaultCount, maxCount, defaultBatchSize int
+	var defaultCount, maxCount, defaultPageSize int
 	var defaultServiceNowMode bool
 	if scenarioManager != nil && scenario != "" {
-		defaultBatchSize, defaultServiceNowMode, maxCount, defaultCount = scenarioManager.GetScenarioConfig(scenario)
+		_, defaultPageSize, defaultServiceNowMode, maxCount, defaultCount = scenarioManager.GetScenarioConfig(scenario)
 	} else {
 		// Use hardcoded defaults for backward compatibility
 		defaultCount = 10000
 		maxCount = 1000000
-		defaultBatchSize = 100
+		defaultPageSize = *paramPaginatedDefaultLimit
 		defaultServiceNowMode = false
 	}
 
 	// Parse parameters with scenario-aware defaults
 	totalCount := getIntParam(r, "total", defaultCount)
-	limit := getIntParam(r, "limit", defaultBatchSize)
+	limit := getIntParam(r, "limit", defaultPageSize)
 	offset := getIntParam(r, "offset", 0)
 	page := getIntParam(r, "page", 1)
-	size := getIntParam(r, "size", defaultBatchSize)
+	size := getIntParam(r, "size", defaultPageSize)
 	cursor := r.URL.Query().Get("cursor")
 
-	// ServiceNow mode: use scenario default unless explicitly overridden
-	serviceNowMode := defaultServiceNowMode
-	if serviceNowParam := r.URL.Query().Get("servicenow"); serviceNowParam != "" {
-		serviceNowMode = serviceNowParam == "true"
-	}
+	// Answer non-final pages with 206 Partial Content if requested
+	partialStatus := r.URL.Query().Get("partial_status") == "true"
 
 	delay := getDurationParam(r, "delay", 0)
 
 	// Validate parameters
 	if totalCount <= 0 || totalCount > maxCount {
-		http.Error(w, fmt.Sprintf("Total count must be between 1 and %d", maxCount), http.StatusBadRequest)
+		writeErrorResponse(w, r, http.StatusBadRequest, errCodeBadRequest, fmt.Sprintf("Total count must be between 1 and %d", maxCount))
 		return
 	}
+	timestampOpts, err := getTimestampOptions(r)
+	if err != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, errCodeBadRequest, err.Error())
+		return
+	}
+	style, err := getJSONStyle(r)
+	if err != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, errCodeBadRequest, err.Error())
+		return
+	}
+	if scenarioManager != nil && scenario != "" {
+		timestampOpts.Template = scenarioManager.GetItemTemplate(scenario)
+	}
+	w.Header().Add("Vary", "Accept")
+	format, err := getResponseFormat(r)
+	if errors.Is(err, errNotAcceptable) {
+		writeErrorResponse(w, r, http.StatusNotAcceptable, errCodeNotAcceptable, err.Error())
+		return
+	}
+	if err != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, errCodeBadRequest, err.Error())
+		return
+	}
+	itemOpts, err := parseItemOptions(r, 1, defaultServiceNowMode)
+	if err != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, errCodeBadRequest, err.Error())
+		return
+	}
+	applyRandomSeed(w, scenario, &itemOpts)
+	serviceNowMode := itemOpts.ServiceNow
+	fixedTimestamp := itemOpts.FixedTimestamp
+	forcedHasMore, forceHasMore, err := getForceHasMore(r)
+	if err != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, errCodeBadRequest, err.Error())
+		return
+	}
+
+	// Enforce the scenario's concurrent request limit
+	release, ok := acquireScenarioSlot(w, scenario, serviceNowMode)
+	if !ok {
+		return
+	}
+	defer release()
+
+	// Fail the request if the scenario injects an error
+	if injectScenarioError(w, r, scenario, serviceNowMode) {
+		return
+	}
+
+	// Grow the data set between requests if the scenario drifts. New items
+	// appear at the head, so drifting data sets are listed newest first and
+	// existing items move to higher positions.
+	if scenarioManager != nil && scenario != "" {
+		var inserted int
+		inserted, itemOpts.Descending = scenarioManager.NextPaginationDrift(scenario)
+		totalCount += inserted
+
+		// Shrink the data set between requests if the scenario deletes
+		// items. The oldest items go first: the remaining items keep their
+		// IDs but move to lower positions, and late offsets fall beyond the
+		// end.
+		if deleted, shrinking := scenarioManager.NextPaginationShrink(scenario); shrinking {
+			itemOpts.Deleted = min(deleted, totalCount)
+			totalCount -= itemOpts.Deleted
+		}
+	}
+	itemOpts.Total = totalCount
 
 	// Apply scenario-based delay if specified
 	if scenario != "" && scenarioManager != nil {
 		// For pagination, use item index 0 to get base scenario delay
 		scenarioDelay, _ := scenarioManager.GetScenarioDelay(scenario, 0)
-		if scenarioDelay > 0 {
-			time.Sleep(scenarioDelay)
+		if dist := scenarioManager.GetLatencyDistribution(scenario); dist != nil {
+			scenarioDelay = dist.Sample()
+		}
+		if err := sleepContext(r.Context(), scenarioDelay); err != nil {
+			return
 		}
 	} else if delay > 0 {
 		// Apply custom delay if specified (simulates API processing time)
-		time.Sleep(delay)
+		if err := sleepContext(r.Context(), delay); err != nil {
+			return
+		}
+	}
+
+	// Hold back the response for the requested time-to-first-byte
+	if err := waitTTFB(r, scenario); err != nil {
+		return
 	}
 
 	// Determine pagination type and calculate parameters
@@ -119,15 +240,20 @@ func PaginatedPayloadHandler(w http.ResponseWriter, r *http.Request) {
 	if cursor != "" {
 		// Cursor-based pagination
 		paginationType = "cursor"
-		startIndex, pageSize = parseCursor(cursor, limit)
+		var err error
+		startIndex, pageSize, err = parseCursor(cursor, limit)
+		if err != nil {
+			writeErrorResponse(w, r, http.StatusBadRequest, errCodeBadRequest, err.Error())
+			return
+		}
 	} else if r.URL.Query().Has("page") || r.URL.Query().Has("size") {
 		// Page/size pagination
 		paginationType = "page"
 		if page < 1 {
 			page = 1
 		}
-		if size <= 0 || size > 1000 {
-			size = 100
+		if size <= 0 || size > maxPageSize {
+			size = *paramPaginatedDefaultLimit
 		}
 		startIndex = (page - 1) * size
 		pageSize = size
@@ -137,24 +263,92 @@ func PaginatedPayloadHandler(w http.ResponseWriter, r *http.Request) {
 		if offset < 0 {
 			offset = 0
 		}
-		if limit <= 0 || limit > 1000 {
-			limit = 100
+		if limit <= 0 || limit > maxPageSize {
+			limit = *paramPaginatedDefaultLimit
 		}
 		startIndex = offset
 		pageSize = limit
 	}
 
+	effective := map[string]interface{}{
+		"total":      totalCount,
+		"pagination": paginationType,
+		"scenario":   scenario,
+		"servicenow": serviceNowMode,
+		"delay":      delay.String(),
+		"id_start":   itemOpts.IDStart,
+	}
+	if partialStatus {
+		effective["partial_status"] = true
+	}
+	if forceHasMore {
+		effective["force_has_more"] = forcedHasMore
+	}
+	if paginationType == "page" {
+		effective["page"] = page
+		effective["size"] = size
+	} else {
+		effective["limit"] = pageSize
+		effective["offset"] = startIndex
+	}
+	if timestampOpts.ShuffleFields {
+		effective["shuffle_fields"] = true
+	}
+	if timestampOpts.StringIDs {
+		effective["string_ids"] = true
+	}
+	if timestampOpts.NullRate > 0 {
+		effective["null_rate"] = timestampOpts.NullRate
+	}
+	if itemOpts.Size.enabled() {
+		effective["size_variation"] = itemOpts.Size.Variation
+		effective["value_size"] = itemOpts.Size.ValueSize
+	}
+	if itemOpts.Size.Seeded {
+		effective["seed"] = itemOpts.Size.Seed
+	}
+	if itemOpts.Cardinality > 0 {
+		effective["cardinality"] = itemOpts.Cardinality
+	}
+	if itemOpts.ValueTemplate != nil {
+		effective["value_template"] = itemOpts.ValueTemplate.Root.String()
+	}
+	if !fixedTimestamp.IsZero() {
+		effective["fixed_timestamp"] = fixedTimestamp.Format(time.RFC3339)
+	}
+	setEffectiveParams(w, effective)
+
+	// With a fixed timestamp the data never changes, so conditional requests
+	// can be answered with 304 Not Modified. A nonce changes every response.
+	var nonce int64
+	if r.URL.Query().Get("nonce") == "true" {
+		nonce = nextPaginationNonce()
+	} else if !fixedTimestamp.IsZero() && checkNotModified(w, r, fixedTimestamp) {
+		return
+	}
+	setPageCacheHeaders(w, r.URL.Query().Get("no_cache") == "true", nonce)
+
+	// Send the scenario's alternate_content_type on odd pages, if it sets one;
+	// the body stays JSON either way
+	contentType := "application/json"
+	if scenarioManager != nil && scenario != "" {
+		contentType = pageContentType(scenarioManager.GetAlternateContentType(scenario), startIndex, pageSize)
+	}
+
 	// Validate bounds
 	if startIndex >= totalCount {
 		// Return empty page if offset/page is beyond data
+		metadata, err := createPaginationMetadata(paginationType, totalCount, startIndex, pageSize, page, size, limit, offset, false)
+		if err != nil {
+			writeErrorResponse(w, r, http.StatusInternalServerError, errCodeInternal, "Failed to create cursor")
+			return
+		}
 		response := PaginatedResponse{
 			Result:   []PaginatedItem{},
-			Metadata: createPaginationMetadata(paginationType, totalCount, startIndex, pageSize, page, size, limit, offset, false),
-		}
-		w.Header().Set("Content-Type", "application/json")
-		if err := json.NewEncoder(w).Encode(response); err != nil {
-			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			Metadata: metadata,
 		}
+		response.Metadata.Nonce = nonce
+		writePaginatedResponse(w, r, response, timestampOpts, format, contentType, http.StatusOK, style)
 		return
 	}
 
@@ -162,52 +356,150 @@ func PaginatedPayloadHandler(w http.ResponseWriter, r *http.Request) {
 	endIndex := min(startIndex+pageSize, totalCount)
 	actualSize := endIndex - startIndex
 
+	// Pad item values on later pages if the scenario grows them
+	if scenarioManager != nil && scenario != "" {
+		itemOpts.Padding = pagePadding(scenarioManager.GetPageGrowth(scenario), startIndex)
+	}
+
 	// Generate items for this page
 	items := make([]PaginatedItem, actualSize)
-	for i := range actualSize {
-		itemID := startIndex + i + 1 // 1-based IDs
-		var item PaginatedItem
-
-		if serviceNowMode {
-			item = PaginatedItem{
-				ID:        itemID,
-				Value:     fmt.Sprintf("ServiceNow Record %d", itemID),
-				Timestamp: time.Now(),
-				SysID:     generateSysID(),
-				Number:    fmt.Sprintf("INC%07d", itemID),
-				State:     []string{"New", "In Progress", "Resolved", "Closed"}[itemID%4],
-			}
-		} else {
-			item = PaginatedItem{
-				ID:        itemID,
-				Value:     fmt.Sprintf("Item %d", itemID),
-				Timestamp: time.Now(),
-			}
-		}
-		items[i] = item
+	for i, item := range generateItems(itemOpts, startIndex, actualSize) {
+		items[i] = PaginatedItem(item)
 	}
 
 	// Determine if there are more pages
 	hasMore := endIndex < totalCount
 
+	// Drop the items of a page if the scenario returns empty pages. Only
+	// pages before the last one are emptied, so has_more stays true.
+	if hasMore && scenarioManager != nil && scenario != "" && rollProbability(scenarioManager.GetEmptyPageRate(scenario)) {
+		items = []PaginatedItem{}
+	}
+
+	// A forced has_more only changes the metadata (including the next page
+	// pointer), the status code still reflects the real position
+	reportedHasMore := hasMore
+	if forceHasMore {
+		reportedHasMore = forcedHasMore
+	}
+
 	// Create response
+	metadata, err := createPaginationMetadata(paginationType, totalCount, startIndex, pageSize, page, size, limit, offset, reportedHasMore)
+	if err != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, errCodeInternal, "Failed to create cursor")
+		return
+	}
 	response := PaginatedResponse{
 		Result:   items,
-		Metadata: createPaginationMetadata(paginationType, totalCount, startIndex, pageSize, page, size, limit, offset, hasMore),
+		Metadata: metadata,
+	}
+	response.Metadata.Nonce = nonce
+
+	status := http.StatusOK
+	if partialStatus && hasMore {
+		// Items are addressed by their 0-based index, like offset
+		w.Header().Set("Content-Range", fmt.Sprintf("items %d-%d/%d", startIndex, endIndex-1, totalCount))
+		status = http.StatusPartialContent
 	}
 
-	// Set response headers
-	w.Header().Set("Content-Type", "application/json")
+	writePaginatedResponse(w, r, response, timestampOpts, format, contentType, status, style)
+}
+
+// checkNotModified sets the Last-Modified header and answers with 304 Not
+// Modified if the request's If-Modified-Since is not older than lastModified.
+// It returns true if the response has been written.
+func checkNotModified(w http.ResponseWriter, r *http.Request, lastModified time.Time) bool {
+	// HTTP dates have second precision
+	lastModified = lastModified.UTC().Truncate(time.Second)
+	w.Header().Set("Last-Modified", lastModified.Format(http.TimeFormat))
+
+	ims := r.Header.Get("If-Modified-Since")
+	if ims == "" {
+		return false
+	}
+	since, err := http.ParseTime(ims)
+	if err != nil || lastModified.After(since) {
+		return false
+	}
 	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// writePaginatedResponse encodes the page in the requested response format
+// with the given HTTP status and JSON style. JSON pages are labelled with
+// contentType; multipart responses always carry their own.
+func writePaginatedResponse(w http.ResponseWriter, r *http.Request, response PaginatedResponse, opts TimestampOptions, format, contentType string, status int, style JSONStyle) {
+	if format == FormatMultipart {
+		if err := writeMultipartResponse(w, response, opts, status); err != nil {
+			writeErrorResponse(w, r, http.StatusInternalServerError, errCodeInternal, "Failed to encode response")
+		}
+		return
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	if style == JSONStylePretty {
+		encoder.SetIndent("", prettyIndent)
+	}
+	encodable, err := encodablePaginatedResponse(response, opts)
+	if err == nil {
+		err = encoder.Encode(encodable)
+	}
+	if err != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, errCodeInternal, "Failed to encode response")
+		return
+	}
 
-	// Encode and send response
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	data := buf.Bytes()
+	if style == JSONStyleSpaced {
+		data = appendSpacedJSON(nil, data)
 	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	w.Write(data)
 }
 
-// createPaginationMetadata creates appropriate metadata based on pagination type
-func createPaginationMetadata(paginationType string, totalCount, startIndex, pageSize, page, size, limit, offset int, hasMore bool) PaginationMetadata {
+// encodablePaginatedResponse returns the response as-is for the default
+// timestamp options, or an equivalent with each item encoded honoring a
+// custom timestamp field name and format or the scenario's item template
+func encodablePaginatedResponse(response PaginatedResponse, opts TimestampOptions) (interface{}, error) {
+	if opts.IsDefault() {
+		return response, nil
+	}
+
+	result := make([]json.RawMessage, len(response.Result))
+	for i, item := range response.Result {
+		data, err := marshalStreamItem(StreamItem(item), opts)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = data
+	}
+	return struct {
+		Result   []json.RawMessage  `json:"result"`
+		Metadata PaginationMetadata `json:"metadata"`
+	}{result, response.Metadata}, nil
+}
+
+// getForceHasMore parses the force_has_more query parameter. forced is false
+// if the parameter is not set.
+func getForceHasMore(r *http.Request) (value, forced bool, err error) {
+	switch r.URL.Query().Get("force_has_more") {
+	case "":
+		return false, false, nil
+	case "true":
+		return true, true, nil
+	case "false":
+		return false, true, nil
+	default:
+		return false, false, fmt.Errorf("force_has_more must be true or false")
+	}
+}
+
+// createPaginationMetadata creates appropriate metadata based on pagination
+// type. It fails only if the next cursor cannot be created.
+func createPaginationMetadata(paginationType string, totalCount, startIndex, pageSize, page, size, limit, offset int, hasMore bool) (PaginationMetadata, error) {
 	metadata := PaginationMetadata{
 		TotalCount: totalCount,
 		HasMore:    hasMore,
@@ -224,7 +516,10 @@ func createPaginationMetadata(paginationType string, totalCount, startIndex, pag
 	case "cursor":
 		metadata.Limit = pageSize
 		if hasMore {
-			nextCursor := createCursor(startIndex + pageSize)
+			nextCursor, err := createCursor(startIndex+pageSize, pageSize)
+			if err != nil {
+				return PaginationMetadata{}, err
+			}
 			metadata.NextCursor = &nextCursor
 		}
 	default: // offset
@@ -236,61 +531,7 @@ func createPaginationMetadata(paginationType string, totalCount, startIndex, pag
 		}
 	}
 
-	return metadata
-}
-
-// parseCursor decodes a cursor token to extract starting position
-func parseCursor(cursor string, defaultLimit int) (int, int) {
-	// Simple base64 encoded JSON cursor: {"id":100,"limit":50}
-	// For production, use more secure/complex cursor implementation
-	decoded, err := base64Decode(cursor)
-	if err != nil {
-		return 0, defaultLimit
-	}
-
-	var cursorData struct {
-		ID    int `json:"id"`
-		Limit int `json:"limit"`
-	}
-
-	if err := json.Unmarshal([]byte(decoded), &cursorData); err != nil {
-		return 0, defaultLimit
-	}
-
-	limit := cursorData.Limit
-	if limit <= 0 || limit > 1000 {
-		limit = defaultLimit
-	}
-
-	return cursorData.ID, limit
-}
-
-// createCursor creates a cursor token for the given starting position
-func createCursor(startID int) string {
-	cursorData := struct {
-		ID    int `json:"id"`
-		Limit int `json:"limit"`
-	}{
-		ID:    startID,
-		Limit: 100, // Default limit for cursor pagination
-	}
-
-	data, _ := json.Marshal(cursorData)
-	return base64Encode(string(data))
-}
-
-// Simple base64 encoding/decoding helpers
-func base64Encode(data string) string {
-	// Simple implementation - in production, use encoding/base64
-	return fmt.Sprintf("cursor_%d", len(data)) // Simplified for demo
-}
-
-func base64Decode(cursor string) (string, error) {
-	// Simple implementation - in production, use encoding/base64
-	if cursor == "" {
-		return "", fmt.Errorf("empty cursor")
-	}
-	return "{\"id\":0,\"limit\":100}", nil // Simplified for demo
+	return metadata, nil
 }
 
 // Plugin registration
@@ -321,6 +562,7 @@ func (p PaginatedPayloadPlugin) OpenAPISpec() OpenAPIPathSpec {
 func (p PaginatedPayloadPlugin) buildOpenAPIOperation() OpenAPIPath {
 	return OpenAPIPath{
 		Get: &OpenAPIOperation{
+			OperationID: "getPaginatedPayload",
 			Summary:     "Get paginated JSON payload",
 			Description: "Returns paginated JSON data supporting limit/offset, page/size, and cursor-based pagination patterns commonly used with ServiceNow Data Stream actions",
 			Tags:        []string{"pagination", "servicenow"},
@@ -348,7 +590,7 @@ func (p PaginatedPayloadPlugin) buildOpenAPIParameters() []OpenAPIParameter {
 		{
 			Name:        "limit",
 			In:          "query",
-			Description: "Number of items per page for limit/offset pagination (default: 100, max: 1000)",
+			Description: "Number of items per page for limit/offset pagination (default: the server's -paginated-default-limit, 100 unless configured; max: 1000)",
 			Required:    false,
 			Schema: &OpenAPISchema{
 				Type:    "integer",
@@ -382,7 +624,7 @@ func (p PaginatedPayloadPlugin) buildOpenAPIParameters() []OpenAPIParameter {
 		{
 			Name:        "size",
 			In:          "query",
-			Description: "Items per page for page/size pagination (default: 100, max: 1000)",
+			Description: "Items per page for page/size pagination (default: the server's -paginated-default-limit, 100 unless configured; max: 1000)",
 			Required:    false,
 			Schema: &OpenAPISchema{
 				Type:    "integer",
@@ -394,7 +636,7 @@ func (p PaginatedPayloadPlugin) buildOpenAPIParameters() []OpenAPIParameter {
 		{
 			Name:        "cursor",
 			In:          "query",
-			Description: "Cursor token for cursor-based pagination",
+			Description: "Cursor token for cursor-based pagination: base64 JSON such as {\"id\":100,\"limit\":50}, or opaque and encrypted with -cursor-key. Invalid or modified cursors are rejected with 400",
 			Required:    false,
 			Schema: &OpenAPISchema{
 				Type:    "string",
@@ -424,20 +666,288 @@ func (p PaginatedPayloadPlugin) buildOpenAPIParameters() []OpenAPIParameter {
 		{
 			Name:        "scenario",
 			In:          "query",
-			Description: "ServiceNow simulation scenario. All scenarios work with pagination: 'peak_hours' (consistent delays, ideal for both), 'maintenance' (single spike per page), 'network_issues' (random delays per page), 'database_load' (single delay per page)",
+			Description: "ServiceNow simulation scenario. All scenarios work with pagination: 'peak_hours' (consistent delays, ideal for both), 'maintenance' (single spike per page), 'network_issues' (random delays per page), 'database_load' (single delay per page), 'recovery' (post-incident delay per page), 'error_storm' (first requests fail, then recovery), 'pagination_drift' (data set grows between pages, listed newest first), 'pagination_shrink' (oldest records are deleted between pages, so total_count decreases and late pages are empty), 'slow_connect' (long time to first byte per page), 'circuit_breaker' (503 while open, then a half-open probe closes the circuit), 'token_expiry' (401 after 5 requests per Authorization header until it changes). 'peak_hours,error_storm' layers several scenarios (delays from the first, error injection from the first that enables it). 'random:peak_hours=3,network_issues=1' picks a scenario per request by weight (non-deterministic)",
 			Required:    false,
 			Schema: &OpenAPISchema{
 				Type:    "string",
-				Enum:    []any{"peak_hours", "maintenance", "network_issues", "database_load"},
+				Enum:    []any{"peak_hours", "maintenance", "network_issues", "database_load", "recovery", "error_storm", "pagination_drift", "pagination_shrink", "slow_connect", "circuit_breaker", "token_expiry"},
 				Example: "peak_hours",
 			},
 		},
+		{
+			Name:        "X-Scenario",
+			In:          "header",
+			Description: "Alternative to the scenario query parameter for header-only clients. Ignored when the query parameter is set",
+			Required:    false,
+			Schema: &OpenAPISchema{
+				Type:    "string",
+				Example: "peak_hours",
+			},
+		},
+		{
+			Name:        "timestamp_field",
+			In:          "query",
+			Description: "JSON key under which the item timestamp is emitted (default: 'timestamp'), e.g. 'sys_created_on'",
+			Required:    false,
+			Schema: &OpenAPISchema{
+				Type:    "string",
+				Example: "sys_created_on",
+			},
+		},
+		{
+			Name:        "timestamp_format",
+			In:          "query",
+			Description: "Timestamp format: 'rfc3339' = RFC 3339 string (default), 'epoch' = Unix epoch milliseconds",
+			Required:    false,
+			Schema: &OpenAPISchema{
+				Type:    "string",
+				Enum:    []interface{}{"rfc3339", "epoch"},
+				Example: "rfc3339",
+			},
+		},
+		{
+			Name:        "shuffle_fields",
+			In:          "query",
+			Description: "Emit the keys of every item in a random order (default: false). The JSON stays valid, only the key order varies from item to item; exposes clients that depend on a fixed key order. Ignored with a scenario item_template",
+			Required:    false,
+			Schema: &OpenAPISchema{
+				Type:    "boolean",
+				Example: true,
+			},
+		},
+		{
+			Name:        "fixed_timestamp",
+			In:          "query",
+			Description: "RFC 3339 time used for all item timestamps instead of the current time. Makes responses reproducible: a Last-Modified header is sent and requests with an If-Modified-Since not older than it get 304 Not Modified",
+			Required:    false,
+			Schema: &OpenAPISchema{
+				Type:    "string",
+				Format:  "date-time",
+				Example: "2024-01-15T10:00:00Z",
+			},
+		},
+		{
+			Name:        "format",
+			In:          "query",
+			Description: "Response format: 'json' or 'multipart' for a multipart/mixed batch with one application/json part per item followed by a metadata part. Overrides the Accept header; without it the format is negotiated from the Accept q-values (application/json or multipart/mixed), defaulting to 'json'",
+			Required:    false,
+			Schema: &OpenAPISchema{
+				Type:    "string",
+				Enum:    []interface{}{"json", "multipart"},
+				Example: "json",
+			},
+		},
+		{
+			Name:        "id_start",
+			In:          "query",
+			Description: "ID of the first item in the data set (default: 1). Pagination positions (offset, page, cursor) are unaffected",
+			Required:    false,
+			Schema: &OpenAPISchema{
+				Type:    "integer",
+				Minimum: &[]int{0}[0],
+				Maximum: &[]int{maxIDStart}[0],
+				Example: 1,
+			},
+		},
+		{
+			Name:        "value_template",
+			In:          "query",
+			Description: "Go text/template rendering the value of every item, with {{.ID}} (item ID) and {{.Index}} (position in the data set, from 0); also in ServiceNow mode. Templates that do not parse or render yield 400",
+			Required:    false,
+			Schema: &OpenAPISchema{
+				Type:    "string",
+				Example: "User-{{.ID}}",
+			},
+		},
+		{
+			Name:        "size_variation",
+			In:          "query",
+			Description: "Pad every item value to a random length within this percentage around value_size, modelling records of heterogeneous size (default: 0, no padding)",
+			Required:    false,
+			Schema: &OpenAPISchema{
+				Type:    "integer",
+				Minimum: &[]int{0}[0],
+				Maximum: &[]int{100}[0],
+				Example: 50,
+			},
+		},
+		{
+			Name:        "value_size",
+			In:          "query",
+			Description: "Base length of item values in bytes for size_variation (default: 256)",
+			Required:    false,
+			Schema: &OpenAPISchema{
+				Type:    "integer",
+				Minimum: &[]int{1}[0],
+				Maximum: &[]int{maxValueSize}[0],
+				Example: 1024,
+			},
+		},
+		{
+			Name:        "seed",
+			In:          "query",
+			Description: "Seed for reproducible data: the same seed gives every item ID the same value, sys_id and size_variation length on every request. Scenarios with random_seed pick a random seed if none is given and report it in the X-PayloadBuddy-Seed header. null_rate, shuffle_fields, error injection, reset_rate and random delays are not seeded",
+			Required:    false,
+			Schema: &OpenAPISchema{
+				Type:    "integer",
+				Minimum: &[]int{0}[0],
+				Example: 42,
+			},
+		},
+		{
+			Name:        "cardinality",
+			In:          "query",
+			Description: "Number of distinct values: item values and ServiceNow states cycle through this many values, while ids, numbers and sys_ids stay unique (default: one value per item). For testing grouping and deduplication",
+			Required:    false,
+			Schema: &OpenAPISchema{
+				Type:    "integer",
+				Minimum: &[]int{1}[0],
+				Maximum: &[]int{maxCardinality}[0],
+				Example: 5,
+			},
+		},
+		{
+			Name:        "string_ids",
+			In:          "query",
+			Description: "Emit the item id as a JSON string (\"42\") instead of a number, as ServiceNow does for numeric-looking values (default: false)",
+			Required:    false,
+			Schema: &OpenAPISchema{
+				Type:    "boolean",
+				Example: true,
+			},
+		},
+		{
+			Name:        "null_rate",
+			In:          "query",
+			Description: "Probability (0.0-1.0) of each item field except id being JSON null, independently per field and item (default: 0). Exposes clients that crash on unexpected nulls. Ignored with a scenario item_template",
+			Required:    false,
+			Schema: &OpenAPISchema{
+				Type:    "number",
+				Example: 0.1,
+			},
+		},
+		{
+			Name:        "pretty",
+			In:          "query",
+			Description: "Indent the JSON response for reading in a browser (default: false, compact). Ignored for format=multipart",
+			Required:    false,
+			Schema: &OpenAPISchema{
+				Type:    "boolean",
+				Example: false,
+			},
+		},
+		{
+			Name:        "json_style",
+			In:          "query",
+			Description: "Whitespace between JSON tokens: compact (none), spaced (one space after every , and :) or pretty (indented, like pretty=true). Overrides pretty. Ignored for format=multipart",
+			Required:    false,
+			Schema: &OpenAPISchema{
+				Type:    "string",
+				Enum:    []interface{}{"compact", "spaced", "pretty"},
+				Example: "spaced",
+			},
+		},
+		{
+			Name:        "proto",
+			In:          "query",
+			Description: "Answer HTTP/1.0-style with \"1.0\": the response is buffered and sent with Content-Length and Connection: close instead of chunked encoding (default: 1.1). The -http10 flag applies this to every request",
+			Required:    false,
+			Schema: &OpenAPISchema{
+				Type:    "string",
+				Enum:    []any{"1.0", "1.1"},
+				Example: "1.0",
+			},
+		},
+		{
+			Name:        "transfer",
+			In:          "query",
+			Description: "Force the transfer encoding: \"chunked\" sends the body with chunked encoding, \"length\" buffers it and sends Content-Length (default: chunked above a few KB). \"chunked\" cannot be combined with proto=1.0",
+			Required:    false,
+			Schema: &OpenAPISchema{
+				Type:    "string",
+				Enum:    []any{"chunked", "length"},
+				Example: "length",
+			},
+		},
+		{
+			Name:        "big_header",
+			In:          "query",
+			Description: "Add an X-PayloadBuddy-Big-Header response header with a value of this many bytes (repeating a-z0-9), to test header size limits of clients and proxies",
+			Required:    false,
+			Schema: &OpenAPISchema{
+				Type:    "integer",
+				Minimum: &[]int{1}[0],
+				Maximum: &[]int{maxBigHeaderBytes}[0],
+				Example: 16384,
+			},
+		},
+		{
+			Name:        "nonce",
+			In:          "query",
+			Description: "Add an auto-incrementing nonce to the metadata and the X-PayloadBuddy-Nonce header, with Vary: *, so every page is unique and intermediaries cannot serve it from cache (default: false)",
+			Required:    false,
+			Schema: &OpenAPISchema{
+				Type:    "boolean",
+				Example: true,
+			},
+		},
+		{
+			Name:        "no_cache",
+			In:          "query",
+			Description: "Send Cache-Control: no-store instead of no-cache so intermediaries do not store pages at all (default: false)",
+			Required:    false,
+			Schema: &OpenAPISchema{
+				Type:    "boolean",
+				Example: true,
+			},
+		},
+		{
+			Name:        "partial_status",
+			In:          "query",
+			Description: "Return 206 Partial Content with a 'Content-Range: items <first>-<last>/<total>' header while has_more is true; the final page is still 200",
+			Required:    false,
+			Schema: &OpenAPISchema{
+				Type:    "boolean",
+				Example: false,
+			},
+		},
+		{
+			Name:        "force_has_more",
+			In:          "query",
+			Description: "Report has_more (and the next page pointer) as given regardless of the actual position, e.g. false on a non-final page, to test clients that trust the flag over the item count. The status code still follows the real position",
+			Required:    false,
+			Schema: &OpenAPISchema{
+				Type:    "boolean",
+				Example: false,
+			},
+		},
+		{
+			Name:        "ttfb",
+			In:          "query",
+			Description: "Artificial time to first byte before the response is written (e.g., '2s'), independent of delay and scenario delays. Defaults to the scenario's setup_delay (e.g. 'slow_connect')",
+			Required:    false,
+			Schema: &OpenAPISchema{
+				Type:    "string",
+				Example: "2s",
+			},
+		},
+		{
+			Name:        "header_delay",
+			In:          "query",
+			Description: "Hold back the status line and response headers by this duration, on top of ttfb and any other delay, to test response header timeouts (e.g., '5s', max: 5m)",
+			Required:    false,
+			Schema: &OpenAPISchema{
+				Type:    "string",
+				Example: "5s",
+			},
+		},
 	}
 }
 
-// buildOpenAPIResponses creates the response specifications
+// buildOpenAPIResponses creates the response specifications, with an example
+// page per loaded scenario
 func (p PaginatedPayloadPlugin) buildOpenAPIResponses() map[string]OpenAPIResponse {
-	return map[string]OpenAPIResponse{
+	responses := map[string]OpenAPIResponse{
 		"200": {
 			Description: "Successful paginated response",
 			Content: map[string]OpenAPIMediaType{
@@ -532,6 +1042,11 @@ func (p PaginatedPayloadPlugin) buildOpenAPIResponses() map[string]OpenAPIRespon
 										Description: "Next cursor token for cursor-based pagination",
 										Example:     "eyJpZCI6MjAwfQ%3D%3D",
 									},
+									"nonce": {
+										Type:        "integer",
+										Description: "Auto-incrementing nonce, only with nonce=true",
+										Example:     1,
+									},
 								},
 								Required: []string{"total_count", "has_more"},
 							},
@@ -563,8 +1078,20 @@ func (p PaginatedPayloadPlugin) buildOpenAPIResponses() map[string]OpenAPIRespon
 						},
 					},
 				},
+				"multipart/mixed": {
+					Schema: &OpenAPISchema{
+						Type:        "string",
+						Description: "Returned for format=multipart: one application/json part per item (Content-ID 'item-<id>') followed by the pagination metadata (Content-ID 'metadata')",
+					},
+				},
 			},
 		},
+		"206": {
+			Description: "Non-final page when partial_status=true. Same body as 200, with a 'Content-Range: items <first>-<last>/<total>' header",
+		},
+		"304": {
+			Description: "Not modified - fixed_timestamp is set and the If-Modified-Since header is not older than it",
+		},
 		"400": {
 			Description: "Bad request - invalid parameters",
 			Content: map[string]OpenAPIMediaType{
@@ -576,6 +1103,20 @@ func (p PaginatedPayloadPlugin) buildOpenAPIResponses() map[string]OpenAPIRespon
 				},
 			},
 		},
+		"403": {
+			Description: "The scenario is not allowed by -allow-scenarios or -deny-scenarios",
+			Content: map[string]OpenAPIMediaType{
+				"text/plain": {
+					Schema: &OpenAPISchema{
+						Type:    "string",
+						Example: "scenario is not allowed on this server: network_issues",
+					},
+				},
+			},
+		},
+		"406": {
+			Description: "Not acceptable - no format parameter is given and the Accept header excludes both application/json and multipart/mixed",
+		},
 		"500": {
 			Description: "Internal server error",
 			Content: map[string]OpenAPIMediaType{
@@ -587,7 +1128,23 @@ func (p PaginatedPayloadPlugin) buildOpenAPIResponses() map[string]OpenAPIRespon
 				},
 			},
 		},
+		"503": {
+			Description: "The scenario's max_concurrent limit is reached, or its circuit_breaker is open (with Retry-After and X-PayloadBuddy-Circuit-State)",
+			Content: map[string]OpenAPIMediaType{
+				"text/plain": {
+					Schema: &OpenAPISchema{
+						Type:    "string",
+						Example: "Scenario custom is at its concurrent request limit",
+					},
+				},
+			},
+		},
 	}
+
+	// Offer a sample page per loaded scenario
+	content := responses["200"].Content
+	content["application/json"] = withScenarioExamples(content["application/json"], paginatedScenarioExample)
+	return responses
 }
 
 // buildOpenAPISchemas creates the schema specifications
@@ -663,6 +1220,10 @@ func (p PaginatedPayloadPlugin) buildOpenAPISchemas() map[string]*OpenAPISchema
 					Type:        "string",
 					Description: "Next cursor token for cursor-based pagination",
 				},
+				"nonce": {
+					Type:        "integer",
+					Description: "Auto-incrementing nonce, only with nonce=true",
+				},
 			},
 			Required: []string{"total_count", "has_more"},
 		},
@@ -2,8 +2,12 @@ package main
 
 import (
 	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strconv"
 	"testing"
 	"time"
@@ -623,3 +627,359 @@ func TestPaginatedPayloadHandlerScenarios(t *testing.T) {
 		})
 	}
 }
+
+// TestPaginatedPayloadHandlerTimestampOptions tests custom timestamp keys and formats
+func TestPaginatedPayloadHandlerTimestampOptions(t *testing.T) {
+	// Disable auth for tests
+	originalAuth := *enableAuth
+	*enableAuth = false
+	defer func() { *enableAuth = originalAuth }()
+
+	req := httptest.NewRequest("GET", "/paginated_payload?limit=3&servicenow=true&timestamp_field=sys_created_on&timestamp_format=epoch", nil)
+	w := httptest.NewRecorder()
+	PaginatedPayloadHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	var response struct {
+		Result   []map[string]interface{} `json:"result"`
+		Metadata PaginationMetadata       `json:"metadata"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse JSON response: %v", err)
+	}
+
+	if len(response.Result) != 3 {
+		t.Fatalf("Expected 3 items, got %d", len(response.Result))
+	}
+	for _, item := range response.Result {
+		if _, ok := item["sys_created_on"].(float64); !ok {
+			t.Errorf("Expected numeric sys_created_on field, got %T", item["sys_created_on"])
+		}
+		if _, ok := item["timestamp"]; ok {
+			t.Error("Expected default timestamp key to be replaced")
+		}
+		if item["number"] == nil {
+			t.Error("Expected ServiceNow fields to be preserved")
+		}
+	}
+	if !response.Metadata.HasMore {
+		t.Error("Expected metadata to be preserved")
+	}
+}
+
+// TestPaginatedPayloadHandlerEffectiveParams tests that the resolved parameters are reported after clamping
+func TestPaginatedPayloadHandlerEffectiveParams(t *testing.T) {
+	// Disable auth for tests
+	originalAuth := *enableAuth
+	*enableAuth = false
+	defer func() { *enableAuth = originalAuth }()
+
+	req := httptest.NewRequest("GET", "/paginated_payload?limit=5000&offset=-5&servicenow=true", nil)
+	w := httptest.NewRecorder()
+	PaginatedPayloadHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	effective, err := url.ParseQuery(w.Header().Get("X-PayloadBuddy-Effective"))
+	if err != nil {
+		t.Fatalf("Failed to parse effective parameters header: %v", err)
+	}
+
+	expected := map[string]string{
+		"limit":      "100",
+		"offset":     "0",
+		"total":      "10000",
+		"pagination": "offset",
+		"servicenow": "true",
+	}
+	for key, value := range expected {
+		if got := effective.Get(key); got != value {
+			t.Errorf("Expected effective %s=%s, got %q", key, value, got)
+		}
+	}
+
+	var response PaginatedResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse JSON response: %v", err)
+	}
+	if len(response.Result) != 100 {
+		t.Errorf("Expected 100 items for out-of-range limit, got %d", len(response.Result))
+	}
+}
+
+// TestPaginatedPayloadHandlerMultipart tests the multipart/mixed response format
+func TestPaginatedPayloadHandlerMultipart(t *testing.T) {
+	// Disable auth for tests
+	originalAuth := *enableAuth
+	*enableAuth = false
+	defer func() { *enableAuth = originalAuth }()
+
+	req := httptest.NewRequest("GET", "/paginated_payload?limit=5&offset=10&format=multipart", nil)
+	w := httptest.NewRecorder()
+	PaginatedPayloadHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	if err != nil {
+		t.Fatalf("Failed to parse Content-Type: %v", err)
+	}
+	if mediaType != "multipart/mixed" || params["boundary"] == "" {
+		t.Fatalf("Expected multipart/mixed with boundary, got %q", w.Header().Get("Content-Type"))
+	}
+
+	var items []PaginatedItem
+	var metadata *PaginationMetadata
+	reader := multipart.NewReader(w.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Failed to read part: %v", err)
+		}
+		if ct := part.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Expected part Content-Type application/json, got %q", ct)
+		}
+
+		if part.Header.Get("Content-ID") == "metadata" {
+			metadata = &PaginationMetadata{}
+			if err := json.NewDecoder(part).Decode(metadata); err != nil {
+				t.Fatalf("Failed to decode metadata part: %v", err)
+			}
+			continue
+		}
+
+		var item PaginatedItem
+		if err := json.NewDecoder(part).Decode(&item); err != nil {
+			t.Fatalf("Failed to decode item part: %v", err)
+		}
+		items = append(items, item)
+	}
+
+	if len(items) != 5 {
+		t.Fatalf("Expected 5 item parts, got %d", len(items))
+	}
+	for i, item := range items {
+		if item.ID != 11+i {
+			t.Errorf("Expected item ID %d, got %d", 11+i, item.ID)
+		}
+	}
+	if metadata == nil || !metadata.HasMore || metadata.NextOffset == nil || *metadata.NextOffset != 15 {
+		t.Errorf("Expected metadata part with next_offset 15, got %+v", metadata)
+	}
+}
+
+// TestPaginatedPayloadHandlerInvalidFormat tests that unknown response formats are rejected
+func TestPaginatedPayloadHandlerInvalidFormat(t *testing.T) {
+	// Disable auth for tests
+	originalAuth := *enableAuth
+	*enableAuth = false
+	defer func() { *enableAuth = originalAuth }()
+
+	req := httptest.NewRequest("GET", "/paginated_payload?format=xml", nil)
+	w := httptest.NewRecorder()
+	PaginatedPayloadHandler(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
+
+// TestPaginatedPayloadHandlerPartialStatus tests 206 responses for non-final pages
+func TestPaginatedPayloadHandlerPartialStatus(t *testing.T) {
+	// Disable auth for tests
+	originalAuth := *enableAuth
+	*enableAuth = false
+	defer func() { *enableAuth = originalAuth }()
+
+	tests := []struct {
+		name          string
+		query         string
+		expectedCode  int
+		expectedRange string
+		hasMore       bool
+	}{
+		{"non-final page", "?total=25&limit=10&offset=0&partial_status=true", http.StatusPartialContent, "items 0-9/25", true},
+		{"final page", "?total=25&limit=10&offset=20&partial_status=true", http.StatusOK, "", false},
+		{"page/size", "?total=25&page=2&size=10&partial_status=true", http.StatusPartialContent, "items 10-19/25", true},
+		{"disabled by default", "?total=25&limit=10&offset=0", http.StatusOK, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/paginated_payload"+tt.query, nil)
+			w := httptest.NewRecorder()
+			PaginatedPayloadHandler(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("Expected status %d, got %d", tt.expectedCode, w.Code)
+			}
+			if got := w.Header().Get("Content-Range"); got != tt.expectedRange {
+				t.Errorf("Expected Content-Range %q, got %q", tt.expectedRange, got)
+			}
+
+			var response PaginatedResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to parse JSON response: %v", err)
+			}
+			if response.Metadata.HasMore != tt.hasMore {
+				t.Errorf("Expected has_more=%v, got %v", tt.hasMore, response.Metadata.HasMore)
+			}
+		})
+	}
+}
+
+func TestPaginatedPayloadHandlerForceHasMore(t *testing.T) {
+	// Disable auth for tests
+	originalAuth := *enableAuth
+	*enableAuth = false
+	defer func() { *enableAuth = originalAuth }()
+
+	// has_more=false on a non-final page, without a next offset
+	req := httptest.NewRequest("GET", "/paginated_payload?total=25&limit=10&offset=0&force_has_more=false&partial_status=true", nil)
+	w := httptest.NewRecorder()
+	PaginatedPayloadHandler(w, req)
+
+	// The status still reflects the real position
+	if w.Code != http.StatusPartialContent {
+		t.Fatalf("Expected status 206, got %d", w.Code)
+	}
+	var response PaginatedResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse JSON response: %v", err)
+	}
+	if len(response.Result) != 10 {
+		t.Errorf("Expected a full page of 10 items, got %d", len(response.Result))
+	}
+	if response.Metadata.HasMore {
+		t.Error("Expected forced has_more=false")
+	}
+	if response.Metadata.NextOffset != nil {
+		t.Errorf("Expected no next_offset, got %d", *response.Metadata.NextOffset)
+	}
+	if response.Metadata.TotalCount != 25 {
+		t.Errorf("Expected total_count 25, got %d", response.Metadata.TotalCount)
+	}
+
+	// has_more=true on the final page
+	req = httptest.NewRequest("GET", "/paginated_payload?total=25&page=3&size=10&force_has_more=true", nil)
+	w = httptest.NewRecorder()
+	PaginatedPayloadHandler(w, req)
+	response = PaginatedResponse{}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse JSON response: %v", err)
+	}
+	if !response.Metadata.HasMore || response.Metadata.NextPage == nil || *response.Metadata.NextPage != 4 {
+		t.Errorf("Expected forced has_more=true with next_page 4, got %+v", response.Metadata)
+	}
+
+	req = httptest.NewRequest("GET", "/paginated_payload?force_has_more=maybe", nil)
+	w = httptest.NewRecorder()
+	PaginatedPayloadHandler(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid force_has_more, got %d", w.Code)
+	}
+}
+
+func TestPaginatedPayloadHandlerIfModifiedSince(t *testing.T) {
+	// Disable auth for tests
+	originalAuth := *enableAuth
+	*enableAuth = false
+	defer func() { *enableAuth = originalAuth }()
+
+	const url = "/paginated_payload?total=5&limit=5&fixed_timestamp=2024-01-15T10:00:00Z"
+
+	// First fetch returns the page with Last-Modified
+	w := httptest.NewRecorder()
+	PaginatedPayloadHandler(w, httptest.NewRequest("GET", url, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	lastModified := w.Header().Get("Last-Modified")
+	if lastModified != "Mon, 15 Jan 2024 10:00:00 GMT" {
+		t.Fatalf("Expected Last-Modified of the fixed timestamp, got %q", lastModified)
+	}
+	var response PaginatedResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse JSON response: %v", err)
+	}
+	for _, item := range response.Result {
+		if !item.Timestamp.Equal(time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)) {
+			t.Errorf("Item %d: expected fixed timestamp, got %v", item.ID, item.Timestamp)
+		}
+	}
+
+	// Re-fetch with the returned Last-Modified is not modified
+	req := httptest.NewRequest("GET", url, nil)
+	req.Header.Set("If-Modified-Since", lastModified)
+	w = httptest.NewRecorder()
+	PaginatedPayloadHandler(w, req)
+	if w.Code != http.StatusNotModified {
+		t.Errorf("Expected status 304, got %d", w.Code)
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("Expected empty body for 304, got %d bytes", w.Body.Len())
+	}
+
+	// An older If-Modified-Since gets the full page
+	req = httptest.NewRequest("GET", url, nil)
+	req.Header.Set("If-Modified-Since", "Sun, 14 Jan 2024 10:00:00 GMT")
+	w = httptest.NewRecorder()
+	PaginatedPayloadHandler(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200 for older If-Modified-Since, got %d", w.Code)
+	}
+
+	// Without fixed_timestamp there is no Last-Modified
+	w = httptest.NewRecorder()
+	PaginatedPayloadHandler(w, httptest.NewRequest("GET", "/paginated_payload?total=5", nil))
+	if got := w.Header().Get("Last-Modified"); got != "" {
+		t.Errorf("Expected no Last-Modified without fixed_timestamp, got %q", got)
+	}
+
+	// Invalid timestamps are rejected
+	w = httptest.NewRecorder()
+	PaginatedPayloadHandler(w, httptest.NewRequest("GET", "/paginated_payload?fixed_timestamp=yesterday", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for invalid fixed_timestamp, got %d", w.Code)
+	}
+}
+
+func TestPaginatedPayloadHandler_DefaultLimitFlag(t *testing.T) {
+	*enableAuth = false
+	originalLimit := *paramPaginatedDefaultLimit
+	*paramPaginatedDefaultLimit = 7
+	defer func() { *paramPaginatedDefaultLimit = originalLimit }()
+
+	tests := []struct {
+		query    string
+		expected int
+	}{
+		{"total=50", 7},
+		{"total=50&page=1", 7},
+		{"total=50&limit=0", 7},
+		{"total=50&limit=3", 3},
+		{"total=50&size=4", 4},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		PaginatedPayloadHandler(w, httptest.NewRequest("GET", "/paginated_payload?"+tt.query, nil))
+
+		var response PaginatedResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("%s: failed to parse JSON: %v", tt.query, err)
+		}
+		if len(response.Result) != tt.expected {
+			t.Errorf("%s: expected %d items, got %d", tt.query, tt.expected, len(response.Result))
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import "fmt"
+
+// driftPerRequestKey is the simulation_config key for how many new items
+// appear at the head of a paginated data set with every request
+const driftPerRequestKey = "drift_per_request"
+
+// parseDriftPerRequest validates a drift_per_request value, which must be a
+// positive whole number
+func parseDriftPerRequest(value interface{}) (int, error) {
+	n, ok := value.(float64)
+	if !ok || n < 1 || n != float64(int(n)) {
+		return 0, fmt.Errorf("%s must be a positive integer", driftPerRequestKey)
+	}
+	return int(n), nil
+}
+
+// NextPaginationDrift advances the scenario's drift clock by one request and
+// returns how many items have been inserted at the head of the data set
+// before this request. The first request sees no inserted items, every
+// following one drift_per_request more. ok is false if the scenario does not
+// define drift_per_request.
+func (sm *ScenarioManager) NextPaginationDrift(scenarioType string) (inserted int, ok bool) {
+	scenario := sm.GetScenario(scenarioType)
+	if scenario == nil || scenario.ScenarioParams == nil {
+		return 0, false
+	}
+	value, exists := scenario.ScenarioParams.SimulationConfig[driftPerRequestKey]
+	if !exists {
+		return 0, false
+	}
+	drift, err := parseDriftPerRequest(value)
+	if err != nil {
+		return 0, false
+	}
+
+	sm.driftMu.Lock()
+	defer sm.driftMu.Unlock()
+	if sm.driftRequests == nil {
+		sm.driftRequests = make(map[string]int)
+	}
+	inserted = sm.driftRequests[scenarioType] * drift
+	sm.driftRequests[scenarioType]++
+	return inserted, true
+}
+
+// ResetPaginationDrift restarts the drift clock of all scenarios
+func (sm *ScenarioManager) ResetPaginationDrift() {
+	sm.driftMu.Lock()
+	defer sm.driftMu.Unlock()
+	sm.driftRequests = nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPaginationDriftScenario(t *testing.T) {
+	originalAuth := *enableAuth
+	*enableAuth = false
+	defer func() { *enableAuth = originalAuth }()
+
+	originalManager := scenarioManager
+	defer func() { scenarioManager = originalManager }()
+
+	scenarioManager = &ScenarioManager{
+		scenarios: make(map[string]*Scenario),
+		validator: NewScenarioValidator(),
+	}
+	scenarioManager.loadEmbeddedScenarios()
+
+	if scenarioManager.GetScenario("pagination_drift") == nil {
+		t.Fatal("Expected embedded pagination_drift scenario")
+	}
+
+	// Walk the first pages by offset like a naive client
+	const limit = 10
+	seen := make(map[int]int)
+	var totals []int
+	for page := 0; page < 4; page++ {
+		w := httptest.NewRecorder()
+		url := fmt.Sprintf("/paginated_payload?scenario=pagination_drift&total=100&limit=%d&offset=%d", limit, page*limit)
+		PaginatedPayloadHandler(w, httptest.NewRequest("GET", url, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Page %d: expected status 200, got %d", page, w.Code)
+		}
+
+		var response PaginatedResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Page %d: failed to parse JSON response: %v", page, err)
+		}
+		if len(response.Result) != limit {
+			t.Fatalf("Page %d: expected %d items, got %d", page, limit, len(response.Result))
+		}
+		totals = append(totals, response.Metadata.TotalCount)
+		for _, item := range response.Result {
+			seen[item.ID]++
+		}
+	}
+
+	// The embedded scenario inserts 5 items per request
+	for i := 1; i < len(totals); i++ {
+		if totals[i] != totals[i-1]+5 {
+			t.Errorf("Expected total_count to grow by 5 per request, got %v", totals)
+			break
+		}
+	}
+
+	// New items push already-seen items onto later pages
+	duplicates := 0
+	for _, count := range seen {
+		if count > 1 {
+			duplicates++
+		}
+	}
+	if duplicates == 0 {
+		t.Errorf("Expected some ids to repeat across pages, seen %v", seen)
+	}
+
+	// Scenarios without drift_per_request keep a stable data set
+	if _, drifting := scenarioManager.NextPaginationDrift("peak_hours"); drifting {
+		t.Error("Expected peak_hours not to drift")
+	}
+}
+
+func TestDriftPerRequestValidation(t *testing.T) {
+	validator := NewScenarioValidator()
+
+	for _, value := range []interface{}{0.0, -5.0, 2.5, "5"} {
+		scenario := &Scenario{
+			ScenarioName: "Drift Test",
+			ScenarioType: "pagination_drift",
+			BaseDelay:    "10ms",
+			ScenarioParams: &ScenarioParameters{
+				SimulationConfig: map[string]interface{}{driftPerRequestKey: value},
+			},
+		}
+		if err := validator.ValidateScenario(scenario); err == nil {
+			t.Errorf("Expected validation error for drift_per_request=%v", value)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import "fmt"
+
+// shrinkPerRequestKey is the simulation_config key for how many of the
+// oldest items of a paginated data set are deleted with every request
+const shrinkPerRequestKey = "shrink_per_request"
+
+// parseShrinkPerRequest validates a shrink_per_request value, which must be
+// a positive whole number
+func parseShrinkPerRequest(value interface{}) (int, error) {
+	n, ok := value.(float64)
+	if !ok || n < 1 || n != float64(int(n)) {
+		return 0, fmt.Errorf("%s must be a positive integer", shrinkPerRequestKey)
+	}
+	return int(n), nil
+}
+
+// NextPaginationShrink advances the scenario's shrink clock by one request
+// and returns how many items have been deleted from the data set before
+// this request. The first request sees the full data set, every following
+// one shrink_per_request fewer items. ok is false if the scenario does not
+// define shrink_per_request.
+func (sm *ScenarioManager) NextPaginationShrink(scenarioType string) (deleted int, ok bool) {
+	scenario := sm.GetScenario(scenarioType)
+	if scenario == nil || scenario.ScenarioParams == nil {
+		return 0, false
+	}
+	value, exists := scenario.ScenarioParams.SimulationConfig[shrinkPerRequestKey]
+	if !exists {
+		return 0, false
+	}
+	shrink, err := parseShrinkPerRequest(value)
+	if err != nil {
+		return 0, false
+	}
+
+	sm.shrinkMu.Lock()
+	defer sm.shrinkMu.Unlock()
+	if sm.shrinkRequests == nil {
+		sm.shrinkRequests = make(map[string]int)
+	}
+	deleted = sm.shrinkRequests[scenarioType] * shrink
+	sm.shrinkRequests[scenarioType]++
+	return deleted, true
+}
+
+// ResetPaginationShrink restarts the shrink clock of all scenarios
+func (sm *ScenarioManager) ResetPaginationShrink() {
+	sm.shrinkMu.Lock()
+	defer sm.shrinkMu.Unlock()
+	sm.shrinkRequests = nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPaginationShrinkScenario(t *testing.T) {
+	originalAuth := *enableAuth
+	*enableAuth = false
+	defer func() { *enableAuth = originalAuth }()
+
+	originalManager := scenarioManager
+	defer func() { scenarioManager = originalManager }()
+
+	scenarioManager = &ScenarioManager{
+		scenarios: make(map[string]*Scenario),
+		validator: NewScenarioValidator(),
+	}
+	scenarioManager.loadEmbeddedScenarios()
+
+	if scenarioManager.GetScenario("pagination_shrink") == nil {
+		t.Fatal("Expected embedded pagination_shrink scenario")
+	}
+
+	// Walk the pages by offset like a naive client until a page is empty.
+	// The embedded scenario deletes 50 items per request.
+	const limit = 100
+	var totals []int
+	var pages []PaginatedResponse
+	for page := 0; page < 10; page++ {
+		w := httptest.NewRecorder()
+		url := fmt.Sprintf("/paginated_payload?scenario=pagination_shrink&total=1000&limit=%d&offset=%d", limit, page*limit)
+		PaginatedPayloadHandler(w, httptest.NewRequest("GET", url, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Page %d: expected status 200, got %d", page, w.Code)
+		}
+
+		var response PaginatedResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Page %d: failed to parse JSON response: %v", page, err)
+		}
+		totals = append(totals, response.Metadata.TotalCount)
+		pages = append(pages, response)
+		if len(response.Result) == 0 {
+			break
+		}
+	}
+
+	for i := 1; i < len(totals); i++ {
+		if totals[i] != totals[i-1]-50 {
+			t.Errorf("Expected total_count to decrease by 50 per request, got %v", totals)
+			break
+		}
+	}
+
+	// Offset 700 lies beyond the 650 items left at the eighth request
+	if len(pages) != 8 || len(pages[7].Result) != 0 || pages[7].Metadata.HasMore {
+		t.Fatalf("Expected the eighth page to be empty and final, got %d pages with totals %v", len(pages), totals)
+	}
+
+	// The remaining items keep their IDs and numbers but move to lower
+	// offsets, so the client skips the items in between
+	first := pages[1].Result[0]
+	if first.ID != 151 || first.Number != "INC0000151" {
+		t.Errorf("Expected the second page to start at ID 151 (INC0000151), got %d (%s)", first.ID, first.Number)
+	}
+
+	// The clock restarts with /reset
+	scenarioManager.ResetPaginationShrink()
+	if deleted, shrinking := scenarioManager.NextPaginationShrink("pagination_shrink"); !shrinking || deleted != 0 {
+		t.Errorf("Expected a full data set after a reset, got %d deleted", deleted)
+	}
+
+	// Scenarios without shrink_per_request keep a stable data set
+	if _, shrinking := scenarioManager.NextPaginationShrink("peak_hours"); shrinking {
+		t.Error("Expected peak_hours not to shrink")
+	}
+}
+
+func TestShrinkPerRequestValidation(t *testing.T) {
+	validator := NewScenarioValidator()
+
+	for _, value := range []interface{}{0.0, -5.0, 2.5, "5"} {
+		scenario := &Scenario{
+			ScenarioName: "Shrink Test",
+			ScenarioType: "pagination_shrink",
+			BaseDelay:    "10ms",
+			ScenarioParams: &ScenarioParameters{
+				SimulationConfig: map[string]interface{}{shrinkPerRequestKey: value},
+			},
+		}
+		if err := validator.ValidateScenario(scenario); err == nil {
+			t.Errorf("Expected validation error for shrink_per_request=%v", value)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+)
+
+var paramPprof = flag.Bool("pprof", false, "Expose the Go runtime profiler under /debug/pprof/ (requires authentication when -auth is enabled)")
+
+// registerPprof adds the net/http/pprof handlers to mux when -pprof is set.
+// They are not plugins, so they are left out of the OpenAPI spec, the rate
+// limit headers and the request timeout (CPU profiles and traces take as
+// long as the seconds parameter asks for), but basic auth still applies.
+func registerPprof(mux *http.ServeMux) {
+	if !*paramPprof {
+		return
+	}
+	mux.HandleFunc("/debug/pprof/", basicAuthMiddleware(pprof.Index))
+	mux.HandleFunc("/debug/pprof/cmdline", basicAuthMiddleware(pprof.Cmdline))
+	mux.HandleFunc("/debug/pprof/profile", basicAuthMiddleware(pprof.Profile))
+	mux.HandleFunc("/debug/pprof/symbol", basicAuthMiddleware(pprof.Symbol))
+	mux.HandleFunc("/debug/pprof/trace", basicAuthMiddleware(pprof.Trace))
+	fmt.Println("Registered endpoint: /debug/pprof/")
+}
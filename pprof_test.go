@@ -0,0 +1,57 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRegisterPprof(t *testing.T) {
+	originalPprof, originalAuth := *paramPprof, *enableAuth
+	originalUser, originalPass := authUsername, authPassword
+	defer func() {
+		*paramPprof, *enableAuth = originalPprof, originalAuth
+		authUsername, authPassword = originalUser, originalPass
+	}()
+	*enableAuth = false
+
+	get := func(mux *http.ServeMux, path string, withAuth bool) int {
+		req := httptest.NewRequest("GET", path, nil)
+		if withAuth {
+			req.SetBasicAuth("user", "secret")
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Absent by default
+	*paramPprof = false
+	mux := http.NewServeMux()
+	registerPlugins(mux)
+	for _, path := range []string{"/debug/pprof/", "/debug/pprof/cmdline"} {
+		if code := get(mux, path, false); code != http.StatusNotFound {
+			t.Errorf("Expected 404 for %s without -pprof, got %d", path, code)
+		}
+	}
+
+	// Present with the flag
+	*paramPprof = true
+	mux = http.NewServeMux()
+	registerPlugins(mux)
+	for _, path := range []string{"/debug/pprof/", "/debug/pprof/cmdline", "/debug/pprof/goroutine?debug=1"} {
+		if code := get(mux, path, false); code != http.StatusOK {
+			t.Errorf("Expected 200 for %s with -pprof, got %d", path, code)
+		}
+	}
+
+	// Basic auth applies when enabled
+	*enableAuth = true
+	authUsername, authPassword = "user", "secret"
+	if code := get(mux, "/debug/pprof/", false); code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without credentials, got %d", code)
+	}
+	if code := get(mux, "/debug/pprof/", true); code != http.StatusOK {
+		t.Errorf("Expected 200 with credentials, got %d", code)
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"text/template"
+)
+
+// prettyIndent is the indentation used for pretty=true output
+const prettyIndent = "  "
+
+// isPretty checks if the request asks for indented JSON instead of the
+// compact default
+func isPretty(r *http.Request) bool {
+	return r.URL.Query().Get("pretty") == "true"
+}
+
+// indentArrayElement indents a compact JSON value as an element of a
+// top-level array
+func indentArrayElement(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(prettyIndent)
+	if err := json.Indent(&buf, data, prettyIndent, prettyIndent); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeRestItemsStyled writes the same items as writeRestItems in a
+// spaced or pretty JSON style, pretty with one item per line block. It
+// trades the flat allocations of the compact writer for the formatting.
+func writeRestItemsStyled(w io.Writer, count, idStart int, stringIDs bool, valueTmpl *template.Template, style JSONStyle) error {
+	bw := bufio.NewWriterSize(w, 32*1024)
+	buf := make([]byte, 0, 64)
+
+	open, end := "[", "]\n"
+	if style == JSONStylePretty {
+		open, end = "[\n", "\n]\n"
+	}
+	if _, err := bw.WriteString(open); err != nil {
+		return err
+	}
+	for i := 1; i <= count; i++ {
+		if i > 1 {
+			if _, err := bw.WriteString(style.arraySeparator()); err != nil {
+				return err
+			}
+		}
+		item, err := style.format(appendRestItem(buf[:0], idStart+i-1, i-1, stringIDs, valueTmpl))
+		if err != nil {
+			return err
+		}
+		if _, err := bw.Write(item); err != nil {
+			return err
+		}
+	}
+	if _, err := bw.WriteString(end); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
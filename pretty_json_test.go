@@ -0,0 +1,43 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPrettyOutput(t *testing.T) {
+	originalAuth := *enableAuth
+	*enableAuth = false
+	defer func() { *enableAuth = originalAuth }()
+
+	handlers := map[string]http.HandlerFunc{
+		"/rest_payload?count=3":                      RestPayloadHandler,
+		"/stream_payload?count=3&delay=0ms":          StreamingPayloadHandler,
+		"/paginated_payload?total=3&limit=3":         PaginatedPayloadHandler,
+		"/stream_payload?count=3&envelope=object":    StreamingPayloadHandler,
+		"/paginated_payload?total=3&servicenow=true": PaginatedPayloadHandler,
+	}
+
+	for target, handler := range handlers {
+		// Default output is compact
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest("GET", target, nil))
+		if strings.Contains(w.Body.String(), "\n  ") {
+			t.Errorf("%s: expected compact output by default, got:\n%s", target, w.Body.String())
+		}
+
+		// pretty=true indents and is still valid JSON
+		w = httptest.NewRecorder()
+		handler(w, httptest.NewRequest("GET", target+"&pretty=true", nil))
+		body := w.Body.String()
+		if !strings.Contains(body, "\n  ") || !strings.Contains(body, `"id": `) {
+			t.Errorf("%s: expected indented items with pretty=true, got:\n%s", target, body)
+		}
+		if !json.Valid(w.Body.Bytes()) {
+			t.Errorf("%s: pretty output is not valid JSON:\n%s", target, body)
+		}
+	}
+}
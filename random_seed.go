@@ -0,0 +1,62 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// randomSeedKey is the simulation_config key that gives every request using
+// the scenario a fresh random seed, so a failing response can be reproduced
+// by replaying the request with seed=<value>
+const randomSeedKey = "random_seed"
+
+// seedHeader reports the seed generated for a request
+const seedHeader = "X-PayloadBuddy-Seed"
+
+// parseRandomSeed validates a random_seed value, which must be a boolean
+func parseRandomSeed(value interface{}) (bool, error) {
+	enabled, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("%s must be a boolean", randomSeedKey)
+	}
+	return enabled, nil
+}
+
+// GetRandomSeed reports whether the scenario generates a random seed for
+// requests without a seed parameter
+func (sm *ScenarioManager) GetRandomSeed(scenarioType string) bool {
+	scenario := sm.GetScenario(scenarioType)
+	if scenario == nil || scenario.ScenarioParams == nil {
+		return false
+	}
+	value, ok := scenario.ScenarioParams.SimulationConfig[randomSeedKey]
+	if !ok {
+		return false
+	}
+	enabled, err := parseRandomSeed(value)
+	if err != nil {
+		return false
+	}
+	return enabled
+}
+
+// applyRandomSeed seeds the items of a request without a seed parameter if
+// the scenario enables random_seed, and reports the seed in the
+// X-PayloadBuddy-Seed header. Passing it back as seed reproduces the values,
+// sys_ids and sizes of the items. Other random rolls, such as null_rate,
+// shuffle_fields, error injection, reset_rate and random delays, are not
+// derived from the seed and differ on a replay.
+func applyRandomSeed(w http.ResponseWriter, scenario string, opts *itemOptions) {
+	if scenarioManager == nil || scenario == "" || opts.Size.Seeded || !scenarioManager.GetRandomSeed(scenario) {
+		return
+	}
+	var buf [8]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return
+	}
+	opts.Size.Seed, opts.Size.Seeded = binary.LittleEndian.Uint64(buf[:]), true
+	w.Header().Set(seedHeader, strconv.FormatUint(opts.Size.Seed, 10))
+}
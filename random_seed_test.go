@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRandomSeed(t *testing.T) {
+	originalAuth := *enableAuth
+	*enableAuth = false
+	defer func() { *enableAuth = originalAuth }()
+
+	originalManager := scenarioManager
+	defer func() { scenarioManager = originalManager }()
+
+	scenarioManager = &ScenarioManager{
+		scenarios: map[string]*Scenario{
+			"custom": {
+				ScenarioType: "custom",
+				BaseDelay:    "0ms",
+				ScenarioParams: &ScenarioParameters{
+					SimulationConfig: map[string]interface{}{randomSeedKey: true},
+				},
+			},
+		},
+		validator: NewScenarioValidator(),
+	}
+
+	get := func(handler http.HandlerFunc, url string) *httptest.ResponseRecorder {
+		t.Helper()
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest("GET", url, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected status 200, got %d: %s", url, w.Code, w.Body.String())
+		}
+		return w
+	}
+
+	for name, test := range map[string]struct {
+		handler http.HandlerFunc
+		url     string
+	}{
+		"stream":    {StreamingPayloadHandler, "/stream_payload?scenario=custom&count=20&delay=0&servicenow=true&size_variation=50&fixed_timestamp=2024-01-15T10:00:00Z"},
+		"paginated": {PaginatedPayloadHandler, "/paginated_payload?scenario=custom&limit=20&servicenow=true&size_variation=50&fixed_timestamp=2024-01-15T10:00:00Z"},
+	} {
+		first := get(test.handler, test.url)
+		seed := first.Header().Get(seedHeader)
+		if seed == "" {
+			t.Fatalf("%s: expected a generated seed in %s", name, seedHeader)
+		}
+		if again := get(test.handler, test.url); again.Header().Get(seedHeader) == seed || bytes.Equal(again.Body.Bytes(), first.Body.Bytes()) {
+			t.Errorf("%s: expected a fresh seed and response on every request", name)
+		}
+
+		// Replaying the seed reproduces the response without a new seed
+		replay := get(test.handler, test.url+"&seed="+seed)
+		if replay.Header().Get(seedHeader) != "" {
+			t.Errorf("%s: expected no generated seed with seed=%s, got %s", name, seed, replay.Header().Get(seedHeader))
+		}
+		if !bytes.Equal(replay.Body.Bytes(), first.Body.Bytes()) {
+			t.Errorf("%s: expected seed=%s to reproduce the response\nfirst:  %s\nreplay: %s", name, seed, first.Body.String(), replay.Body.String())
+		}
+	}
+
+	// Scenarios without random_seed leave requests unseeded
+	if w := get(StreamingPayloadHandler, "/stream_payload?count=1&delay=0"); w.Header().Get(seedHeader) != "" {
+		t.Errorf("Expected no seed without a scenario, got %s", w.Header().Get(seedHeader))
+	}
+}
+
+func TestRandomSeedValidation(t *testing.T) {
+	validator := NewScenarioValidator()
+
+	for _, value := range []interface{}{"true", 1.0} {
+		scenario := &Scenario{
+			ScenarioName: "Random Seed Test",
+			ScenarioType: "custom",
+			BaseDelay:    "10ms",
+			ScenarioParams: &ScenarioParameters{
+				SimulationConfig: map[string]interface{}{randomSeedKey: value},
+			},
+		}
+		if err := validator.ValidateScenario(scenario); err == nil {
+			t.Errorf("Expected validation error for random_seed=%v", value)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"flag"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Rate limit header configuration. The counters only feed the
+// X-RateLimit-* response headers; requests are never rejected.
+var (
+	paramRateLimit       = flag.Int("rate-limit", 100, "Requests per client IP and window reported in the X-RateLimit-* headers")
+	paramRateLimitWindow = flag.Duration("rate-limit-window", time.Minute, "Window after which the X-RateLimit-Remaining count resets")
+)
+
+// rateLimitCounter counts requests for the X-RateLimit-* headers
+var rateLimitCounter = NewRateLimitCounter(100, time.Minute)
+
+// maxTrackedClients is the number of clients above which expired windows are
+// pruned, so the counter does not grow with every client ever seen
+const maxTrackedClients = 1024
+
+// rateLimitWindow holds one client's request count in the current window
+type rateLimitWindow struct {
+	reset time.Time
+	count int
+}
+
+// RateLimitCounter counts requests per client over fixed windows
+type RateLimitCounter struct {
+	mu      sync.Mutex
+	limit   int
+	window  time.Duration
+	clients map[string]*rateLimitWindow
+	now     func() time.Time
+}
+
+// NewRateLimitCounter creates a counter allowing limit requests per window
+func NewRateLimitCounter(limit int, window time.Duration) *RateLimitCounter {
+	return &RateLimitCounter{
+		limit:   limit,
+		window:  window,
+		clients: make(map[string]*rateLimitWindow),
+		now:     time.Now,
+	}
+}
+
+// Count records a request from the client and returns the requests remaining
+// in the current window (never below zero) and when the window resets
+func (c *RateLimitCounter) Count(client string) (remaining int, reset time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	state, exists := c.clients[client]
+	if !exists || !now.Before(state.reset) {
+		if !exists && len(c.clients) >= maxTrackedClients {
+			c.pruneExpired(now)
+		}
+		state = &rateLimitWindow{reset: now.Add(c.window)}
+		c.clients[client] = state
+	}
+	state.count++
+
+	return max(c.limit-state.count, 0), state.reset
+}
+
+// pruneExpired removes clients whose window has ended. The caller must hold mu.
+func (c *RateLimitCounter) pruneExpired(now time.Time) {
+	for client, state := range c.clients {
+		if !now.Before(state.reset) {
+			delete(c.clients, client)
+		}
+	}
+}
+
+// Reset forgets all counted requests
+func (c *RateLimitCounter) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.clients = make(map[string]*rateLimitWindow)
+}
+
+// rateLimitHeadersMiddleware counts every request per client IP and reports
+// the result in X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset
+// (Unix time in seconds). The limit is informational only and not enforced.
+func rateLimitHeadersMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		counter := rateLimitCounter
+		remaining, reset := counter.Count(clientIP(r))
+
+		w.Header().Set("X-RateLimit-Limit", strconv.Itoa(counter.limit))
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+
+		next(w, r)
+	}
+}
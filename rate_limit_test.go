@@ -0,0 +1,73 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRateLimitHeadersMiddleware(t *testing.T) {
+	originalCounter := rateLimitCounter
+	defer func() { rateLimitCounter = originalCounter }()
+	rateLimitCounter = NewRateLimitCounter(3, time.Minute)
+
+	handler := rateLimitHeadersMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	request := func(remoteAddr string) http.Header {
+		req := httptest.NewRequest("GET", "/rest_payload", nil)
+		req.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		handler(w, req)
+		return w.Header()
+	}
+
+	// Remaining decreases per request and never drops below zero
+	for i, expected := range []string{"2", "1", "0", "0"} {
+		header := request("192.0.2.1:1234")
+		if got := header.Get("X-RateLimit-Remaining"); got != expected {
+			t.Errorf("Request %d: expected X-RateLimit-Remaining %s, got %q", i+1, expected, got)
+		}
+		if got := header.Get("X-RateLimit-Limit"); got != "3" {
+			t.Errorf("Request %d: expected X-RateLimit-Limit 3, got %q", i+1, got)
+		}
+		reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64)
+		if err != nil || reset < time.Now().Unix() {
+			t.Errorf("Request %d: expected future X-RateLimit-Reset, got %q", i+1, header.Get("X-RateLimit-Reset"))
+		}
+	}
+
+	// Other clients are counted separately, regardless of their port
+	if got := request("192.0.2.2:1234").Get("X-RateLimit-Remaining"); got != "2" {
+		t.Errorf("Expected separate count for another client, got %q", got)
+	}
+}
+
+func TestRateLimitCounterWindowReset(t *testing.T) {
+	now := time.Now()
+	counter := NewRateLimitCounter(5, time.Minute)
+	counter.now = func() time.Time { return now }
+
+	counter.Count("client")
+	if remaining, _ := counter.Count("client"); remaining != 3 {
+		t.Fatalf("Expected 3 remaining, got %d", remaining)
+	}
+
+	// A new window starts once the old one has passed
+	now = now.Add(time.Minute)
+	remaining, reset := counter.Count("client")
+	if remaining != 4 {
+		t.Errorf("Expected 4 remaining in new window, got %d", remaining)
+	}
+	if !reset.Equal(now.Add(time.Minute)) {
+		t.Errorf("Expected reset at %v, got %v", now.Add(time.Minute), reset)
+	}
+
+	counter.Reset()
+	if remaining, _ := counter.Count("client"); remaining != 4 {
+		t.Errorf("Expected 4 remaining after Reset, got %d", remaining)
+	}
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+var (
+	paramRecord    = flag.String("record", "", "Proxy /paginated_payload to this upstream URL and store every response in -record-dir for later -replay")
+	paramRecordDir = flag.String("record-dir", "recordings", "Directory -record stores upstream responses in")
+	paramReplay    = flag.String("replay", "", "Serve /paginated_payload from the responses recorded with -record in this directory")
+)
+
+const (
+	// maxRecordedBodyBytes caps the size of a single recorded upstream response
+	maxRecordedBodyBytes = 64 << 20
+
+	// recordUpstreamTimeout bounds a single proxied upstream request
+	recordUpstreamTimeout = 30 * time.Second
+)
+
+// recordedHeaders are the upstream response headers kept in a recording;
+// they carry the format and the pagination state clients rely on
+var recordedHeaders = []string{"Content-Type", "Content-Range", "Link", "Last-Modified"}
+
+// paginatedRecording serves /paginated_payload instead of the generated
+// data when -record or -replay is active (nil otherwise)
+var paginatedRecording http.Handler
+
+// Recording is a single upstream response as stored on disk, one file per
+// distinct query string
+type Recording struct {
+	Query  string            `json:"query"`
+	Status int               `json:"status"`
+	Header map[string]string `json:"header,omitempty"`
+	Body   []byte            `json:"body"`
+}
+
+// recordingFile returns the file name of the recording for query. Query
+// parameters are sorted, so their order in the request does not matter.
+func recordingFile(query url.Values) string {
+	sum := sha256.Sum256([]byte(query.Encode()))
+	return hex.EncodeToString(sum[:8]) + ".json"
+}
+
+// writeRecording sends a recorded response to the client
+func writeRecording(w http.ResponseWriter, rec Recording) {
+	for name, value := range rec.Header {
+		w.Header().Set(name, value)
+	}
+	w.WriteHeader(rec.Status)
+	w.Write(rec.Body)
+}
+
+// Recorder proxies requests to an upstream and stores every response
+type Recorder struct {
+	upstream *url.URL
+	dir      string
+	client   *http.Client
+}
+
+// NewRecorder returns a Recorder for the upstream URL storing its responses
+// in dir, which is created if needed
+func NewRecorder(upstream, dir string) (*Recorder, error) {
+	u, err := url.Parse(upstream)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported URL scheme, expected http or https")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &Recorder{upstream: u, dir: dir, client: http.DefaultClient}, nil
+}
+
+// upstreamURL returns the upstream URL for r. The request's query parameters
+// are added to the upstream's own, replacing parameters of the same name.
+func (rc *Recorder) upstreamURL(r *http.Request) string {
+	u := *rc.upstream
+	query := u.Query()
+	for name, values := range r.URL.Query() {
+		query[name] = values
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// ServeHTTP forwards r to the upstream, stores the response and relays it
+func (rc *Recorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), recordUpstreamTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rc.upstreamURL(r), nil)
+	if err != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, errCodeInternal, err.Error())
+		return
+	}
+	if accept := r.Header.Get("Accept"); accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+
+	resp, err := rc.client.Do(req)
+	if err != nil {
+		writeErrorResponse(w, r, http.StatusBadGateway, errCodeBadGateway, fmt.Sprintf("upstream request failed: %v", err))
+		return
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxRecordedBodyBytes+1))
+	if err != nil {
+		writeErrorResponse(w, r, http.StatusBadGateway, errCodeBadGateway, fmt.Sprintf("reading upstream response failed: %v", err))
+		return
+	}
+	if len(body) > maxRecordedBodyBytes {
+		writeErrorResponse(w, r, http.StatusBadGateway, errCodeBadGateway, fmt.Sprintf("upstream response exceeds %d bytes", maxRecordedBodyBytes))
+		return
+	}
+
+	rec := Recording{Query: r.URL.Query().Encode(), Status: resp.StatusCode, Header: map[string]string{}, Body: body}
+	for _, name := range recordedHeaders {
+		if value := resp.Header.Get(name); value != "" {
+			rec.Header[name] = value
+		}
+	}
+	if err := rc.store(r.URL.Query(), rec); err != nil {
+		// Still relay the response; only the recording is lost
+		log.Printf("Warning: failed to store recording: %v", err)
+	}
+
+	writeRecording(w, rec)
+}
+
+// store writes rec to the recording directory
+func (rc *Recorder) store(query url.Values, rec Recording) error {
+	data, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(rc.dir, recordingFile(query)), data, 0o644)
+}
+
+// Replayer serves responses stored by a Recorder
+type Replayer struct {
+	dir string
+}
+
+// NewReplayer returns a Replayer for the recordings in dir
+func NewReplayer(dir string) (*Replayer, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+	return &Replayer{dir: dir}, nil
+}
+
+// ServeHTTP answers r with the recording of the same query, or 404 when
+// the query was never recorded
+func (rp *Replayer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	data, err := os.ReadFile(filepath.Join(rp.dir, recordingFile(r.URL.Query())))
+	if errors.Is(err, fs.ErrNotExist) {
+		writeErrorResponse(w, r, http.StatusNotFound, errCodeNotFound, fmt.Sprintf("no recording for query %q", r.URL.Query().Encode()))
+		return
+	}
+	if err != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, errCodeInternal, err.Error())
+		return
+	}
+
+	var rec Recording
+	if err := json.Unmarshal(data, &rec); err != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, errCodeInternal, fmt.Sprintf("invalid recording: %v", err))
+		return
+	}
+	writeRecording(w, rec)
+}
+
+// setupRecording activates -record or -replay for /paginated_payload
+func setupRecording() error {
+	switch {
+	case *paramRecord != "" && *paramReplay != "":
+		return fmt.Errorf("-record and -replay cannot be combined")
+	case *paramRecord != "":
+		recorder, err := NewRecorder(*paramRecord, *paramRecordDir)
+		if err != nil {
+			return fmt.Errorf("-record: %v", err)
+		}
+		paginatedRecording = recorder
+		log.Printf("Recording /paginated_payload from %s into %s", recorder.upstream.Redacted(), *paramRecordDir)
+	case *paramReplay != "":
+		replayer, err := NewReplayer(*paramReplay)
+		if err != nil {
+			return fmt.Errorf("-replay: %v", err)
+		}
+		paginatedRecording = replayer
+		log.Printf("Replaying /paginated_payload from %s", *paramReplay)
+	}
+	return nil
+}
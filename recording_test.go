@@ -0,0 +1,88 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+// TestRecordAndReplay records two pages from an upstream and replays them
+// after the upstream is gone.
+func TestRecordAndReplay(t *testing.T) {
+	*enableAuth = false
+	origRecording := paginatedRecording
+	defer func() { paginatedRecording = origRecording }()
+
+	var upstreamQueries []string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamQueries = append(upstreamQueries, r.URL.RawQuery)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Link", `<`+r.URL.Path+`?offset=2>; rel="next"`)
+		w.Header().Set("X-Not-Recorded", "1")
+		w.Write([]byte(`{"page":"` + r.URL.Query().Get("offset") + `","source":"` + r.URL.Query().Get("source") + `"}`))
+	}))
+
+	dir := t.TempDir()
+	recorder, err := NewRecorder(upstream.URL+"/items?source=upstream", dir)
+	if err != nil {
+		t.Fatalf("NewRecorder failed: %v", err)
+	}
+	paginatedRecording = recorder
+
+	for _, query := range []string{"limit=2&offset=0", "limit=2&offset=2"} {
+		w := httptest.NewRecorder()
+		PaginatedPayloadHandler(w, httptest.NewRequest("GET", "/paginated_payload?"+query, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Recording %s: expected status 200, got %d: %s", query, w.Code, w.Body.String())
+		}
+	}
+	upstream.Close()
+
+	if len(upstreamQueries) != 2 || upstreamQueries[0] != "limit=2&offset=0&source=upstream" {
+		t.Errorf("Unexpected upstream queries: %v", upstreamQueries)
+	}
+	if files, _ := os.ReadDir(dir); len(files) != 2 {
+		t.Errorf("Expected 2 recordings, got %d", len(files))
+	}
+
+	replayer, err := NewReplayer(dir)
+	if err != nil {
+		t.Fatalf("NewReplayer failed: %v", err)
+	}
+	paginatedRecording = replayer
+
+	// Parameter order must not matter
+	w := httptest.NewRecorder()
+	PaginatedPayloadHandler(w, httptest.NewRequest("GET", "/paginated_payload?offset=2&limit=2", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Replay: expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := w.Body.String(); got != `{"page":"2","source":"upstream"}` {
+		t.Errorf("Unexpected replayed body: %s", got)
+	}
+	if got := w.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("Expected recorded Content-Type, got %q", got)
+	}
+	if w.Header().Get("Link") == "" {
+		t.Error("Expected the recorded Link header")
+	}
+	if w.Header().Get("X-Not-Recorded") != "" {
+		t.Error("Expected headers outside recordedHeaders to be dropped")
+	}
+
+	w = httptest.NewRecorder()
+	PaginatedPayloadHandler(w, httptest.NewRequest("GET", "/paginated_payload?limit=2&offset=4", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unrecorded query, got %d", w.Code)
+	}
+}
+
+func TestNewRecorderInvalid(t *testing.T) {
+	if _, err := NewRecorder("ftp://example.com/items", t.TempDir()); err == nil {
+		t.Error("Expected an error for a non-HTTP upstream")
+	}
+	if _, err := NewReplayer(t.TempDir() + "/missing"); err == nil {
+		t.Error("Expected an error for a missing replay directory")
+	}
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Limits for the redirect endpoint
+const (
+	defaultRedirectCode = http.StatusFound
+	maxRedirectHops     = 100
+)
+
+// RedirectPlugin implements PayloadPlugin for testing redirect handling
+type RedirectPlugin struct{}
+
+// Path returns the HTTP path for the redirect endpoint
+func (p RedirectPlugin) Path() string {
+	return "/redirect"
+}
+
+// Handler returns the handler function for the redirect endpoint
+func (p RedirectPlugin) Handler() http.HandlerFunc {
+	return RedirectHandler
+}
+
+func init() {
+	registerPlugin(RedirectPlugin{})
+}
+
+// RedirectResult is returned once a redirect chain without a target ends
+type RedirectResult struct {
+	Redirects int `json:"redirects"`
+}
+
+// getRedirectCode parses the code parameter, which must be a redirect
+// status that carries a Location header
+func getRedirectCode(r *http.Request) (int, error) {
+	val := r.URL.Query().Get("code")
+	if val == "" {
+		return defaultRedirectCode, nil
+	}
+	code, err := strconv.Atoi(val)
+	switch {
+	case err != nil:
+	case code == http.StatusMovedPermanently, code == http.StatusFound, code == http.StatusSeeOther,
+		code == http.StatusTemporaryRedirect, code == http.StatusPermanentRedirect:
+		return code, nil
+	}
+	return 0, fmt.Errorf("code must be one of: 301, 302, 303, 307, 308")
+}
+
+// getRedirectTarget parses the to parameter. Relative references are passed
+// through unchanged so clients resolve them; absolute URLs must use http or
+// https.
+func getRedirectTarget(r *http.Request) (string, error) {
+	to := r.URL.Query().Get("to")
+	if to == "" {
+		return "", nil
+	}
+	u, err := url.Parse(to)
+	if err != nil {
+		return "", fmt.Errorf("to must be a valid URL: %v", err)
+	}
+	if u.IsAbs() && u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("to must be a relative reference or an http(s) URL")
+	}
+	return to, nil
+}
+
+// RedirectHandler answers with a redirect to exercise client redirect
+// following and loop protection.
+//
+// Query Parameters:
+//   - to: Final target, a relative reference (e.g. "/rest_payload?count=10") or an absolute http(s) URL
+//   - code: Redirect status: 301, 302, 303, 307 or 308 (default: 302)
+//   - n: Number of redirects in the chain (default: 1, max: 100). Intermediate hops point back at /redirect with n decremented; the last hop points at to
+//   - loop: Redirect to the very same URL forever, for loop detection tests (default: false)
+//   - followed: Redirects followed so far, maintained by the chain itself
+//
+// Without to the chain ends at /redirect itself, which answers 200 with the
+// number of redirects followed.
+func RedirectHandler(w http.ResponseWriter, r *http.Request) {
+	code, err := getRedirectCode(r)
+	if err != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, errCodeBadRequest, err.Error())
+		return
+	}
+	to, err := getRedirectTarget(r)
+	if err != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, errCodeBadRequest, err.Error())
+		return
+	}
+	hops := getIntParam(r, "n", 1)
+	if hops < 0 || hops > maxRedirectHops {
+		writeErrorResponse(w, r, http.StatusBadRequest, errCodeBadRequest, fmt.Sprintf("n must be between 0 and %d", maxRedirectHops))
+		return
+	}
+	followed := getIntParam(r, "followed", 0)
+
+	// Loop mode points every response at the request itself
+	if r.URL.Query().Get("loop") == "true" {
+		redirect(w, r.URL.RequestURI(), code)
+		return
+	}
+
+	if hops == 0 {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(RedirectResult{Redirects: followed}); err != nil {
+			writeErrorResponse(w, r, http.StatusInternalServerError, errCodeInternal, "Failed to encode response")
+		}
+		return
+	}
+
+	// The last hop leaves for the target, if there is one
+	if hops == 1 && to != "" {
+		redirect(w, to, code)
+		return
+	}
+
+	next := r.URL.Query()
+	next.Set("n", strconv.Itoa(hops-1))
+	next.Set("followed", strconv.Itoa(followed+1))
+	redirect(w, r.URL.Path+"?"+next.Encode(), code)
+}
+
+// redirect sends a redirect with location as is. Unlike http.Redirect it
+// keeps relative references unresolved, leaving that to the client.
+func redirect(w http.ResponseWriter, location string, code int) {
+	w.Header().Set("Location", location)
+	w.WriteHeader(code)
+}
+
+// OpenAPISpec returns the OpenAPI specification for the redirect endpoint
+func (p RedirectPlugin) OpenAPISpec() OpenAPIPathSpec {
+	return OpenAPIPathSpec{
+		Path: "/redirect",
+		Operation: OpenAPIPath{
+			Get: &OpenAPIOperation{
+				OperationID: "getRedirect",
+				Summary:     "Redirect the client",
+				Description: "Answers with a 3xx redirect, optionally chained over several hops or looping forever, to test client redirect following and loop protection",
+				Tags:        []string{"resilience"},
+				Parameters: []OpenAPIParameter{
+					{
+						Name:        "to",
+						In:          "query",
+						Description: "Final target: a relative reference, passed to the client unresolved, or an absolute http(s) URL. Without it the chain ends at /redirect, which answers 200",
+						Required:    false,
+						Schema: &OpenAPISchema{
+							Type:    "string",
+							Example: "/rest_payload?count=10",
+						},
+					},
+					{
+						Name:        "code",
+						In:          "query",
+						Description: "Redirect status code (default: 302)",
+						Required:    false,
+						Schema: &OpenAPISchema{
+							Type:    "integer",
+							Enum:    []interface{}{301, 302, 303, 307, 308},
+							Example: 302,
+						},
+					},
+					{
+						Name:        "n",
+						In:          "query",
+						Description: "Number of redirects before the target is reached (default: 1)",
+						Required:    false,
+						Schema: &OpenAPISchema{
+							Type:    "integer",
+							Minimum: &[]int{0}[0],
+							Maximum: &[]int{maxRedirectHops}[0],
+							Example: 3,
+						},
+					},
+					{
+						Name:        "loop",
+						In:          "query",
+						Description: "Redirect to the same URL forever to test loop detection (default: false)",
+						Required:    false,
+						Schema: &OpenAPISchema{
+							Type:    "boolean",
+							Example: false,
+						},
+					},
+				},
+				Responses: map[string]OpenAPIResponse{
+					"200": {
+						Description: "End of a redirect chain without a target",
+						Content: map[string]OpenAPIMediaType{
+							"application/json": {
+								Schema: &OpenAPISchema{
+									Type: "object",
+									Properties: map[string]*OpenAPISchema{
+										"redirects": {Type: "integer", Description: "Number of redirects the client followed"},
+									},
+								},
+								Example: RedirectResult{Redirects: 3},
+							},
+						},
+					},
+					"302": {
+						Description: "Redirect to the next hop or the target (the status follows the code parameter)",
+					},
+					"400": {
+						Description: "Bad request - invalid code, to or n",
+						Content: map[string]OpenAPIMediaType{
+							"text/plain": {
+								Schema: &OpenAPISchema{
+									Type:    "string",
+									Example: "code must be one of: 301, 302, 303, 307, 308",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
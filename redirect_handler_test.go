@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRedirectHandler_Single(t *testing.T) {
+	tests := []struct {
+		query    string
+		code     int
+		location string
+	}{
+		{"to=/rest_payload%3Fcount%3D1", http.StatusFound, "/rest_payload?count=1"},
+		{"to=rest_payload&code=307", http.StatusTemporaryRedirect, "rest_payload"},
+		{"to=https://example.com/items&code=301", http.StatusMovedPermanently, "https://example.com/items"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		RedirectHandler(w, httptest.NewRequest("GET", "/redirect?"+tt.query, nil))
+		if w.Code != tt.code {
+			t.Errorf("%s: expected status %d, got %d", tt.query, tt.code, w.Code)
+		}
+		if got := w.Header().Get("Location"); got != tt.location {
+			t.Errorf("%s: expected Location %q, got %q", tt.query, tt.location, got)
+		}
+	}
+}
+
+// TestRedirectHandler_Chain follows an N-hop chain with a real client
+func TestRedirectHandler_Chain(t *testing.T) {
+	*enableAuth = false
+	mux := http.NewServeMux()
+	mux.HandleFunc("/redirect", RedirectHandler)
+	mux.HandleFunc("/rest_payload", RestPayloadHandler)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	var hops []string
+	client := &http.Client{CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		hops = append(hops, req.URL.RequestURI())
+		return nil
+	}}
+
+	resp, err := client.Get(server.URL + "/redirect?n=3&to=/rest_payload%3Fcount%3D2")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected final status 200, got %d", resp.StatusCode)
+	}
+	if len(hops) != 3 || hops[2] != "/rest_payload?count=2" {
+		t.Errorf("Expected 3 redirects ending at the target, got %v", hops)
+	}
+	var items []StreamItem
+	if err := json.NewDecoder(resp.Body).Decode(&items); err != nil || len(items) != 2 {
+		t.Errorf("Expected the target's 2 items, got %d (%v)", len(items), err)
+	}
+
+	// Without a target the chain ends at /redirect
+	resp, err = http.Get(server.URL + "/redirect?n=4&code=308")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	var result RedirectResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode result: %v", err)
+	}
+	if result.Redirects != 4 {
+		t.Errorf("Expected 4 redirects followed, got %d", result.Redirects)
+	}
+}
+
+func TestRedirectHandler_Loop(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(RedirectHandler))
+	defer server.Close()
+
+	_, err := http.Get(server.URL + "/redirect?loop=true")
+	if err == nil || !strings.Contains(err.Error(), "stopped after 10 redirects") {
+		t.Errorf("Expected the client to stop the redirect loop, got %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	RedirectHandler(w, httptest.NewRequest("GET", "/redirect?loop=true&code=303", nil))
+	if got := w.Header().Get("Location"); got != "/redirect?loop=true&code=303" {
+		t.Errorf("Expected a self-referential Location, got %q", got)
+	}
+}
+
+func TestRedirectHandler_Invalid(t *testing.T) {
+	for _, query := range []string{"code=200", "code=abc", "n=-1", "n=101", "to=javascript:alert(1)"} {
+		w := httptest.NewRecorder()
+		RedirectHandler(w, httptest.NewRequest("GET", "/redirect?"+query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", query, w.Code)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// paramRequestTimeout bounds the time a handler may take, 0 disables it
+var paramRequestTimeout = flag.Duration("request-timeout", 0, "Answer requests that take longer with 503 Service Unavailable (0 = no timeout); streaming endpoints are exempt")
+
+// longLivedPaths are endpoints that are slow by design and must never be cut
+// off by the request timeout
+var longLivedPaths = map[string]bool{
+	"/stream_payload": true,
+	"/slow-read":      true,
+	"/longpoll":       true,
+}
+
+// timeoutWriter guards the response writer of a request with a timeout.
+// Unlike http.TimeoutHandler it does not buffer, so flushed responses such as
+// transfer=chunked or tiny_chunks reach the client as they are written.
+// After the timeout every write fails with http.ErrHandlerTimeout. The
+// handler gets its own header map, copied to the response when it starts,
+// so a late handler cannot race with the 503 reply.
+type timeoutWriter struct {
+	http.ResponseWriter
+	header      http.Header
+	mu          sync.Mutex
+	wroteHeader bool
+	timedOut    bool
+}
+
+func (t *timeoutWriter) Header() http.Header {
+	return t.header
+}
+
+// start copies the handler's headers and writes the status line once. The
+// caller must hold mu.
+func (t *timeoutWriter) start(status int) {
+	if t.wroteHeader {
+		return
+	}
+	t.wroteHeader = true
+	dst := t.ResponseWriter.Header()
+	for name := range dst {
+		if _, ok := t.header[name]; !ok {
+			delete(dst, name)
+		}
+	}
+	for name, values := range t.header {
+		dst[name] = values
+	}
+	t.ResponseWriter.WriteHeader(status)
+}
+
+func (t *timeoutWriter) WriteHeader(status int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.timedOut {
+		return
+	}
+	t.start(status)
+}
+
+func (t *timeoutWriter) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	t.start(http.StatusOK)
+	return t.ResponseWriter.Write(p)
+}
+
+func (t *timeoutWriter) Flush() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.timedOut {
+		return
+	}
+	if flusher, ok := t.ResponseWriter.(http.Flusher); ok {
+		t.start(http.StatusOK)
+		flusher.Flush()
+	}
+}
+
+// Unwrap gives http.ResponseController access to the underlying writer
+func (t *timeoutWriter) Unwrap() http.ResponseWriter {
+	return t.ResponseWriter
+}
+
+// timeout stops all further writes. If the response has not started yet it
+// is answered with 503 Service Unavailable; otherwise it ends where it is.
+func (t *timeoutWriter) timeout(r *http.Request) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.timedOut = true
+	if !t.wroteHeader {
+		writeErrorResponse(t.ResponseWriter, r, http.StatusServiceUnavailable, errCodeTimeout, "Request timed out")
+	}
+}
+
+// requestTimeoutMiddleware answers with 503 Service Unavailable if next does
+// not finish within timeout. The request context is cancelled on timeout, so
+// context-aware delays stop early; the middleware still waits for next to
+// return so no handler outlives its request. Responses that have already
+// started cannot change their status and are cut off instead. Long-lived
+// endpoints and a timeout of 0 leave next unwrapped.
+func requestTimeoutMiddleware(path string, timeout time.Duration, next http.HandlerFunc) http.HandlerFunc {
+	if timeout <= 0 || longLivedPaths[path] {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		r = r.WithContext(ctx)
+
+		tw := &timeoutWriter{ResponseWriter: w, header: w.Header().Clone()}
+		done := make(chan struct{})
+		panicked := make(chan any, 1)
+		go func() {
+			defer func() {
+				if p := recover(); p != nil {
+					panicked <- p
+				}
+			}()
+			next(tw, r)
+			close(done)
+		}()
+
+		select {
+		case <-done:
+			// Handlers stopped by the cancelled context may return before
+			// the timeout is noticed here
+			if ctx.Err() == context.DeadlineExceeded {
+				tw.timeout(r)
+			}
+		case p := <-panicked:
+			panic(p)
+		case <-ctx.Done():
+			tw.timeout(r)
+			select {
+			case <-done:
+			case p := <-panicked:
+				panic(p)
+			}
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRequestTimeoutMiddleware(t *testing.T) {
+	originalAuth := *enableAuth
+	*enableAuth = false
+	defer func() { *enableAuth = originalAuth }()
+
+	const timeout = 50 * time.Millisecond
+
+	// A slow paginated request is cut off with 503
+	handler := requestTimeoutMiddleware("/paginated_payload", timeout, PaginatedPayloadHandler)
+	w := httptest.NewRecorder()
+	start := time.Now()
+	handler(w, httptest.NewRequest("GET", "/paginated_payload?delay=2s&limit=1", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503 for slow paginated request, got %d", w.Code)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected timeout after ~%v, took %v", timeout, elapsed)
+	}
+
+	// Clients accepting JSON get the error envelope
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/paginated_payload?delay=2s&limit=1", nil)
+	req.Header.Set("Accept", "application/json")
+	handler(w, req)
+	var errResp ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &errResp); err != nil || w.Code != http.StatusServiceUnavailable || errResp.Error.Code != errCodeTimeout {
+		t.Errorf("Expected a 503 %s envelope, got %d %q (%v)", errCodeTimeout, w.Code, w.Body.String(), err)
+	}
+
+	// A fast paginated request is unaffected
+	w = httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/paginated_payload?limit=1", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200 for fast paginated request, got %d", w.Code)
+	}
+
+	// A stream outliving the timeout completes normally
+	handler = requestTimeoutMiddleware("/stream_payload", timeout, StreamingPayloadHandler)
+	w = httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/stream_payload?count=5&delay=30ms&batch_size=1", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 for stream, got %d", w.Code)
+	}
+	var items []StreamItem
+	if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil {
+		t.Fatalf("Failed to parse streamed JSON: %v", err)
+	}
+	if len(items) != 5 {
+		t.Errorf("Expected 5 streamed items, got %d", len(items))
+	}
+}
+
+func TestRequestTimeoutMiddleware_Disabled(t *testing.T) {
+	called := false
+	next := func(w http.ResponseWriter, r *http.Request) { called = true }
+
+	handler := requestTimeoutMiddleware("/rest_payload", 0, next)
+	handler(httptest.NewRecorder(), httptest.NewRequest("GET", "/rest_payload", nil))
+	if !called {
+		t.Error("Expected handler to be called without timeout")
+	}
+}
+
+// TestRequestTimeoutFlushing checks that the timeout keeps flushed responses
+// such as transfer=chunked intact instead of buffering them
+func TestRequestTimeoutFlushing(t *testing.T) {
+	originalAuth := *enableAuth
+	*enableAuth = false
+	defer func() { *enableAuth = originalAuth }()
+
+	server := httptest.NewServer(requestTimeoutMiddleware("/rest_payload", time.Second, transferModeMiddleware("/rest_payload", RestPayloadHandler)))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/rest_payload?count=1&transfer=chunked")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read body: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || len(body) == 0 {
+		t.Fatalf("Expected status 200 with a body, got %d: %s", resp.StatusCode, body)
+	}
+	if len(resp.TransferEncoding) != 1 || resp.TransferEncoding[0] != "chunked" {
+		t.Errorf("Expected chunked encoding with -request-timeout, got %v (Content-Length %d)", resp.TransferEncoding, resp.ContentLength)
+	}
+}
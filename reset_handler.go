@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// ResetPlugin implements PayloadPlugin for clearing runtime state between test runs
+type ResetPlugin struct{}
+
+// Path returns the HTTP path for the reset endpoint
+func (p ResetPlugin) Path() string {
+	return "/reset"
+}
+
+// Handler returns the handler function for the reset endpoint
+func (p ResetPlugin) Handler() http.HandlerFunc {
+	return ResetHandler
+}
+
+func init() {
+	registerPlugin(ResetPlugin{})
+}
+
+// ResetResponse lists the runtime state cleared by a reset
+type ResetResponse struct {
+	Reset []string `json:"reset"`
+}
+
+// ResetHandler clears the server's runtime state so test runs start from a
+// clean slate without restarting the server: the error injection progress
+// of all scenarios (e.g. how many requests an error_storm scenario has already
+// failed), the pagination drift and shrink clocks, the token_expiry sessions,
+// the per-client X-RateLimit-* counters and the pagination nonce. Cached
+// layered scenarios are dropped as well. Only POST is accepted.
+func ResetHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeErrorResponse(w, r, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed, use POST")
+		return
+	}
+
+	response := ResetResponse{Reset: []string{}}
+	if scenarioManager != nil {
+		scenarioManager.ResetErrorInjection()
+		scenarioManager.ResetPaginationDrift()
+		scenarioManager.ResetPaginationShrink()
+		scenarioManager.ResetTokenExpiry()
+		scenarioManager.ResetLayeredScenarios()
+		response.Reset = append(response.Reset, "error_injection", "pagination_drift", "pagination_shrink", "token_expiry", "layered_scenarios")
+	}
+	rateLimitCounter.Reset()
+	resetPaginationNonce()
+	response.Reset = append(response.Reset, "rate_limit", "pagination_nonce")
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, errCodeInternal, "Failed to encode response")
+	}
+}
+
+// OpenAPISpec returns the OpenAPI specification for the reset endpoint
+func (p ResetPlugin) OpenAPISpec() OpenAPIPathSpec {
+	return OpenAPIPathSpec{
+		Path: "/reset",
+		Operation: OpenAPIPath{
+			Post: &OpenAPIOperation{
+				OperationID: "postReset",
+				Summary:     "Reset runtime state",
+				Description: "Clears runtime state (scenario error injection progress, pagination drift and shrink, token_expiry sessions and X-RateLimit-* counters), so consecutive test runs behave identically without restarting the server",
+				Tags:        []string{"admin"},
+				Responses: map[string]OpenAPIResponse{
+					"200": {
+						Description: "Runtime state cleared",
+						Content: map[string]OpenAPIMediaType{
+							"application/json": {
+								Schema: &OpenAPISchema{
+									Type: "object",
+									Properties: map[string]*OpenAPISchema{
+										"reset": {
+											Type:        "array",
+											Description: "The kinds of state that were cleared",
+											Items:       &OpenAPISchema{Type: "string"},
+										},
+									},
+								},
+								Example: ResetResponse{Reset: []string{"error_injection", "pagination_drift", "pagination_shrink", "token_expiry", "layered_scenarios", "rate_limit", "pagination_nonce"}},
+							},
+						},
+					},
+					"405": {
+						Description: "Method not allowed - only POST is supported",
+						Content: map[string]OpenAPIMediaType{
+							"text/plain": {
+								Schema: &OpenAPISchema{
+									Type:    "string",
+									Example: "Method not allowed, use POST",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResetHandler(t *testing.T) {
+	originalAuth := *enableAuth
+	*enableAuth = false
+	defer func() { *enableAuth = originalAuth }()
+
+	originalManager := scenarioManager
+	defer func() { scenarioManager = originalManager }()
+
+	scenarioManager = &ScenarioManager{
+		scenarios: make(map[string]*Scenario),
+		validator: NewScenarioValidator(),
+	}
+	scenarioManager.loadEmbeddedScenarios()
+
+	requestCount := func() int {
+		scenarioManager.errorsMu.Lock()
+		defer scenarioManager.errorsMu.Unlock()
+		if state := scenarioManager.errorStates["error_storm"]; state != nil {
+			return state.requests
+		}
+		return 0
+	}
+
+	for range 3 {
+		PaginatedPayloadHandler(httptest.NewRecorder(), httptest.NewRequest("GET", "/paginated_payload?scenario=error_storm&limit=1", nil))
+	}
+	if got := requestCount(); got != 3 {
+		t.Fatalf("Expected 3 counted requests before reset, got %d", got)
+	}
+
+	w := httptest.NewRecorder()
+	ResetHandler(w, httptest.NewRequest("POST", "/reset", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	var response ResetResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse JSON response: %v", err)
+	}
+	expectedReset := []string{"error_injection", "pagination_drift", "pagination_shrink", "token_expiry", "layered_scenarios", "rate_limit", "pagination_nonce"}
+	if fmt.Sprint(response.Reset) != fmt.Sprint(expectedReset) {
+		t.Errorf("Expected %v to be reset, got %v", expectedReset, response.Reset)
+	}
+
+	if got := requestCount(); got != 0 {
+		t.Errorf("Expected 0 counted requests after reset, got %d", got)
+	}
+
+	// The error storm starts over from its first failing request
+	w = httptest.NewRecorder()
+	PaginatedPayloadHandler(w, httptest.NewRequest("GET", "/paginated_payload?scenario=error_storm&limit=1", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected 500 after reset, got %d", w.Code)
+	}
+}
+
+func TestResetHandler_MethodNotAllowed(t *testing.T) {
+	w := httptest.NewRecorder()
+	ResetHandler(w, httptest.NewRequest("GET", "/reset", nil))
+
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405, got %d", w.Code)
+	}
+	if allow := w.Header().Get("Allow"); allow != http.MethodPost {
+		t.Errorf("Expected Allow: POST, got %q", allow)
+	}
+}
@@ -1,11 +1,27 @@
 package main
 
 import (
+	"bufio"
+	"compress/gzip"
 	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
+	"text/template"
 )
 
+// maxRestCount bounds the count parameter and the -rest-default-count flag
+const maxRestCount = 1000000
+
+// maxRestRepeat bounds the repeat parameter
+const maxRestRepeat = 10
+
+// paramRestDefaultCount is the number of items returned when count is omitted
+var paramRestDefaultCount = flag.Int("rest-default-count", 10000, "Number of items /rest_payload returns when count is omitted")
+
 // Item represents a single object in the JSON payload returned by the /payload endpoint.
 type Item struct {
 	ID   int    `json:"id"`   // Unique identifier for the item
@@ -14,37 +30,347 @@ type Item struct {
 
 // RestPayloadHandler handles HTTP GET requests to the /payload endpoint.
 //
-// It generates a slice of 10000 Item objects and returns them as a JSON array.
-// This endpoint is primarily used for testing REST client implementations and
-// observing behavior when consuming very large JSON responses.
+// It returns count Item objects as a JSON array. Without count the
+// -rest-default-count flag applies (10000 unless configured).
+// Items are encoded one at a time, so large counts do not require
+// preallocating the whole payload in memory. This endpoint is primarily used
+// for testing REST client implementations and observing behavior when
+// consuming very large JSON responses.
+//
+// With dry_run=true a JSON summary of the planned response is returned instead.
+// The ttfb parameter delays the response to simulate a slow backend;
+// header_delay (see headerDelayMiddleware) holds back the headers as well.
+// IDs start at 1 unless id_start sets a different first ID; string_ids=true
+// emits them as JSON strings. value_template (e.g. "User-{{.ID}}") renders
+// the item names.
+// With pretty=true the output is indented for reading in a browser;
+// json_style selects compact, spaced or pretty separators (see JSONStyle).
+//
+// The prefix parameter writes a UTF-8 BOM or whitespace before the JSON (see
+// BodyPrefix), which decoders tolerate to varying degrees.
+// The corrupt parameter deliberately breaks the JSON output (see CorruptMode)
+// to test client error handling. Corrupted responses are NOT valid JSON.
+// Neither are responses with repeat > 1, which send the array that many
+// times back to back like upstreams that duplicate their response body.
+// With tiny_chunks=true the body is flushed in chunks of tinyChunkBytes.
+// The bandwidth parameter (e.g. "1MBps") paces the body like a
+// bandwidth-limited link; like tiny_chunks it does not apply to corrupt=gzip.
+// big_header adds an X-PayloadBuddy-Big-Header of the given size in bytes.
 func RestPayloadHandler(w http.ResponseWriter, r *http.Request) {
 	// Set the Content-Type header so clients interpret the response as JSON.
 	w.Header().Set("Content-Type", "application/json")
+	if err := applyBigHeader(w, r); err != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, errCodeBadRequest, err.Error())
+		return
+	}
 
-	// Parse count parameter, default to 10000
-	count := 10000
+	// Parse count parameter, default to the -rest-default-count flag
+	count := *paramRestDefaultCount
 	if val := r.URL.Query().Get("count"); val != "" {
-		if parsed, err := strconv.Atoi(val); err == nil && parsed > 0 && parsed <= 1000000 {
+		if parsed, err := strconv.Atoi(val); err == nil && parsed > 0 && parsed <= maxRestCount {
 			count = parsed
 		}
 	}
 
-	// Preallocate a slice of Item with 'count' elements.
-	data := make([]Item, count)
+	corrupt, err := getCorruptMode(r)
+	if err != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, errCodeBadRequest, err.Error())
+		return
+	}
+	// Rest items have no ServiceNow fields or timestamps, only the IDs apply
+	itemOpts, err := parseItemOptions(r, 1, false)
+	if err != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, errCodeBadRequest, err.Error())
+		return
+	}
+	idStart, valueTmpl := itemOpts.IDStart, itemOpts.ValueTemplate
+
+	prefix, err := getBodyPrefix(r)
+	if err != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, errCodeBadRequest, err.Error())
+		return
+	}
+	stringIDs := isStringIDs(r)
+	tinyChunks := isTinyChunks(r) && corrupt != CorruptGzip
+	repeat, err := getRestRepeat(r, corrupt)
+	if err != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, errCodeBadRequest, err.Error())
+		return
+	}
+	bandwidth, err := getBandwidth(r)
+	if err != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, errCodeBadRequest, err.Error())
+		return
+	}
+	style, err := getJSONStyle(r)
+	if err != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, errCodeBadRequest, err.Error())
+		return
+	}
+
+	effective := map[string]interface{}{
+		"count":    count,
+		"corrupt":  corrupt,
+		"id_start": idStart,
+	}
+	if stringIDs {
+		effective["string_ids"] = true
+	}
+	if prefix != PrefixNone {
+		effective["prefix"] = prefix
+	}
+	if repeat > 1 {
+		effective["repeat"] = repeat
+	}
+	if tinyChunks {
+		effective["tiny_chunks"] = true
+	}
+	if bandwidth > 0 && corrupt != CorruptGzip {
+		effective["bandwidth"] = bandwidth
+	}
+	if valueTmpl != nil {
+		effective["value_template"] = valueTmpl.Root.String()
+	}
+	setEffectiveParams(w, effective)
+
+	// Report the planned response instead of generating it
+	if isDryRun(r) {
+		writeRestDryRun(w, r, count, idStart, stringIDs, repeat, valueTmpl)
+		return
+	}
+
+	// Hold back the response for the requested time-to-first-byte
+	if err := waitTTFB(r, ""); err != nil {
+		return
+	}
+
+	// Encode the items one at a time into a single JSON array so memory use
+	// stays flat regardless of count. Once the first byte is written the
+	// status can no longer change, so write errors simply end the response.
+	if corrupt == CorruptGzip {
+		writeTruncatedGzip(w, count, idStart, stringIDs, valueTmpl, prefix)
+		return
+	}
+
+	// Split the bounded body into tiny flushed chunks to stress the client's
+	// chunk reassembly
+	var body io.Writer = w
+	if flusher, ok := w.(http.Flusher); ok && tinyChunks {
+		chunker := newChunkFlusher(w, flusher, tinyChunkBytes)
+		defer chunker.Flush()
+		body = chunker
+	}
+	if bandwidth > 0 {
+		body = newThrottledWriter(r.Context(), body, bandwidth)
+	}
+
+	if _, err := body.Write(prefix.bytes()); err != nil {
+		return
+	}
+	for range repeat {
+		// Corrupted output is always compact
+		if (style == JSONStyleSpaced || style == JSONStylePretty) && corrupt == CorruptNone {
+			err = writeRestItemsStyled(body, count, idStart, stringIDs, valueTmpl, style)
+		} else {
+			err = writeRestItems(body, count, idStart, stringIDs, valueTmpl, corrupt)
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// getRestRepeat parses the repeat query parameter, how many times the array
+// is written. Repeating only makes sense for complete arrays, so it cannot
+// be combined with corrupt.
+func getRestRepeat(r *http.Request, corrupt CorruptMode) (int, error) {
+	val := r.URL.Query().Get("repeat")
+	if val == "" {
+		return 1, nil
+	}
+	repeat, err := strconv.Atoi(val)
+	if err != nil || repeat < 1 || repeat > maxRestRepeat {
+		return 0, fmt.Errorf("repeat must be between 1 and %d", maxRestRepeat)
+	}
+	if repeat > 1 && corrupt != CorruptNone {
+		return 0, fmt.Errorf("repeat cannot be combined with corrupt")
+	}
+	return repeat, nil
+}
+
+// CorruptMode selects how the rest payload output is intentionally broken
+type CorruptMode string
+
+// Corruption modes supported by the corrupt query parameter
+const (
+	CorruptNone          CorruptMode = "none"           // Valid JSON (default)
+	CorruptTruncated     CorruptMode = "truncated"      // Output stops in the middle of an item, array never closed
+	CorruptTrailingComma CorruptMode = "trailing_comma" // A comma follows the last array element
+	CorruptInvalidUTF8   CorruptMode = "invalid_utf8"   // The first item's name contains invalid UTF-8 bytes
+	CorruptGzip          CorruptMode = "gzip"           // Claims gzip encoding but the compressed stream is truncated
+)
+
+// getCorruptMode parses the corrupt query parameter
+func getCorruptMode(r *http.Request) (CorruptMode, error) {
+	mode := CorruptMode(strings.ToLower(r.URL.Query().Get("corrupt")))
+	switch mode {
+	case "":
+		return CorruptNone, nil
+	case CorruptNone, CorruptTruncated, CorruptTrailingComma, CorruptInvalidUTF8, CorruptGzip:
+		return mode, nil
+	default:
+		return CorruptNone, fmt.Errorf("corrupt must be one of: %s, %s, %s, %s, %s",
+			CorruptNone, CorruptTruncated, CorruptTrailingComma, CorruptInvalidUTF8, CorruptGzip)
+	}
+}
 
-	// Populate each Item in the slice with an ID and a static name.
+// BodyPrefix selects bytes written before the JSON body of the rest payload
+type BodyPrefix string
+
+// Prefixes supported by the prefix query parameter
+const (
+	PrefixNone       BodyPrefix = "none"       // JSON starts at the first byte (default)
+	PrefixBOM        BodyPrefix = "bom"        // UTF-8 byte order mark EF BB BF
+	PrefixWhitespace BodyPrefix = "whitespace" // Space, newline and tab
+)
+
+// getBodyPrefix parses the prefix query parameter
+func getBodyPrefix(r *http.Request) (BodyPrefix, error) {
+	prefix := BodyPrefix(strings.ToLower(r.URL.Query().Get("prefix")))
+	switch prefix {
+	case "":
+		return PrefixNone, nil
+	case PrefixNone, PrefixBOM, PrefixWhitespace:
+		return prefix, nil
+	default:
+		return PrefixNone, fmt.Errorf("prefix must be one of: %s, %s, %s", PrefixNone, PrefixBOM, PrefixWhitespace)
+	}
+}
+
+// bytes returns the bytes written for the prefix
+func (p BodyPrefix) bytes() []byte {
+	switch p {
+	case PrefixBOM:
+		return []byte("\xEF\xBB\xBF")
+	case PrefixWhitespace:
+		return []byte(" \n\t")
+	default:
+		return nil
+	}
+}
+
+// writeRestItems writes count items with IDs starting at idStart as a JSON
+// array, byte-identical to encoding a []Item with json.Encoder unless a
+// corruption mode or string IDs are set. A single item buffer is reused for
+// every element, so allocations do not grow with count. If valueTmpl is set
+// it renders the name of every item.
+func writeRestItems(w io.Writer, count, idStart int, stringIDs bool, valueTmpl *template.Template, corrupt CorruptMode) error {
+	bw := bufio.NewWriterSize(w, 32*1024)
+	buf := make([]byte, 0, 64)
+
+	if err := bw.WriteByte('['); err != nil {
+		return err
+	}
 	for i := 1; i <= count; i++ {
-		data[i-1] = Item{
-			ID:   i,
-			Name: "Object " + strconv.Itoa(i),
+		if i > 1 {
+			if err := bw.WriteByte(','); err != nil {
+				return err
+			}
 		}
+		buf = appendRestItem(buf[:0], idStart+i-1, i-1, stringIDs, valueTmpl)
+
+		switch {
+		case corrupt == CorruptInvalidUTF8 && i == 1:
+			// Insert bytes that can never appear in UTF-8 before the closing quote
+			buf = append(buf[:len(buf)-2], 0xff, 0xfe, '"', '}')
+		case corrupt == CorruptTruncated && i == (count+1)/2:
+			// Cut the middle item in half and end the response there
+			if _, err := bw.Write(buf[:len(buf)/2]); err != nil {
+				return err
+			}
+			return bw.Flush()
+		}
+
+		if _, err := bw.Write(buf); err != nil {
+			return err
+		}
+	}
+	if corrupt == CorruptTrailingComma {
+		if err := bw.WriteByte(','); err != nil {
+			return err
+		}
+	}
+	if _, err := bw.WriteString("]\n"); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// writeTruncatedGzip sends the JSON array gzip-compressed with a
+// Content-Encoding: gzip header, but never closes the gzip stream. The final
+// deflate block and the gzip trailer (CRC-32 and size) are missing, so every
+// compliant decompressor fails with an unexpected EOF. This is intentionally
+// broken output for testing client decompression error handling. As the
+// response is already cut off on purpose, write errors simply end it early.
+func writeTruncatedGzip(w http.ResponseWriter, count, idStart int, stringIDs bool, valueTmpl *template.Template, prefix BodyPrefix) {
+	w.Header().Set("Content-Encoding", "gzip")
+	w.Header().Del("Content-Length")
+
+	gz := gzip.NewWriter(w)
+	if _, err := gz.Write(prefix.bytes()); err != nil {
+		return
+	}
+	if err := writeRestItems(gz, count, idStart, stringIDs, valueTmpl, CorruptNone); err != nil {
+		return
 	}
+	// Flush emits the compressed data written so far but, unlike Close,
+	// leaves the stream unterminated
+	_ = gz.Flush()
+}
+
+// appendRestItem appends the JSON encoding of Item{ID: id, Name: "Object <id>"}
+// to buf, with the id quoted if stringIDs is set. The default name only ever
+// contains ASCII letters, digits and a space, so no escaping is required. A
+// valueTmpl renders the name instead, for the item at position index.
+func appendRestItem(buf []byte, id, index int, stringIDs bool, valueTmpl *template.Template) []byte {
+	buf = append(buf, `{"id":`...)
+	if stringIDs {
+		buf = append(buf, '"')
+		buf = strconv.AppendInt(buf, int64(id), 10)
+		buf = append(buf, '"')
+	} else {
+		buf = strconv.AppendInt(buf, int64(id), 10)
+	}
+	if valueTmpl != nil {
+		name, _ := json.Marshal(renderValueTemplate(valueTmpl, id, index))
+		buf = append(buf, `,"name":`...)
+		buf = append(buf, name...)
+		return append(buf, '}')
+	}
+	buf = append(buf, `,"name":"Object `...)
+	buf = strconv.AppendInt(buf, int64(id), 10)
+	return append(buf, `"}`...)
+}
+
+// writeRestDryRun reports the size of the array a rest payload request would
+// produce, without generating it
+func writeRestDryRun(w http.ResponseWriter, r *http.Request, count, idStart int, stringIDs bool, repeat int, valueTmpl *template.Template) {
+	first := appendRestItem(nil, idStart, 0, stringIDs, valueTmpl)
+	last := appendRestItem(nil, idStart+count-1, count-1, stringIDs, valueTmpl)
 
-	// Encode the slice as JSON and write it to the response writer.
-	// If encoding fails, an HTTP 500 error is sent.
-	if err := json.NewEncoder(w).Encode(data); err != nil {
-		http.Error(w, "Failed to encode payload", http.StatusInternalServerError)
+	summary := DryRunSummary{
+		Endpoint:       r.URL.Path,
+		ItemCount:      count,
+		EstimatedBytes: estimateArrayBytes(first, last, count, len(","), len("[]\n")) * int64(repeat),
+		EffectiveParameters: map[string]interface{}{
+			"count":      count,
+			"id_start":   idStart,
+			"string_ids": stringIDs,
+			"repeat":     repeat,
+		},
 	}
+	summary.setEstimatedDuration(0)
+	writeDryRunSummary(w, r, summary)
 }
 
 // OpenAPISpec returns the OpenAPI specification for the rest payload endpoint
@@ -53,6 +379,7 @@ func (h RestPayloadPlugin) OpenAPISpec() OpenAPIPathSpec {
 		Path: "/rest_payload",
 		Operation: OpenAPIPath{
 			Get: &OpenAPIOperation{
+				OperationID: "getRestPayload",
 				Summary:     "Get large JSON payload",
 				Description: "Returns a configurable number of JSON objects for testing REST client implementations",
 				Tags:        []string{"payload"},
@@ -60,7 +387,7 @@ func (h RestPayloadPlugin) OpenAPISpec() OpenAPIPathSpec {
 					{
 						Name:        "count",
 						In:          "query",
-						Description: "Number of objects to return (default: 10000, max: 1000000)",
+						Description: "Number of objects to return (default: 10000, configurable with the -rest-default-count flag; max: 1000000)",
 						Required:    false,
 						Schema: &OpenAPISchema{
 							Type:    "integer",
@@ -69,6 +396,177 @@ func (h RestPayloadPlugin) OpenAPISpec() OpenAPIPathSpec {
 							Example: 10000,
 						},
 					},
+					{
+						Name:        "pretty",
+						In:          "query",
+						Description: "Indent the JSON output for reading in a browser (default: false, compact). Ignored with corrupt",
+						Required:    false,
+						Schema: &OpenAPISchema{
+							Type:    "boolean",
+							Example: false,
+						},
+					},
+					{
+						Name:        "json_style",
+						In:          "query",
+						Description: "Whitespace between JSON tokens: compact (none), spaced (one space after every , and :) or pretty (indented, like pretty=true). Overrides pretty. Ignored with corrupt",
+						Required:    false,
+						Schema: &OpenAPISchema{
+							Type:    "string",
+							Enum:    []interface{}{"compact", "spaced", "pretty"},
+							Example: "spaced",
+						},
+					},
+					{
+						Name:        "proto",
+						In:          "query",
+						Description: "Answer HTTP/1.0-style with \"1.0\": the response is buffered and sent with Content-Length and Connection: close instead of chunked encoding (default: 1.1). The -http10 flag applies this to every request",
+						Required:    false,
+						Schema: &OpenAPISchema{
+							Type:    "string",
+							Enum:    []any{"1.0", "1.1"},
+							Example: "1.0",
+						},
+					},
+					{
+						Name:        "transfer",
+						In:          "query",
+						Description: "Force the transfer encoding: \"chunked\" sends the body with chunked encoding, \"length\" buffers it and sends Content-Length (default: chunked above a few KB). \"chunked\" cannot be combined with proto=1.0",
+						Required:    false,
+						Schema: &OpenAPISchema{
+							Type:    "string",
+							Enum:    []any{"chunked", "length"},
+							Example: "length",
+						},
+					},
+					{
+						Name:        "big_header",
+						In:          "query",
+						Description: "Add an X-PayloadBuddy-Big-Header response header with a value of this many bytes (repeating a-z0-9), to test header size limits of clients and proxies",
+						Required:    false,
+						Schema: &OpenAPISchema{
+							Type:    "integer",
+							Minimum: &[]int{1}[0],
+							Maximum: &[]int{maxBigHeaderBytes}[0],
+							Example: 16384,
+						},
+					},
+					{
+						Name:        "dry_run",
+						In:          "query",
+						Description: "Return a JSON summary of the planned response (item count, estimated bytes, effective parameters) instead of the payload",
+						Required:    false,
+						Schema: &OpenAPISchema{
+							Type:    "boolean",
+							Example: false,
+						},
+					},
+					{
+						Name:        "ttfb",
+						In:          "query",
+						Description: "Artificial time to first byte before the response is written (e.g., '2s')",
+						Required:    false,
+						Schema: &OpenAPISchema{
+							Type:    "string",
+							Example: "2s",
+						},
+					},
+					{
+						Name:        "header_delay",
+						In:          "query",
+						Description: "Hold back the status line and response headers by this duration, on top of ttfb and any other delay, to test response header timeouts (e.g., '5s', max: 5m)",
+						Required:    false,
+						Schema: &OpenAPISchema{
+							Type:    "string",
+							Example: "5s",
+						},
+					},
+					{
+						Name:        "id_start",
+						In:          "query",
+						Description: "ID of the first item (default: 1)",
+						Required:    false,
+						Schema: &OpenAPISchema{
+							Type:    "integer",
+							Minimum: &[]int{0}[0],
+							Maximum: &[]int{maxIDStart}[0],
+							Example: 1,
+						},
+					},
+					{
+						Name:        "value_template",
+						In:          "query",
+						Description: "Go text/template rendering the name of every item instead of \"Object <id>\", with {{.ID}} (item ID) and {{.Index}} (position, from 0). Templates that do not parse or render yield 400",
+						Required:    false,
+						Schema: &OpenAPISchema{
+							Type:    "string",
+							Example: "User-{{.ID}}",
+						},
+					},
+					{
+						Name:        "string_ids",
+						In:          "query",
+						Description: "Emit the item id as a JSON string (\"42\") instead of a number, as ServiceNow does for numeric-looking values (default: false)",
+						Required:    false,
+						Schema: &OpenAPISchema{
+							Type:    "boolean",
+							Example: true,
+						},
+					},
+					{
+						Name:        "prefix",
+						In:          "query",
+						Description: "Bytes written before the JSON body: 'bom' a UTF-8 byte order mark, 'whitespace' a space, newline and tab. Leading whitespace is valid JSON and a BOM may be ignored by RFC 8259 parsers, but both trip up some clients",
+						Required:    false,
+						Schema: &OpenAPISchema{
+							Type:    "string",
+							Enum:    []interface{}{"none", "bom", "whitespace"},
+							Example: "bom",
+						},
+					},
+					{
+						Name:        "corrupt",
+						In:          "query",
+						Description: "Intentionally break the JSON output to test client resilience. Any mode other than 'none' produces INVALID JSON on purpose; 'gzip' sets Content-Encoding: gzip with a truncated gzip stream",
+						Required:    false,
+						Schema: &OpenAPISchema{
+							Type:    "string",
+							Enum:    []interface{}{"none", "truncated", "trailing_comma", "invalid_utf8", "gzip"},
+							Example: "none",
+						},
+					},
+					{
+						Name:        "tiny_chunks",
+						In:          "query",
+						Description: "Flush the body in 16 byte chunks to stress the client's chunk reassembly (default: false). Slow for large counts; ignored with corrupt=gzip",
+						Required:    false,
+						Schema: &OpenAPISchema{
+							Type:    "boolean",
+							Example: true,
+						},
+					},
+					{
+						Name:        "bandwidth",
+						In:          "query",
+						Description: "Pace the body to at most this many bytes per second, like a bandwidth-limited link. Units are decimal bytes: Bps, KBps, MBps, GBps; a plain number is bytes per second (default: unlimited). Ignored with corrupt=gzip",
+						Required:    false,
+						Schema: &OpenAPISchema{
+							Type:    "string",
+							Example: "1MBps",
+						},
+					},
+					{
+						Name:        "repeat",
+						In:          "query",
+						Description: "Write the array this many times back to back, like buggy upstreams that send their body twice (default: 1). Any value above 1 produces INVALID JSON on purpose; cannot be combined with corrupt",
+						Required:    false,
+						Schema: &OpenAPISchema{
+							Type:    "integer",
+							Minimum: &[]int{1}[0],
+							Maximum: &[]int{maxRestRepeat}[0],
+							Example: 2,
+						},
+					},
 				},
 				Responses: map[string]OpenAPIResponse{
 					"200": {
@@ -101,6 +599,17 @@ func (h RestPayloadPlugin) OpenAPISpec() OpenAPIPathSpec {
 							},
 						},
 					},
+					"400": {
+						Description: "Bad request - invalid parameters",
+						Content: map[string]OpenAPIMediaType{
+							"text/plain": {
+								Schema: &OpenAPISchema{
+									Type:    "string",
+									Example: "corrupt must be one of: none, truncated, trailing_comma, invalid_utf8, gzip",
+								},
+							},
+						},
+					},
 					"500": {
 						Description: "Internal server error",
 						Content: map[string]OpenAPIMediaType{
@@ -3,6 +3,8 @@
 package main
 
 import (
+	"bytes"
+	"compress/gzip"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
@@ -10,6 +12,8 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
+	"unicode/utf8"
 
 	"github.com/xeipuuv/gojsonschema"
 )
@@ -159,6 +163,35 @@ func TestRestPayloadHandler_CountParameter(t *testing.T) {
 	}
 }
 
+// TestRestPayloadHandler_DefaultCountFlag checks that -rest-default-count changes the default response length.
+func TestRestPayloadHandler_DefaultCountFlag(t *testing.T) {
+	*enableAuth = false
+	originalCount := *paramRestDefaultCount
+	*paramRestDefaultCount = 7
+	defer func() { *paramRestDefaultCount = originalCount }()
+
+	w := httptest.NewRecorder()
+	RestPayloadHandler(w, httptest.NewRequest("GET", "/rest_payload", nil))
+
+	var items []Item
+	if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil {
+		t.Fatalf("Failed to parse JSON: %v", err)
+	}
+	if len(items) != 7 {
+		t.Errorf("Expected 7 items from the configured default, got %d", len(items))
+	}
+
+	// An explicit count still takes precedence
+	w = httptest.NewRecorder()
+	RestPayloadHandler(w, httptest.NewRequest("GET", "/rest_payload?count=3", nil))
+	if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil {
+		t.Fatalf("Failed to parse JSON: %v", err)
+	}
+	if len(items) != 3 {
+		t.Errorf("Expected 3 items with explicit count, got %d", len(items))
+	}
+}
+
 // TestRestPayloadHandler_AuthenticationRequired tests that authentication is required when enabled.
 func TestRestPayloadHandler_AuthenticationRequired(t *testing.T) {
 	*enableAuth = true
@@ -198,3 +231,271 @@ func TestRestPayloadHandler_AuthenticationRequired(t *testing.T) {
 		t.Errorf("Expected status 200 with correct auth, got %d", resp.StatusCode)
 	}
 }
+
+// TestRestPayloadHandler_TTFB checks that the response is held back for the configured time to first byte.
+func TestRestPayloadHandler_TTFB(t *testing.T) {
+	*enableAuth = false
+	req := httptest.NewRequest(http.MethodGet, "/rest_payload?count=1&ttfb=100ms", nil)
+	w := httptest.NewRecorder()
+
+	start := time.Now()
+	RestPayloadHandler(w, req)
+	elapsed := time.Since(start)
+
+	if elapsed < 100*time.Millisecond {
+		t.Errorf("Expected response after at least 100ms, got %v", elapsed)
+	}
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+}
+
+// TestRestPayloadHandler_MatchesJSONEncoding checks that the streamed array is byte-identical
+// to encoding the equivalent []Item with json.Encoder.
+func TestRestPayloadHandler_MatchesJSONEncoding(t *testing.T) {
+	*enableAuth = false
+	for _, count := range []int{1, 2, 137} {
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/rest_payload?count=%d", count), nil)
+		w := httptest.NewRecorder()
+
+		RestPayloadHandler(w, req)
+
+		items := make([]Item, count)
+		for i := range items {
+			items[i] = Item{ID: i + 1, Name: fmt.Sprintf("Object %d", i+1)}
+		}
+		var expected bytes.Buffer
+		if err := json.NewEncoder(&expected).Encode(items); err != nil {
+			t.Fatalf("Failed to encode expected payload: %v", err)
+		}
+
+		if w.Body.String() != expected.String() {
+			t.Errorf("count=%d: streamed payload differs from json.Encoder output", count)
+		}
+	}
+}
+
+// TestRestPayloadHandler_FlatAllocations checks that allocations do not grow with count,
+// i.e. the payload is not materialized in memory before it is written.
+func TestRestPayloadHandler_FlatAllocations(t *testing.T) {
+	*enableAuth = false
+	allocs := func(count int) float64 {
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/rest_payload?count=%d", count), nil)
+		w := &discardResponseWriter{header: http.Header{}}
+		return testing.AllocsPerRun(5, func() {
+			RestPayloadHandler(w, req)
+		})
+	}
+
+	small := allocs(1000)
+	large := allocs(100000)
+	if large > small*2 {
+		t.Errorf("Allocations scale with count: %.0f for 1000 items, %.0f for 100000 items", small, large)
+	}
+}
+
+// discardResponseWriter is an http.ResponseWriter that drops the body so
+// benchmarks measure the handler rather than the response buffer.
+type discardResponseWriter struct {
+	header http.Header
+}
+
+func (d *discardResponseWriter) Header() http.Header         { return d.header }
+func (d *discardResponseWriter) Write(p []byte) (int, error) { return len(p), nil }
+func (d *discardResponseWriter) WriteHeader(int)             {}
+
+func benchmarkRestPayloadHandler(b *testing.B, count int) {
+	originalAuth := *enableAuth
+	*enableAuth = false
+	defer func() { *enableAuth = originalAuth }()
+
+	req := httptest.NewRequest("GET", fmt.Sprintf("/rest_payload?count=%d", count), nil)
+	w := &discardResponseWriter{header: http.Header{}}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		RestPayloadHandler(w, req)
+	}
+}
+
+func BenchmarkRestPayloadHandler(b *testing.B) {
+	benchmarkRestPayloadHandler(b, 10000)
+}
+
+func BenchmarkRestPayloadHandlerLargeCount(b *testing.B) {
+	benchmarkRestPayloadHandler(b, 1000000)
+}
+
+// TestRestPayloadHandler_Corrupt checks that corruption modes break the JSON output while none keeps it valid.
+func TestRestPayloadHandler_Corrupt(t *testing.T) {
+	*enableAuth = false
+	tests := []struct {
+		mode      string
+		wantValid bool
+	}{
+		{"none", true},
+		{"truncated", false},
+		{"trailing_comma", false},
+		{"invalid_utf8", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/rest_payload?count=10&corrupt="+tt.mode, nil)
+			w := httptest.NewRecorder()
+
+			RestPayloadHandler(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d", w.Code)
+			}
+			// RFC 8259 requires UTF-8, which json.Valid does not check
+			body := w.Body.Bytes()
+			if valid := json.Valid(body) && utf8.Valid(body); valid != tt.wantValid {
+				t.Errorf("Expected valid=%v, got valid=%v for body %q", tt.wantValid, valid, w.Body.String())
+			}
+		})
+	}
+}
+
+// TestRestPayloadHandler_CorruptGzip checks that corrupt=gzip claims gzip encoding but cannot be decompressed.
+func TestRestPayloadHandler_CorruptGzip(t *testing.T) {
+	*enableAuth = false
+	req := httptest.NewRequest(http.MethodGet, "/rest_payload?count=100&corrupt=gzip", nil)
+	w := httptest.NewRecorder()
+
+	RestPayloadHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if got := w.Header().Get("Content-Encoding"); got != "gzip" {
+		t.Fatalf("Expected Content-Encoding gzip, got %q", got)
+	}
+
+	gz, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatalf("Expected a valid gzip header, got error: %v", err)
+	}
+	if _, err := io.ReadAll(gz); err == nil {
+		t.Error("Expected decompression to fail for truncated gzip body")
+	}
+}
+
+// TestRestPayloadHandler_InvalidCorrupt checks that an unknown corruption mode is rejected.
+func TestRestPayloadHandler_InvalidCorrupt(t *testing.T) {
+	*enableAuth = false
+	req := httptest.NewRequest(http.MethodGet, "/rest_payload?corrupt=scramble", nil)
+	w := httptest.NewRecorder()
+
+	RestPayloadHandler(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
+
+// TestRestPayloadHandler_Prefix checks that the prefix bytes precede an otherwise unchanged JSON array.
+func TestRestPayloadHandler_Prefix(t *testing.T) {
+	*enableAuth = false
+	tests := map[string][]byte{
+		"bom":        {0xEF, 0xBB, 0xBF},
+		"whitespace": []byte(" \n\t"),
+		"none":       nil,
+	}
+	for prefix, want := range tests {
+		w := httptest.NewRecorder()
+		RestPayloadHandler(w, httptest.NewRequest(http.MethodGet, "/rest_payload?count=2&prefix="+prefix, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected status 200, got %d", prefix, w.Code)
+		}
+		body := w.Body.Bytes()
+		if !bytes.HasPrefix(body, append(want, '[')) {
+			t.Errorf("%s: expected % X before the array, got %q", prefix, want, body)
+			continue
+		}
+		var items []Item
+		if err := json.Unmarshal(body[len(want):], &items); err != nil || len(items) != 2 {
+			t.Errorf("%s: expected 2 items after the prefix, got %v (%v)", prefix, items, err)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	RestPayloadHandler(w, httptest.NewRequest(http.MethodGet, "/rest_payload?prefix=utf16", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unknown prefix, got %d", w.Code)
+	}
+}
+
+func TestRestPayloadHandler_Repeat(t *testing.T) {
+	*enableAuth = false
+	w := httptest.NewRecorder()
+	RestPayloadHandler(w, httptest.NewRequest(http.MethodGet, "/rest_payload?count=3&repeat=2", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	body := w.Body.Bytes()
+	if json.Valid(body) {
+		t.Fatalf("Expected invalid JSON for repeat=2, got %s", body)
+	}
+
+	// The body holds two complete arrays back to back
+	dec := json.NewDecoder(bytes.NewReader(body))
+	for i := 0; i < 2; i++ {
+		var items []Item
+		if err := dec.Decode(&items); err != nil {
+			t.Fatalf("Array %d: %v", i+1, err)
+		}
+		if len(items) != 3 || items[0].ID != 1 {
+			t.Errorf("Array %d: expected items 1-3, got %v", i+1, items)
+		}
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		t.Errorf("Expected exactly two arrays, got more data: %v", err)
+	}
+
+	for _, query := range []string{"repeat=0", "repeat=11", "repeat=x", "repeat=2&corrupt=truncated"} {
+		w := httptest.NewRecorder()
+		RestPayloadHandler(w, httptest.NewRequest(http.MethodGet, "/rest_payload?"+query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", query, w.Code)
+		}
+	}
+}
+
+func TestRestPayloadHandler_TinyChunks(t *testing.T) {
+	*enableAuth = false
+	w := &flushRecorder{ResponseRecorder: httptest.NewRecorder()}
+	RestPayloadHandler(w, httptest.NewRequest(http.MethodGet, "/rest_payload?count=50&tiny_chunks=true", nil))
+
+	body := w.Body.Bytes()
+	if want := len(body) / tinyChunkBytes; len(w.flushedAt) < want {
+		t.Errorf("Expected at least %d flushes for %d bytes, got %d", want, len(body), len(w.flushedAt))
+	}
+	for i, at := range w.flushedAt[:len(w.flushedAt)-1] {
+		if at != (i+1)*tinyChunkBytes {
+			t.Fatalf("Expected flush %d after %d bytes, got %d", i+1, (i+1)*tinyChunkBytes, at)
+		}
+	}
+
+	// Over a real connection the pieces arrive chunked and still form the
+	// complete array
+	ts := httptest.NewServer(http.HandlerFunc(RestPayloadHandler))
+	defer ts.Close()
+	resp, err := http.Get(ts.URL + "/rest_payload?count=50&tiny_chunks=true")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if len(resp.TransferEncoding) == 0 || resp.TransferEncoding[0] != "chunked" {
+		t.Errorf("Expected a chunked response, got %v", resp.TransferEncoding)
+	}
+	var items []Item
+	if err := json.NewDecoder(resp.Body).Decode(&items); err != nil {
+		t.Fatalf("Failed to decode the reassembled body: %v", err)
+	}
+	if len(items) != 50 || items[49].ID != 50 {
+		t.Errorf("Expected 50 complete items, got %d", len(items))
+	}
+}
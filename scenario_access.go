@@ -0,0 +1,101 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Scenario access configuration, comma separated scenario types
+var (
+	paramAllowScenarios = flag.String("allow-scenarios", "", "Comma separated scenario types requests may use (default: all)")
+	paramDenyScenarios  = flag.String("deny-scenarios", "", "Comma separated scenario types requests may not use, e.g. network_issues on a shared demo server")
+)
+
+// scenarioAccess restricts the scenarios requests may use, nil allows all
+var scenarioAccess *ScenarioAccess
+
+// errScenarioNotAllowed is returned for requests naming a denied scenario
+var errScenarioNotAllowed = errors.New("scenario is not allowed on this server")
+
+// ScenarioAccess decides which scenario types may be used. A scenario is
+// allowed if it is not denied and, when an allow list is set, listed there.
+type ScenarioAccess struct {
+	allow map[string]bool
+	deny  map[string]bool
+}
+
+// NewScenarioAccess creates the access rules from comma separated allow and
+// deny lists. An empty allow list allows every scenario that is not denied.
+func NewScenarioAccess(allow, deny string) *ScenarioAccess {
+	return &ScenarioAccess{
+		allow: parseScenarioList(allow),
+		deny:  parseScenarioList(deny),
+	}
+}
+
+// parseScenarioList splits a comma separated list of scenario types into a set
+func parseScenarioList(list string) map[string]bool {
+	set := make(map[string]bool)
+	for _, entry := range strings.Split(list, ",") {
+		if entry = strings.ToLower(strings.TrimSpace(entry)); entry != "" {
+			set[entry] = true
+		}
+	}
+	return set
+}
+
+// Allowed reports whether requests may use the scenario type. Requests
+// without a scenario are always allowed.
+func (a *ScenarioAccess) Allowed(scenarioType string) bool {
+	if a == nil || scenarioType == "" {
+		return true
+	}
+	if a.deny[scenarioType] {
+		return false
+	}
+	return len(a.allow) == 0 || a.allow[scenarioType]
+}
+
+// checkScenarioAccess returns errScenarioNotAllowed if the scenario
+// parameter names a disallowed scenario. Every candidate of a random
+// selection must be allowed, so a request never fails only by chance, and
+// so must every layer of a layered selection.
+func checkScenarioAccess(scenario string) error {
+	candidates := []string{scenario}
+	if spec, isRandom := strings.CutPrefix(scenario, randomScenarioPrefix); isRandom {
+		choices, err := parseWeightedScenarios(spec)
+		if err != nil {
+			return err
+		}
+		candidates = candidates[:0]
+		for _, choice := range choices {
+			candidates = append(candidates, choice.scenario)
+		}
+	} else if strings.Contains(scenario, layeredScenarioSeparator) {
+		layers, err := parseLayeredScenarios(scenario)
+		if err != nil {
+			return err
+		}
+		candidates = layers
+	}
+
+	for _, candidate := range candidates {
+		if !scenarioAccess.Allowed(candidate) {
+			return fmt.Errorf("%w: %s", errScenarioNotAllowed, candidate)
+		}
+	}
+	return nil
+}
+
+// writeScenarioParamError answers a request with an invalid scenario
+// parameter: 403 Forbidden for disallowed scenarios, 400 otherwise
+func writeScenarioParamError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, errScenarioNotAllowed) {
+		writeErrorResponse(w, r, http.StatusForbidden, errCodeForbidden, err.Error())
+		return
+	}
+	writeErrorResponse(w, r, http.StatusBadRequest, errCodeBadRequest, err.Error())
+}
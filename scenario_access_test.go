@@ -0,0 +1,76 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestScenarioAccessDeny(t *testing.T) {
+	originalAuth := *enableAuth
+	*enableAuth = false
+	defer func() { *enableAuth = originalAuth }()
+
+	originalAccess := scenarioAccess
+	scenarioAccess = NewScenarioAccess("", "network_issues")
+	defer func() { scenarioAccess = originalAccess }()
+
+	tests := []struct {
+		name         string
+		handler      http.HandlerFunc
+		target       string
+		header       string
+		expectedCode int
+	}{
+		{"denied stream", StreamingPayloadHandler, "/stream_payload?count=1&scenario=network_issues", "", http.StatusForbidden},
+		{"denied paginated", PaginatedPayloadHandler, "/paginated_payload?limit=1&scenario=network_issues", "", http.StatusForbidden},
+		{"denied via header", PaginatedPayloadHandler, "/paginated_payload?limit=1", "Network_Issues", http.StatusForbidden},
+		{"denied in random", PaginatedPayloadHandler, "/paginated_payload?limit=1&scenario=random:peak_hours,network_issues", "", http.StatusForbidden},
+		{"allowed scenario", PaginatedPayloadHandler, "/paginated_payload?limit=1&total=1&scenario=custom", "", http.StatusOK},
+		{"no scenario", PaginatedPayloadHandler, "/paginated_payload?limit=1&total=1", "", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.target, nil)
+			if tt.header != "" {
+				req.Header.Set("X-Scenario", tt.header)
+			}
+			w := httptest.NewRecorder()
+			tt.handler(w, req)
+			if w.Code != tt.expectedCode {
+				t.Errorf("Expected status %d, got %d", tt.expectedCode, w.Code)
+			}
+		})
+	}
+}
+
+func TestScenarioAccessAllowList(t *testing.T) {
+	access := NewScenarioAccess("peak_hours, maintenance", "maintenance")
+	expected := map[string]bool{
+		"peak_hours":     true,
+		"maintenance":    false, // deny wins over allow
+		"network_issues": false,
+		"":               true,
+	}
+	for scenario, want := range expected {
+		if got := access.Allowed(scenario); got != want {
+			t.Errorf("Allowed(%q) = %v, expected %v", scenario, got, want)
+		}
+	}
+
+	// The scenario listing only contains allowed scenarios
+	originalAccess := scenarioAccess
+	scenarioAccess = access
+	defer func() { scenarioAccess = originalAccess }()
+
+	sm := &ScenarioManager{
+		scenarios: make(map[string]*Scenario),
+		validator: NewScenarioValidator(),
+	}
+	sm.loadEmbeddedScenarios()
+	if listed := sm.ListScenarios(); !slices.Equal(listed, []string{"peak_hours"}) {
+		t.Errorf("Expected only peak_hours to be listed, got %v", listed)
+	}
+}
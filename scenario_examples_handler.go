@@ -0,0 +1,98 @@
+package main
+
+import (
+	"archive/zip"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// scenarioExamplesFilename is the file name suggested for the example bundle
+const scenarioExamplesFilename = "payloadbuddy-scenarios.zip"
+
+// ScenarioExamplesPlugin implements PayloadPlugin for downloading the
+// built-in scenarios as editable examples
+type ScenarioExamplesPlugin struct{}
+
+// Path returns the HTTP path for the scenario examples endpoint
+func (p ScenarioExamplesPlugin) Path() string {
+	return "/scenarios/examples.zip"
+}
+
+// Handler returns the handler function for the scenario examples endpoint
+func (p ScenarioExamplesPlugin) Handler() http.HandlerFunc {
+	return ScenarioExamplesHandler
+}
+
+func init() {
+	registerPlugin(ScenarioExamplesPlugin{})
+}
+
+// writeScenarioExamples writes the embedded scenarios and the scenario schema
+// to zw, all at the top level so the archive can be unpacked straight into
+// the user scenario directory
+func writeScenarioExamples(zw *zip.Writer) error {
+	entries, err := embeddedScenarios.ReadDir("scenarios")
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		content, err := embeddedScenarios.ReadFile(path.Join("scenarios", entry.Name()))
+		if err != nil {
+			return err
+		}
+		f, err := zw.Create(entry.Name())
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(content); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// ScenarioExamplesHandler streams a zip archive of the built-in scenarios and
+// the scenario schema, as a starting point for custom scenarios. Unpacked
+// into the user scenario directory, the examples override the built-in
+// scenarios of the same type once edited; the schema file is not loaded as a
+// scenario.
+func ScenarioExamplesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+scenarioExamplesFilename+`"`)
+
+	// The archive is streamed, so a failure after the first file can only
+	// cut the response short
+	_ = writeScenarioExamples(zip.NewWriter(w))
+}
+
+// OpenAPISpec returns the OpenAPI specification for the scenario examples endpoint
+func (p ScenarioExamplesPlugin) OpenAPISpec() OpenAPIPathSpec {
+	return OpenAPIPathSpec{
+		Path: "/scenarios/examples.zip",
+		Operation: OpenAPIPath{
+			Get: &OpenAPIOperation{
+				OperationID: "getScenarioExamples",
+				Summary:     "Download example scenarios",
+				Description: "Returns a zip archive of the built-in scenarios and the scenario JSON schema, to edit and unpack into the user scenario directory ($HOME/.config/payloadBuddy/scenarios)",
+				Tags:        []string{"documentation"},
+				Responses: map[string]OpenAPIResponse{
+					"200": {
+						Description: "Zip archive with one JSON file per built-in scenario and " + scenarioSchemaFile,
+						Content: map[string]OpenAPIMediaType{
+							"application/zip": {
+								Schema: &OpenAPISchema{
+									Type:   "string",
+									Format: "binary",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
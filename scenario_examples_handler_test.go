@@ -0,0 +1,79 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestScenarioExamplesHandler(t *testing.T) {
+	w := httptest.NewRecorder()
+	ScenarioExamplesHandler(w, httptest.NewRequest("GET", "/scenarios/examples.zip", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/zip" {
+		t.Errorf("expected Content-Type application/zip, got %q", ct)
+	}
+	if cd := w.Header().Get("Content-Disposition"); cd != `attachment; filename="payloadbuddy-scenarios.zip"` {
+		t.Errorf("unexpected Content-Disposition %q", cd)
+	}
+
+	body := w.Body.Bytes()
+	zr, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		t.Fatalf("response is not a zip archive: %v", err)
+	}
+
+	files := make(map[string][]byte)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("failed to open %s: %v", f.Name, err)
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", f.Name, err)
+		}
+		files[f.Name] = content
+	}
+
+	for _, name := range []string{"peak_hours.json", "maintenance.json", "network-issues.json", "database-load.json", scenarioSchemaFile} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("expected %s in the archive", name)
+		}
+	}
+
+	// Every example is a valid scenario as downloaded
+	validator := NewScenarioValidator()
+	for name, content := range files {
+		if name == scenarioSchemaFile {
+			continue
+		}
+		if _, err := validator.ValidateJSON(content); err != nil {
+			t.Errorf("%s does not validate: %v", name, err)
+		}
+	}
+
+	// Unpacked into the user directory, everything loads without problems
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), content, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sm := &ScenarioManager{scenarios: make(map[string]*Scenario), userPath: dir, validator: validator}
+	if err := sm.loadUserScenarios(); err != nil {
+		t.Errorf("expected the unpacked examples to load, got %v", err)
+	}
+	if sm.GetScenario("peak_hours") == nil {
+		t.Error("expected peak_hours to load from the examples")
+	}
+}
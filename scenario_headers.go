@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// responseHeadersKey is the simulation_config key holding custom response
+// headers, e.g. {"X-RateLimit-Remaining": "42"}
+const responseHeadersKey = "response_headers"
+
+// reservedResponseHeaders may not be set by scenarios because they control
+// how the response body is framed
+var reservedResponseHeaders = []string{"Content-Length", "Transfer-Encoding", "Connection"}
+
+// parseResponseHeaders validates a response_headers value, which must map
+// valid HTTP header names to string values
+func parseResponseHeaders(value interface{}) (map[string]string, error) {
+	raw, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s must be an object of header names to values", responseHeadersKey)
+	}
+
+	headers := make(map[string]string, len(raw))
+	for name, v := range raw {
+		if !isValidHeaderName(name) {
+			return nil, fmt.Errorf("%s contains invalid header name %q", responseHeadersKey, name)
+		}
+		for _, reserved := range reservedResponseHeaders {
+			if strings.EqualFold(name, reserved) {
+				return nil, fmt.Errorf("%s may not set %s", responseHeadersKey, reserved)
+			}
+		}
+
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s value for %s must be a string", responseHeadersKey, name)
+		}
+		if strings.ContainsAny(s, "\r\n") {
+			return nil, fmt.Errorf("%s value for %s must not contain line breaks", responseHeadersKey, name)
+		}
+		headers[name] = s
+	}
+	return headers, nil
+}
+
+// isValidHeaderName reports whether name is a non-empty RFC 9110 token
+func isValidHeaderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range name {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case strings.ContainsRune("!#$%&'*+-.^_`|~", c):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// GetResponseHeaders returns the custom response headers configured through
+// scenario_parameters.simulation_config.response_headers, or nil if the
+// scenario does not define any
+func (sm *ScenarioManager) GetResponseHeaders(scenarioType string) map[string]string {
+	scenario := sm.GetScenario(scenarioType)
+	if scenario == nil || scenario.ScenarioParams == nil {
+		return nil
+	}
+	value, ok := scenario.ScenarioParams.SimulationConfig[responseHeadersKey]
+	if !ok {
+		return nil
+	}
+	headers, err := parseResponseHeaders(value)
+	if err != nil {
+		return nil
+	}
+	return headers
+}
+
+// applyScenarioHeaders sets the scenario's custom response headers
+func applyScenarioHeaders(w http.ResponseWriter, scenario string) {
+	if scenarioManager == nil || scenario == "" {
+		return
+	}
+	for name, value := range scenarioManager.GetResponseHeaders(scenario) {
+		w.Header().Set(name, value)
+	}
+}
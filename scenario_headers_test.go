@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestScenarioResponseHeaders(t *testing.T) {
+	originalAuth := *enableAuth
+	*enableAuth = false
+	defer func() { *enableAuth = originalAuth }()
+
+	originalManager := scenarioManager
+	defer func() { scenarioManager = originalManager }()
+
+	tempDir := t.TempDir()
+	scenarioJSON := []byte(`{
+		"schema_version": "1.0.0",
+		"scenario_name": "Header Backend",
+		"scenario_type": "custom",
+		"base_delay": "1ms",
+		"scenario_parameters": {
+			"simulation_config": {
+				"response_headers": {"X-Foo": "bar", "X-RateLimit-Remaining": "42"}
+			}
+		}
+	}`)
+	if err := os.WriteFile(filepath.Join(tempDir, "headers.json"), scenarioJSON, 0644); err != nil {
+		t.Fatalf("Failed to write test scenario file: %v", err)
+	}
+
+	scenarioManager = &ScenarioManager{
+		scenarios: make(map[string]*Scenario),
+		userPath:  tempDir,
+		validator: NewScenarioValidator(),
+	}
+	scenarioManager.loadUserScenarios()
+
+	w := httptest.NewRecorder()
+	PaginatedPayloadHandler(w, httptest.NewRequest("GET", "/paginated_payload?scenario=custom&limit=1", nil))
+	if got := w.Header().Get("X-Foo"); got != "bar" {
+		t.Errorf("Expected X-Foo: bar on /paginated_payload, got %q", got)
+	}
+	if got := w.Header().Get("X-RateLimit-Remaining"); got != "42" {
+		t.Errorf("Expected X-RateLimit-Remaining: 42 on /paginated_payload, got %q", got)
+	}
+
+	w = httptest.NewRecorder()
+	StreamingPayloadHandler(w, httptest.NewRequest("GET", "/stream_payload?scenario=custom&count=1", nil))
+	if got := w.Header().Get("X-Foo"); got != "bar" {
+		t.Errorf("Expected X-Foo: bar on /stream_payload, got %q", got)
+	}
+
+	// Requests without the scenario do not get the headers
+	w = httptest.NewRecorder()
+	PaginatedPayloadHandler(w, httptest.NewRequest("GET", "/paginated_payload?limit=1", nil))
+	if got := w.Header().Get("X-Foo"); got != "" {
+		t.Errorf("Expected no X-Foo without scenario, got %q", got)
+	}
+}
+
+func TestResponseHeadersValidation(t *testing.T) {
+	validator := NewScenarioValidator()
+
+	tests := []struct {
+		name        string
+		headers     string
+		expectError bool
+	}{
+		{"valid", `{"X-ServiceNow-Transaction": "abc123"}`, false},
+		{"empty", `{}`, false},
+		{"not an object", `"X-Foo: bar"`, true},
+		{"space in name", `{"X Foo": "bar"}`, true},
+		{"colon in name", `{"X-Foo:": "bar"}`, true},
+		{"empty name", `{"": "bar"}`, true},
+		{"non-string value", `{"X-Foo": 1}`, true},
+		{"line break in value", `{"X-Foo": "bar\r\nX-Injected: 1"}`, true},
+		{"reserved header", `{"content-length": "10"}`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var headers interface{}
+			if err := json.Unmarshal([]byte(tt.headers), &headers); err != nil {
+				t.Fatalf("Invalid test input: %v", err)
+			}
+
+			scenario := &Scenario{
+				ScenarioName: "Header Test",
+				ScenarioType: "custom",
+				BaseDelay:    "100ms",
+				ScenarioParams: &ScenarioParameters{
+					SimulationConfig: map[string]interface{}{responseHeadersKey: headers},
+				},
+			}
+
+			err := validator.ValidateScenario(scenario)
+			if tt.expectError && err == nil {
+				t.Error("Expected validation error")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Unexpected validation error: %v", err)
+			}
+		})
+	}
+}
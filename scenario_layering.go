@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// layeredScenarioSeparator separates the scenarios of a layered selection
+// such as "peak_hours,error_storm"
+const layeredScenarioSeparator = ","
+
+// maxLayeredScenarios is the number of merged scenarios cached, above which
+// an arbitrary one is dropped, since selections are chosen by clients
+const maxLayeredScenarios = 256
+
+// parseLayeredScenarios splits a layered selection into its scenario names.
+// A selection without a separator is a single scenario.
+func parseLayeredScenarios(spec string) ([]string, error) {
+	var names []string
+	for _, name := range strings.Split(spec, layeredScenarioSeparator) {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return nil, fmt.Errorf("scenario list contains an empty scenario name")
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// layerScenarios merges scenarios into a single one, in order of precedence:
+//
+//   - The first scenario supplies everything that is not merged: its
+//     scenario_type, which selects the built-in delay behavior, base delay,
+//     delay strategy, delay overrides, timing patterns, batch and page sizes,
+//     ServiceNow mode and response limits.
+//   - error_injection comes from the first scenario that enables it.
+//   - simulation_config keys are merged; if several scenarios set the same
+//     key, the earlier scenario wins.
+func layerScenarios(layers []*Scenario) *Scenario {
+	merged := *layers[0]
+
+	names := make([]string, len(layers))
+	for i, layer := range layers {
+		names[i] = layer.ScenarioName
+	}
+	merged.ScenarioName = strings.Join(names, " + ")
+
+	merged.ErrorInjection = nil
+	for _, layer := range layers {
+		if layer.ErrorInjection != nil && layer.ErrorInjection.Enabled {
+			merged.ErrorInjection = layer.ErrorInjection
+			break
+		}
+	}
+
+	config := make(map[string]interface{})
+	for i := len(layers) - 1; i >= 0; i-- {
+		if layers[i].ScenarioParams == nil {
+			continue
+		}
+		for key, value := range layers[i].ScenarioParams.SimulationConfig {
+			config[key] = value
+		}
+	}
+	params := ScenarioParameters{SimulationConfig: config}
+	if first := layers[0].ScenarioParams; first != nil {
+		params.DelayOverrides = first.DelayOverrides
+		params.TimingPatterns = first.TimingPatterns
+	}
+	merged.ScenarioParams = &params
+	merged.prepare()
+	return &merged
+}
+
+// layeredScenario returns the merged scenario of a layered selection,
+// building it on first use. It is nil if any of the layers is unknown, so
+// like an unknown single scenario the selection has no effect. Layered
+// selections keep their own error injection, concurrency and drift state,
+// separate from that of their layers, since state is keyed by the selection
+// rather than the merged scenario's scenario_type.
+func (sm *ScenarioManager) layeredScenario(scenarioType string) *Scenario {
+	sm.layeredMu.Lock()
+	defer sm.layeredMu.Unlock()
+	if scenario, exists := sm.layered[scenarioType]; exists {
+		return scenario
+	}
+
+	names, err := parseLayeredScenarios(scenarioType)
+	if err != nil {
+		return nil
+	}
+	layers := make([]*Scenario, len(names))
+	for i, name := range names {
+		if layers[i] = sm.scenarios[name]; layers[i] == nil {
+			return nil
+		}
+	}
+
+	scenario := layerScenarios(layers)
+	if sm.layered == nil {
+		sm.layered = make(map[string]*Scenario)
+	}
+	if len(sm.layered) >= maxLayeredScenarios {
+		for other := range sm.layered {
+			delete(sm.layered, other)
+			break
+		}
+	}
+	sm.layered[scenarioType] = scenario
+	return scenario
+}
+
+// ResetLayeredScenarios forgets the merged scenarios, so they are rebuilt
+// from the current scenarios on next use
+func (sm *ScenarioManager) ResetLayeredScenarios() {
+	sm.layeredMu.Lock()
+	defer sm.layeredMu.Unlock()
+	sm.layered = nil
+}
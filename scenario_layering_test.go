@@ -0,0 +1,167 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestLayeredScenarios combines a slow scenario with response headers and a
+// failing scenario and checks that the effects of both apply
+func TestLayeredScenarios(t *testing.T) {
+	originalAuth := *enableAuth
+	*enableAuth = false
+	defer func() { *enableAuth = originalAuth }()
+
+	originalManager := scenarioManager
+	defer func() { scenarioManager = originalManager }()
+
+	scenarioManager = &ScenarioManager{
+		scenarios: map[string]*Scenario{
+			"slow": {
+				ScenarioName: "Slow",
+				ScenarioType: "slow",
+				BaseDelay:    "20ms",
+				ScenarioParams: &ScenarioParameters{
+					SimulationConfig: map[string]interface{}{
+						responseHeadersKey: map[string]interface{}{"X-Layer": "slow"},
+					},
+				},
+			},
+			"failing": {
+				ScenarioName: "Failing",
+				ScenarioType: "failing",
+				BaseDelay:    "1ms",
+				ErrorInjection: &ErrorInjectionConfig{
+					Enabled:    true,
+					ErrorRate:  1.0,
+					ErrorTypes: []string{"server_error"},
+				},
+				ScenarioParams: &ScenarioParameters{
+					SimulationConfig: map[string]interface{}{
+						responseHeadersKey: map[string]interface{}{"X-Layer": "failing"},
+						maxConcurrentKey:   float64(3),
+					},
+				},
+			},
+		},
+		validator: NewScenarioValidator(),
+	}
+
+	delay, _ := scenarioManager.GetScenarioDelay("slow,failing", 0)
+	if delay != 20*time.Millisecond {
+		t.Errorf("Expected the 20ms delay of the first scenario, got %v", delay)
+	}
+	if got := scenarioManager.GetMaxConcurrent("slow,failing"); got != 3 {
+		t.Errorf("Expected max_concurrent 3 merged from the second scenario, got %d", got)
+	}
+
+	w := httptest.NewRecorder()
+	PaginatedPayloadHandler(w, httptest.NewRequest("GET", "/paginated_payload?scenario=slow,%20failing&limit=1", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected the injected 500 of the failing scenario, got %d", w.Code)
+	}
+	if got := w.Header().Get("X-Layer"); got != "slow" {
+		t.Errorf("Expected X-Layer from the first scenario, got %q", got)
+	}
+
+	// The order of the list decides precedence
+	delay, _ = scenarioManager.GetScenarioDelay("failing,slow", 0)
+	if delay != time.Millisecond {
+		t.Errorf("Expected the 1ms delay of failing listed first, got %v", delay)
+	}
+
+	// Unknown layers make the selection a no-op
+	if scenarioManager.GetScenario("slow,unknown") != nil {
+		t.Error("Expected no scenario for a selection with an unknown layer")
+	}
+}
+
+func TestLayeredScenarioParam(t *testing.T) {
+	tests := []struct {
+		scenario    string
+		expected    string
+		expectError bool
+	}{
+		{"peak_hours,error_storm", "peak_hours,error_storm", false},
+		{"Peak_Hours , error_storm", "peak_hours,error_storm", false},
+		{"peak_hours,,error_storm", "", true},
+		{"peak_hours,", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.scenario, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/stream_payload", nil)
+			req.Header.Set("X-Scenario", tt.scenario)
+			got, err := getScenarioParam(req)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error, got scenario %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestLayeredBuiltInScenarios checks that layered selections keep the
+// built-in delay behavior of their first scenario
+func TestLayeredBuiltInScenarios(t *testing.T) {
+	sm := &ScenarioManager{scenarios: make(map[string]*Scenario), validator: NewScenarioValidator()}
+	sm.loadEmbeddedScenarios()
+
+	for _, test := range []struct {
+		scenario  string
+		itemIndex int
+	}{
+		{"maintenance", 0},
+		{"maintenance", 1},
+		{"database_load", 1000},
+		{"recovery", 0},
+		{"peak_hours", 5},
+	} {
+		want, wantStrategy := sm.GetScenarioDelay(test.scenario, test.itemIndex)
+		got, gotStrategy := sm.GetScenarioDelay(test.scenario+",error_storm", test.itemIndex)
+		if got != want || gotStrategy != wantStrategy {
+			t.Errorf("%s,error_storm item %d: expected %v (%v) like %s alone, got %v (%v)", test.scenario, test.itemIndex, want, wantStrategy, test.scenario, got, gotStrategy)
+		}
+	}
+	if got := sm.GetScenarioType("network_issues,error_storm"); got != "network_issues" {
+		t.Errorf("Expected the network_issues type of the first layer, got %q", got)
+	}
+}
+
+// TestLayeredScenarioCache checks that the cache of merged scenarios is
+// bounded and rebuilt when scenarios are reloaded
+func TestLayeredScenarioCache(t *testing.T) {
+	sm := &ScenarioManager{
+		scenarios: map[string]*Scenario{
+			"a": {ScenarioType: "a", BaseDelay: "1ms"},
+			"b": {ScenarioType: "b", BaseDelay: "2ms"},
+		},
+		validator: NewScenarioValidator(),
+	}
+	for i := 0; i < maxLayeredScenarios+10; i++ {
+		sm.GetScenario("a" + strings.Repeat(",b", i+1))
+	}
+	if len(sm.layered) > maxLayeredScenarios {
+		t.Errorf("Expected at most %d cached scenarios, got %d", maxLayeredScenarios, len(sm.layered))
+	}
+
+	if sm.GetScenario("a,b").BaseDelay != "1ms" {
+		t.Fatal("Expected the base delay of a")
+	}
+	sm.scenarios["a"] = &Scenario{ScenarioType: "a", BaseDelay: "5ms"}
+	sm.ResetLayeredScenarios()
+	if got := sm.GetScenario("a,b").BaseDelay; got != "5ms" {
+		t.Errorf("Expected the reloaded base delay 5ms, got %s", got)
+	}
+}
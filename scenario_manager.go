@@ -1,15 +1,22 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	"embed"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"io/fs"
 	"log"
+	"net/http"
 	"os"
 	"path/filepath"
 	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -18,6 +25,10 @@ import (
 //go:embed scenarios/*.json
 var embeddedScenarios embed.FS
 
+// scenarioSchemaFile is the JSON schema next to the embedded scenarios. It is
+// not a scenario and is skipped when loading.
+const scenarioSchemaFile = "scenario_schema_v1.0.0.json"
+
 // Scenario represents a complete scenario configuration
 type Scenario struct {
 	SchemaVersion    string                `json:"schema_version"`
@@ -28,12 +39,44 @@ type Scenario struct {
 	DelayStrategy    string                `json:"delay_strategy,omitempty"`
 	ServiceNowMode   bool                  `json:"servicenow_mode,omitempty"`
 	BatchSize        int                   `json:"batch_size,omitempty"`
+	StreamBatchSize  int                   `json:"stream_batch_size,omitempty"` // Overrides BatchSize on /stream_payload
+	PageSize         int                   `json:"page_size,omitempty"`         // Overrides BatchSize on /paginated_payload
 	ResponseLimits   *ResponseLimits       `json:"response_limits,omitempty"`
 	ScenarioParams   *ScenarioParameters   `json:"scenario_parameters,omitempty"`
 	ServiceNowConfig *ServiceNowConfig     `json:"servicenow_config,omitempty"`
 	ErrorInjection   *ErrorInjectionConfig `json:"error_injection,omitempty"`
 	PerfMonitoring   *PerformanceConfig    `json:"performance_monitoring,omitempty"`
 	Metadata         *ScenarioMetadata     `json:"metadata,omitempty"`
+
+	// latency is the parsed latency_percentiles, set by prepare so the
+	// per-item delay does not parse them again
+	latency  *LatencyDistribution
+	prepared bool
+}
+
+// prepare parses the settings needed on hot paths once, when the scenario
+// is loaded. The scenario must not be modified afterwards.
+func (s *Scenario) prepare() {
+	s.latency = s.parseLatencyDistribution()
+	s.prepared = true
+}
+
+// parseLatencyDistribution parses scenario_parameters.simulation_config
+// .latency_percentiles, or returns nil if the scenario does not define valid
+// percentile targets
+func (s *Scenario) parseLatencyDistribution() *LatencyDistribution {
+	if s.ScenarioParams == nil {
+		return nil
+	}
+	value, ok := s.ScenarioParams.SimulationConfig[latencyPercentilesKey]
+	if !ok {
+		return nil
+	}
+	dist, err := parseLatencyPercentiles(value)
+	if err != nil {
+		return nil
+	}
+	return dist
 }
 
 // ResponseLimits defines response count limits
@@ -105,10 +148,51 @@ type ScenarioManager struct {
 	scenarios map[string]*Scenario
 	userPath  string
 	validator *ScenarioValidator
+
+	// slots holds the per-scenario semaphores enforcing max_concurrent
+	slotsMu sync.Mutex
+	slots   map[string]chan struct{}
+
+	// errorStates tracks error injection per scenario
+	errorsMu    sync.Mutex
+	errorStates map[string]*errorInjectionState
+
+	// driftRequests counts requests per scenario as the pagination drift clock
+	driftMu       sync.Mutex
+	driftRequests map[string]int
+
+	// shrinkRequests counts requests per scenario as the pagination shrink
+	// clock
+	shrinkMu       sync.Mutex
+	shrinkRequests map[string]int
+
+	// tokenSessions tracks credentials per scenario and client for
+	// token_expiry_after
+	tokensMu      sync.Mutex
+	tokenSessions map[string]*tokenSession
+
+	// layered caches the merged scenarios of layered selections
+	layeredMu sync.Mutex
+	layered   map[string]*Scenario
 }
 
-// NewScenarioManager creates a new scenario manager
+// NewScenarioManager creates a new scenario manager. User scenarios that
+// cannot be loaded are logged and skipped.
 func NewScenarioManager() *ScenarioManager {
+	sm, _ := newScenarioManager()
+	return sm
+}
+
+// NewStrictScenarioManager creates a new scenario manager like
+// NewScenarioManager, but returns an error if any user scenario fails to
+// load, validate or pass the compatibility check
+func NewStrictScenarioManager() (*ScenarioManager, error) {
+	return newScenarioManager()
+}
+
+// newScenarioManager loads the embedded and user scenarios and returns the
+// manager together with all user scenario load errors
+func newScenarioManager() (*ScenarioManager, error) {
 	sm := &ScenarioManager{
 		scenarios: make(map[string]*Scenario),
 		userPath:  getScenarioPath(),
@@ -117,9 +201,9 @@ func NewScenarioManager() *ScenarioManager {
 
 	// Load scenarios in order: embedded first, then user scenarios
 	sm.loadEmbeddedScenarios()
-	sm.loadUserScenarios()
+	err := sm.loadUserScenarios()
 
-	return sm
+	return sm, err
 }
 
 // getScenarioPath returns the user scenario directory path
@@ -155,7 +239,7 @@ func (sm *ScenarioManager) loadEmbeddedScenarios() {
 	}
 
 	for _, entry := range entries {
-		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") && entry.Name() != "scenario_schema_v1.0.0.json" {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") && entry.Name() != scenarioSchemaFile {
 			content, err := embeddedScenarios.ReadFile(filepath.Join("scenarios", entry.Name()))
 			if err != nil {
 				log.Printf("Warning: Failed to read embedded scenario %s: %v", entry.Name(), err)
@@ -174,69 +258,203 @@ func (sm *ScenarioManager) loadEmbeddedScenarios() {
 				log.Printf("Warning: Embedded scenario %s is not compatible with current version", scenario.ScenarioName)
 				continue
 			}
+			if err := sm.checkTestedVersions(scenario); err != nil {
+				log.Printf("Warning: Embedded scenario %s is %v", scenario.ScenarioName, err)
+			}
 
+			sm.logScenarioWarnings(scenario)
+			scenario.prepare()
 			sm.scenarios[scenario.ScenarioType] = scenario
 			log.Printf("Loaded embedded scenario: %s (%s)", scenario.ScenarioName, scenario.ScenarioType)
 		}
 	}
+	sm.ResetLayeredScenarios()
 }
 
 // loadUserScenarios loads user-defined scenarios from the config directory
-func (sm *ScenarioManager) loadUserScenarios() {
+func (sm *ScenarioManager) loadUserScenarios() error {
 	if _, err := os.Stat(sm.userPath); os.IsNotExist(err) {
 		// Directory doesn't exist, nothing to load
-		return
+		return nil
 	}
 
+	// Skipped files are collected for strict mode
+	var problems []error
 	err := filepath.WalkDir(sm.userPath, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 
-		if !d.IsDir() && strings.HasSuffix(path, ".json") {
+		if !d.IsDir() && d.Name() != scenarioSchemaFile && (strings.HasSuffix(path, ".json") || strings.HasSuffix(path, ".jsonc")) {
 			// Validate path is within userPath to prevent directory traversal
 			cleanPath := filepath.Clean(path)
 			userPathAbs, _ := filepath.Abs(sm.userPath)
 			pathAbs, _ := filepath.Abs(cleanPath)
 			if !strings.HasPrefix(pathAbs, userPathAbs) {
 				log.Printf("Warning: Skipping file outside user directory: %s", path)
+				problems = append(problems, fmt.Errorf("%s: outside the user scenario directory", path))
 				return nil
 			}
 
 			content, err := os.ReadFile(cleanPath)
 			if err != nil {
 				log.Printf("Warning: Failed to read user scenario %s: %v", cleanPath, err)
+				problems = append(problems, fmt.Errorf("%s: %v", cleanPath, err))
 				return nil // Continue with next file
 			}
 
-			// Validate and parse scenario
-			scenario, err := sm.validator.ValidateJSON(content)
+			// Validate and parse scenario, with ${VAR} references expanded
+			scenario, err := sm.validator.ValidateJSON(expandScenarioEnv(content))
 			if err != nil {
 				log.Printf("Warning: Validation failed for user scenario %s: %v", path, err)
+				problems = append(problems, fmt.Errorf("%s: validation failed: %v", path, err))
 				return nil // Continue with next file
 			}
 
 			// Validate compatibility
 			if !sm.isCompatible(scenario) {
 				log.Printf("Warning: User scenario %s is not compatible with current version", scenario.ScenarioName)
+				problems = append(problems, fmt.Errorf("%s: scenario %s is not compatible with the current version", path, scenario.ScenarioName))
 				return nil
 			}
 
+			// Scenarios outside their tested versions load, but fail strict mode
+			if err := sm.checkTestedVersions(scenario); err != nil {
+				log.Printf("Warning: User scenario %s is %v", scenario.ScenarioName, err)
+				problems = append(problems, fmt.Errorf("%s: scenario %s is %v", path, scenario.ScenarioName, err))
+			}
+
 			// User scenarios override embedded ones with same scenario_type
 			if existing, exists := sm.scenarios[scenario.ScenarioType]; exists {
 				log.Printf("User scenario %s (%s) overriding embedded scenario %s",
 					scenario.ScenarioName, scenario.ScenarioType, existing.ScenarioName)
 			}
 
+			sm.logScenarioWarnings(scenario)
+			scenario.prepare()
 			sm.scenarios[scenario.ScenarioType] = scenario
 			log.Printf("Loaded user scenario: %s (%s)", scenario.ScenarioName, scenario.ScenarioType)
 		}
 
 		return nil
 	})
+	sm.ResetLayeredScenarios()
 
 	if err != nil {
 		log.Printf("Warning: Error scanning user scenarios: %v", err)
+		problems = append(problems, fmt.Errorf("scanning %s: %v", sm.userPath, err))
+	}
+	return errors.Join(problems...)
+}
+
+// maxRemoteScenarioBytes limits the size of a remote scenario document
+const maxRemoteScenarioBytes = 1 << 20
+
+// LoadRemoteScenarios fetches a scenario, or a JSON array of scenarios, from
+// the given http(s) URL and registers every valid one. Remote scenarios are
+// loaded after embedded and user scenarios and override them by scenario_type.
+// Fetch and validation failures are logged as warnings; the number of loaded
+// scenarios is returned.
+func (sm *ScenarioManager) LoadRemoteScenarios(url string) int {
+	content, err := fetchRemoteScenarios(url)
+	if err != nil {
+		log.Printf("Warning: Failed to load remote scenarios from %s: %v", url, err)
+		return 0
+	}
+
+	documents, err := splitScenarioDocuments(content)
+	if err != nil {
+		log.Printf("Warning: Failed to parse remote scenarios from %s: %v", url, err)
+		return 0
+	}
+
+	loaded := 0
+	for i, document := range documents {
+		scenario, err := sm.validator.ValidateJSON(document)
+		if err != nil {
+			log.Printf("Warning: Validation failed for remote scenario #%d from %s: %v", i+1, url, err)
+			continue
+		}
+
+		if !sm.isCompatible(scenario) {
+			log.Printf("Warning: Remote scenario %s is not compatible with current version", scenario.ScenarioName)
+			continue
+		}
+		if err := sm.checkTestedVersions(scenario); err != nil {
+			log.Printf("Warning: Remote scenario %s is %v", scenario.ScenarioName, err)
+		}
+
+		sm.logScenarioWarnings(scenario)
+		if existing, exists := sm.scenarios[scenario.ScenarioType]; exists {
+			log.Printf("Remote scenario %s (%s) overriding scenario %s",
+				scenario.ScenarioName, scenario.ScenarioType, existing.ScenarioName)
+		}
+
+		scenario.prepare()
+		sm.scenarios[scenario.ScenarioType] = scenario
+		log.Printf("Loaded remote scenario: %s (%s)", scenario.ScenarioName, scenario.ScenarioType)
+		loaded++
+	}
+
+	sm.ResetLayeredScenarios()
+	return loaded
+}
+
+// fetchRemoteScenarios downloads the raw scenario document from url
+func fetchRemoteScenarios(url string) ([]byte, error) {
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		return nil, fmt.Errorf("unsupported URL scheme, expected http or https")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	return io.ReadAll(io.LimitReader(resp.Body, maxRemoteScenarioBytes))
+}
+
+// expandScenarioEnv replaces ${VAR} and $VAR references in a local scenario
+// file with the values of the environment variables, so one file can behave
+// differently per environment. Unset variables expand to the empty string.
+// Remote scenarios are never expanded: they are not trusted and could echo
+// secrets from the environment back to clients, e.g. via response_headers.
+func expandScenarioEnv(content []byte) []byte {
+	return []byte(os.ExpandEnv(string(content)))
+}
+
+// splitScenarioDocuments returns the individual scenario documents contained
+// in content, which may be a single scenario object or an array of them.
+// Comments are stripped first so JSONC documents are accepted.
+func splitScenarioDocuments(content []byte) ([]json.RawMessage, error) {
+	trimmed := bytes.TrimSpace(stripJSONComments(content))
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		var documents []json.RawMessage
+		if err := json.Unmarshal(trimmed, &documents); err != nil {
+			return nil, err
+		}
+		return documents, nil
+	}
+	return []json.RawMessage{trimmed}, nil
+}
+
+// logScenarioWarnings logs the non-fatal validation issues of a loaded scenario
+func (sm *ScenarioManager) logScenarioWarnings(scenario *Scenario) {
+	for _, warning := range sm.validator.ScenarioWarnings(scenario) {
+		log.Printf("Warning: Scenario %s (%s): %s", scenario.ScenarioName, scenario.ScenarioType, warning)
 	}
 }
 
@@ -258,16 +476,44 @@ func (sm *ScenarioManager) isCompatible(scenario *Scenario) bool {
 	return true
 }
 
-// GetScenario retrieves a scenario by type
+// checkTestedVersions reports a scenario whose tested_versions do not list the
+// running version. Such a scenario still loads, but runs outside the range
+// its author validated. Scenarios without tested_versions and development
+// builds without an x.y.z version always pass.
+func (sm *ScenarioManager) checkTestedVersions(scenario *Scenario) error {
+	if scenario.Metadata == nil || scenario.Metadata.Compatibility == nil {
+		return nil
+	}
+	tested := scenario.Metadata.Compatibility.TestedVersions
+	current := strings.TrimPrefix(Version, "v")
+	if len(tested) == 0 || sm.validator.validateVersionFormat(current) != nil {
+		return nil
+	}
+	for _, version := range tested {
+		if version == current {
+			return nil
+		}
+	}
+	return fmt.Errorf("not tested with payloadBuddy %s (tested_versions: %s)", current, strings.Join(tested, ", "))
+}
+
+// GetScenario retrieves a scenario by type. A layered selection such as
+// "peak_hours,error_storm" yields the merged scenario, see layerScenarios.
 func (sm *ScenarioManager) GetScenario(scenarioType string) *Scenario {
+	if strings.Contains(scenarioType, layeredScenarioSeparator) {
+		return sm.layeredScenario(scenarioType)
+	}
 	return sm.scenarios[scenarioType]
 }
 
-// ListScenarios returns all available scenario types
+// ListScenarios returns all available scenario types that requests are
+// allowed to use
 func (sm *ScenarioManager) ListScenarios() []string {
 	var types []string
 	for scenarioType := range sm.scenarios {
-		types = append(types, scenarioType)
+		if scenarioAccess.Allowed(scenarioType) {
+			types = append(types, scenarioType)
+		}
 	}
 	return types
 }
@@ -303,6 +549,17 @@ func ParseDelayStrategy(strategy string) DelayStrategy {
 	}
 }
 
+// GetScenarioType returns the scenario_type of the scenario, which selects
+// its built-in behavior, or "" if the scenario is unknown. For a layered
+// selection it is the type of the first layer.
+func (sm *ScenarioManager) GetScenarioType(scenarioType string) string {
+	scenario := sm.GetScenario(scenarioType)
+	if scenario == nil {
+		return ""
+	}
+	return scenario.ScenarioType
+}
+
 // GetScenarioDelay calculates delay for a scenario at a specific item index
 func (sm *ScenarioManager) GetScenarioDelay(scenarioType string, itemIndex int) (time.Duration, DelayStrategy) {
 	scenario := sm.GetScenario(scenarioType)
@@ -316,40 +573,138 @@ func (sm *ScenarioManager) GetScenarioDelay(scenarioType string, itemIndex int)
 		baseDelay = 10 * time.Millisecond
 	}
 
-	strategy := ParseDelayStrategy(scenario.DelayStrategy)
-
-	// Apply scenario-specific delay modifications
+	// Apply scenario-specific delay modifications. Built-in scenario types
+	// bring their own default strategy, but a delay_strategy set in the
+	// scenario file always wins.
 	switch scenario.ScenarioType {
 	case "peak_hours":
-		return 200 * time.Millisecond, FixedDelay
+		return 200 * time.Millisecond, scenarioStrategy(scenario, FixedDelay)
 	case "maintenance":
 		if itemIndex%500 == 0 {
-			return 2 * time.Second, FixedDelay // Maintenance spike
+			return 2 * time.Second, scenarioStrategy(scenario, FixedDelay) // Maintenance spike
 		}
-		return 500 * time.Millisecond, FixedDelay
+		return 500 * time.Millisecond, scenarioStrategy(scenario, FixedDelay)
 	case "network_issues":
 		// This will be handled by the caller using random logic
-		return baseDelay, RandomDelay
+		return baseDelay, scenarioStrategy(scenario, RandomDelay)
 	case "database_load":
 		// Progressive degradation: baseDelay + (itemIndex/100 * 10ms)
 		degradation := time.Duration(itemIndex/100) * 10 * time.Millisecond
-		return baseDelay + degradation, FixedDelay
+		return baseDelay + degradation, scenarioStrategy(scenario, FixedDelay)
+	case "recovery":
+		// Gradual recovery: baseDelay + 50ms per 100 items left until item 1000
+		remaining := max(1000-itemIndex, 0)
+		recovery := time.Duration((remaining+99)/100) * 50 * time.Millisecond
+		return baseDelay + recovery, scenarioStrategy(scenario, FixedDelay)
 	default:
-		return baseDelay, strategy
+		return baseDelay, ParseDelayStrategy(scenario.DelayStrategy)
 	}
 }
 
-// GetScenarioConfig returns configuration values for a scenario
-func (sm *ScenarioManager) GetScenarioConfig(scenarioType string) (batchSize int, serviceNowMode bool, maxCount int, defaultCount int) {
+// scenarioStrategy returns the scenario's configured delay strategy, or the
+// given fallback when the scenario does not set one
+func scenarioStrategy(scenario *Scenario, fallback DelayStrategy) DelayStrategy {
+	if scenario.DelayStrategy == "" {
+		return fallback
+	}
+	return ParseDelayStrategy(scenario.DelayStrategy)
+}
+
+// GetLatencyDistribution returns the latency distribution configured through
+// scenario_parameters.simulation_config.latency_percentiles, or nil if the
+// scenario does not define percentile targets. Loaded scenarios have it
+// parsed already; others are parsed on every call.
+func (sm *ScenarioManager) GetLatencyDistribution(scenarioType string) *LatencyDistribution {
+	scenario := sm.GetScenario(scenarioType)
+	if scenario == nil {
+		return nil
+	}
+	if scenario.prepared {
+		return scenario.latency
+	}
+	return scenario.parseLatencyDistribution()
+}
+
+// maxConcurrentKey is the simulation_config key limiting concurrent requests
+const maxConcurrentKey = "max_concurrent"
+
+// parseMaxConcurrent validates a max_concurrent value, which must be a
+// positive whole number
+func parseMaxConcurrent(value interface{}) (int, error) {
+	n, ok := value.(float64)
+	if !ok || n < 1 || n != float64(int(n)) {
+		return 0, fmt.Errorf("%s must be a positive integer", maxConcurrentKey)
+	}
+	return int(n), nil
+}
+
+// GetMaxConcurrent returns the scenario's max_concurrent limit from
+// scenario_parameters.simulation_config, or 0 if it is unlimited
+func (sm *ScenarioManager) GetMaxConcurrent(scenarioType string) int {
+	scenario := sm.GetScenario(scenarioType)
+	if scenario == nil || scenario.ScenarioParams == nil {
+		return 0
+	}
+	value, ok := scenario.ScenarioParams.SimulationConfig[maxConcurrentKey]
+	if !ok {
+		return 0
+	}
+	limit, err := parseMaxConcurrent(value)
+	if err != nil {
+		return 0
+	}
+	return limit
+}
+
+// AcquireScenarioSlot reserves one of the scenario's concurrent request slots.
+// It returns false if the scenario's max_concurrent limit is reached. On
+// success the returned release function must be called when the request ends.
+// Scenarios without a limit always succeed.
+func (sm *ScenarioManager) AcquireScenarioSlot(scenarioType string) (release func(), ok bool) {
+	limit := sm.GetMaxConcurrent(scenarioType)
+	if limit == 0 {
+		return func() {}, true
+	}
+
+	sm.slotsMu.Lock()
+	if sm.slots == nil {
+		sm.slots = make(map[string]chan struct{})
+	}
+	slots, exists := sm.slots[scenarioType]
+	if !exists {
+		slots = make(chan struct{}, limit)
+		sm.slots[scenarioType] = slots
+	}
+	sm.slotsMu.Unlock()
+
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, true
+	default:
+		return nil, false
+	}
+}
+
+// GetScenarioConfig returns configuration values for a scenario. The
+// streaming batch size and the page size fall back to batch_size when the
+// scenario does not set stream_batch_size or page_size.
+func (sm *ScenarioManager) GetScenarioConfig(scenarioType string) (streamBatchSize int, pageSize int, serviceNowMode bool, maxCount int, defaultCount int) {
 	scenario := sm.GetScenario(scenarioType)
 	if scenario == nil {
-		return 100, false, 1000000, 10000 // Default values
+		return 100, 100, false, 1000000, 10000 // Default values
 	}
 
-	batchSize = 100
+	batchSize := 100
 	if scenario.BatchSize > 0 {
 		batchSize = scenario.BatchSize
 	}
+	streamBatchSize, pageSize = batchSize, batchSize
+	if scenario.StreamBatchSize > 0 {
+		streamBatchSize = scenario.StreamBatchSize
+	}
+	if scenario.PageSize > 0 {
+		pageSize = scenario.PageSize
+	}
 
 	serviceNowMode = scenario.ServiceNowMode
 
@@ -2,8 +2,14 @@ package main
 
 import (
 	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 )
@@ -136,14 +142,40 @@ func TestGetScenarioDelay(t *testing.T) {
 	}
 }
 
+// TestRecoveryScenarioDelay checks that the embedded recovery scenario
+// starts slow and ramps down to its base delay
+func TestRecoveryScenarioDelay(t *testing.T) {
+	sm := NewScenarioManager()
+
+	previous, strategy := sm.GetScenarioDelay("recovery", 0)
+	if previous != 510*time.Millisecond {
+		t.Errorf("Expected the first item to wait 510ms, got %v", previous)
+	}
+	if strategy != FixedDelay {
+		t.Errorf("Expected FixedDelay, got %v", strategy)
+	}
+	for _, index := range []int{100, 500, 900} {
+		delay, _ := sm.GetScenarioDelay("recovery", index)
+		if delay >= previous {
+			t.Errorf("Expected item %d to be faster than earlier items, got %v after %v", index, delay, previous)
+		}
+		previous = delay
+	}
+	for _, index := range []int{1000, 5000} {
+		if delay, _ := sm.GetScenarioDelay("recovery", index); delay != 10*time.Millisecond {
+			t.Errorf("Expected item %d to be back at the 10ms base delay, got %v", index, delay)
+		}
+	}
+}
+
 func TestGetScenarioConfig(t *testing.T) {
 	sm := NewScenarioManager()
 
 	// Test peak_hours scenario config
-	batchSize, serviceNowMode, maxCount, defaultCount := sm.GetScenarioConfig("peak_hours")
+	batchSize, pageSize, serviceNowMode, maxCount, defaultCount := sm.GetScenarioConfig("peak_hours")
 
-	if batchSize == 0 {
-		t.Error("Batch size should not be 0")
+	if batchSize == 0 || pageSize == 0 {
+		t.Error("Batch size and page size should not be 0")
 	}
 
 	if maxCount == 0 {
@@ -160,12 +192,70 @@ func TestGetScenarioConfig(t *testing.T) {
 	}
 
 	// Test non-existent scenario
-	batchSize, serviceNowMode, maxCount, defaultCount = sm.GetScenarioConfig("non_existent")
-	if batchSize != 100 || serviceNowMode != false || maxCount != 1000000 || defaultCount != 10000 {
+	batchSize, pageSize, serviceNowMode, maxCount, defaultCount = sm.GetScenarioConfig("non_existent")
+	if batchSize != 100 || pageSize != 100 || serviceNowMode != false || maxCount != 1000000 || defaultCount != 10000 {
 		t.Error("Non-existent scenario should return default values")
 	}
 }
 
+// TestScenarioPageAndBatchSize tests that pagination defaults to the
+// scenario's page_size and streaming to its stream_batch_size
+func TestScenarioPageAndBatchSize(t *testing.T) {
+	originalAuth := *enableAuth
+	*enableAuth = false
+	defer func() { *enableAuth = originalAuth }()
+
+	originalManager := scenarioManager
+	defer func() { scenarioManager = originalManager }()
+
+	scenarioManager = &ScenarioManager{
+		scenarios: map[string]*Scenario{
+			"custom": {
+				ScenarioType:    "custom",
+				BaseDelay:       "0ms",
+				BatchSize:       50,
+				StreamBatchSize: 7,
+				PageSize:        20,
+			},
+			"database_load": {
+				ScenarioType: "database_load",
+				BaseDelay:    "0ms",
+				BatchSize:    30,
+			},
+		},
+		validator: NewScenarioValidator(),
+	}
+
+	tests := []struct {
+		scenario            string
+		pageSize, batchSize int
+	}{
+		{"custom", 20, 7},
+		{"database_load", 30, 30}, // Both fall back to batch_size
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		PaginatedPayloadHandler(w, httptest.NewRequest("GET", "/paginated_payload?scenario="+tt.scenario+"&total=100", nil))
+		var page PaginatedResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+			t.Fatalf("%s: failed to parse paginated response: %v", tt.scenario, err)
+		}
+		if page.Metadata.Limit != tt.pageSize || len(page.Result) != tt.pageSize {
+			t.Errorf("%s: expected pages of %d items, got limit %d with %d items", tt.scenario, tt.pageSize, page.Metadata.Limit, len(page.Result))
+		}
+
+		w = httptest.NewRecorder()
+		StreamingPayloadHandler(w, httptest.NewRequest("GET", "/stream_payload?scenario="+tt.scenario+"&count=10", nil))
+		effective, err := url.ParseQuery(w.Header().Get("X-PayloadBuddy-Effective"))
+		if err != nil {
+			t.Fatalf("%s: failed to parse effective parameters: %v", tt.scenario, err)
+		}
+		if got := effective.Get("batch_size"); got != strconv.Itoa(tt.batchSize) {
+			t.Errorf("%s: expected stream batch size %d, got %s", tt.scenario, tt.batchSize, got)
+		}
+	}
+}
+
 func TestParseDelay(t *testing.T) {
 	testCases := []struct {
 		input    string
@@ -276,3 +366,355 @@ func TestUserScenarioOverride(t *testing.T) {
 		t.Errorf("Expected overridden base delay '300ms', got '%s'", overriddenScenario.BaseDelay)
 	}
 }
+
+func TestStrictScenarioManager(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	scenarioDir := filepath.Join(home, ".config", "payloadBuddy", "scenarios")
+	if err := os.MkdirAll(scenarioDir, 0750); err != nil {
+		t.Fatalf("Failed to create scenario directory: %v", err)
+	}
+
+	// A valid scenario loads in strict mode
+	valid := `{"schema_version":"1.0.0","scenario_name":"Valid","scenario_type":"custom","base_delay":"10ms"}`
+	if err := os.WriteFile(filepath.Join(scenarioDir, "valid.json"), []byte(valid), 0644); err != nil {
+		t.Fatalf("Failed to write scenario: %v", err)
+	}
+	if _, err := NewStrictScenarioManager(); err != nil {
+		t.Fatalf("Expected valid scenarios to load, got %v", err)
+	}
+
+	// An invalid scenario fails strict loading and names the file
+	invalid := `{"schema_version":"1.0.0","scenario_name":"Broken","scenario_type":"custom","base_delay":"soon"}`
+	if err := os.WriteFile(filepath.Join(scenarioDir, "broken.json"), []byte(invalid), 0644); err != nil {
+		t.Fatalf("Failed to write scenario: %v", err)
+	}
+	_, err := NewStrictScenarioManager()
+	if err == nil {
+		t.Fatal("Expected an error for the invalid scenario")
+	}
+	if !strings.Contains(err.Error(), "broken.json") {
+		t.Errorf("Expected the error to name broken.json, got %v", err)
+	}
+
+	// Without strict mode the invalid scenario is skipped
+	sm := NewScenarioManager()
+	if sm.GetScenario("custom") == nil || sm.GetScenario("custom").ScenarioName != "Valid" {
+		t.Error("Expected the valid scenario to be loaded and the invalid one skipped")
+	}
+}
+
+func TestUserScenarioDelayStrategyOverride(t *testing.T) {
+	tempDir := t.TempDir()
+
+	// Override peak_hours with a progressive delay strategy
+	customScenario := Scenario{
+		SchemaVersion: "1.0.0",
+		ScenarioName:  "Progressive Peak Hours",
+		ScenarioType:  "peak_hours",
+		BaseDelay:     "200ms",
+		DelayStrategy: "progressive",
+	}
+
+	scenarioJSON, err := json.Marshal(customScenario)
+	if err != nil {
+		t.Fatalf("Failed to marshal test scenario: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(tempDir, "progressive_peak_hours.json"), scenarioJSON, 0644); err != nil {
+		t.Fatalf("Failed to write test scenario file: %v", err)
+	}
+
+	sm := &ScenarioManager{
+		scenarios: make(map[string]*Scenario),
+		userPath:  tempDir,
+		validator: NewScenarioValidator(),
+	}
+	sm.loadEmbeddedScenarios()
+
+	// Embedded peak_hours keeps its fixed strategy
+	if _, strategy := sm.GetScenarioDelay("peak_hours", 0); strategy != FixedDelay {
+		t.Errorf("Embedded peak_hours strategy: expected FixedDelay, got %v", strategy)
+	}
+
+	sm.loadUserScenarios()
+
+	delay, strategy := sm.GetScenarioDelay("peak_hours", 2000)
+	if strategy != ProgressiveDelay {
+		t.Errorf("Overridden peak_hours strategy: expected ProgressiveDelay, got %v", strategy)
+	}
+	if delay != 200*time.Millisecond {
+		t.Errorf("Overridden peak_hours base delay: expected 200ms, got %v", delay)
+	}
+
+	// The strategy takes effect on the applied delay
+	if applied := calculateStrategyDelay(strategy, delay, 2000); applied != 600*time.Millisecond {
+		t.Errorf("Expected progressive delay of 600ms at item 2000, got %v", applied)
+	}
+}
+
+func TestLoadRemoteScenarios(t *testing.T) {
+	remote := []Scenario{
+		{
+			SchemaVersion: "1.0.0",
+			ScenarioName:  "Remote Custom",
+			ScenarioType:  "custom",
+			BaseDelay:     "15ms",
+		},
+		{
+			// Invalid: missing base_delay, must be skipped
+			ScenarioName: "Broken Remote",
+			ScenarioType: "custom",
+		},
+	}
+	payload, err := json.Marshal(remote)
+	if err != nil {
+		t.Fatalf("Failed to marshal remote scenarios: %v", err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/scenarios.json" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(payload)
+	}))
+	defer server.Close()
+
+	sm := &ScenarioManager{
+		scenarios: make(map[string]*Scenario),
+		userPath:  t.TempDir(),
+		validator: NewScenarioValidator(),
+	}
+	sm.loadEmbeddedScenarios()
+
+	if loaded := sm.LoadRemoteScenarios(server.URL + "/scenarios.json"); loaded != 1 {
+		t.Errorf("Expected 1 remote scenario to load, got %d", loaded)
+	}
+
+	scenario := sm.GetScenario("custom")
+	if scenario == nil || scenario.ScenarioName != "Remote Custom" {
+		t.Fatalf("Expected remote scenario to be registered, got %+v", scenario)
+	}
+	if sm.GetScenario("peak_hours") == nil {
+		t.Error("Embedded scenarios should still be available")
+	}
+
+	// A single scenario object is accepted as well
+	single, _ := json.Marshal(remote[0])
+	if docs, err := splitScenarioDocuments(single); err != nil || len(docs) != 1 {
+		t.Errorf("Expected a single scenario document, got %d (err: %v)", len(docs), err)
+	}
+
+	// Fetch failures are handled gracefully
+	if loaded := sm.LoadRemoteScenarios(server.URL + "/missing.json"); loaded != 0 {
+		t.Errorf("Expected no scenarios from a missing URL, got %d", loaded)
+	}
+	if loaded := sm.LoadRemoteScenarios("file:///etc/passwd"); loaded != 0 {
+		t.Errorf("Expected unsupported scheme to be rejected, got %d", loaded)
+	}
+}
+
+func TestScenarioMaxConcurrent(t *testing.T) {
+	originalAuth := *enableAuth
+	*enableAuth = false
+	defer func() { *enableAuth = originalAuth }()
+
+	originalManager := scenarioManager
+	defer func() { scenarioManager = originalManager }()
+
+	tempDir := t.TempDir()
+	scenarioJSON := []byte(`{
+		"schema_version": "1.0.0",
+		"scenario_name": "Fragile Backend",
+		"scenario_type": "custom",
+		"base_delay": "1ms",
+		"scenario_parameters": {
+			"simulation_config": {"max_concurrent": 2}
+		}
+	}`)
+	if err := os.WriteFile(filepath.Join(tempDir, "fragile.json"), scenarioJSON, 0644); err != nil {
+		t.Fatalf("Failed to write test scenario file: %v", err)
+	}
+
+	scenarioManager = &ScenarioManager{
+		scenarios: make(map[string]*Scenario),
+		userPath:  tempDir,
+		validator: NewScenarioValidator(),
+	}
+	scenarioManager.loadEmbeddedScenarios()
+	scenarioManager.loadUserScenarios()
+
+	if limit := scenarioManager.GetMaxConcurrent("custom"); limit != 2 {
+		t.Fatalf("Expected max_concurrent 2, got %d", limit)
+	}
+
+	// Saturate the custom scenario
+	var releases []func()
+	for i := 0; i < 2; i++ {
+		release, ok := scenarioManager.AcquireScenarioSlot("custom")
+		if !ok {
+			t.Fatalf("Expected slot %d to be available", i+1)
+		}
+		releases = append(releases, release)
+	}
+
+	request := func(handler http.HandlerFunc, url string) int {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest("GET", url, nil))
+		return w.Code
+	}
+
+	// Overflow requests are rejected on both endpoints
+	if code := request(StreamingPayloadHandler, "/stream_payload?scenario=custom&count=1"); code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 for saturated scenario on /stream_payload, got %d", code)
+	}
+	if code := request(PaginatedPayloadHandler, "/paginated_payload?scenario=custom&limit=1"); code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 for saturated scenario on /paginated_payload, got %d", code)
+	}
+
+	// Other scenarios and requests without a scenario are unaffected
+	if code := request(StreamingPayloadHandler, "/stream_payload?scenario=peak_hours&count=1"); code != http.StatusOK {
+		t.Errorf("Expected 200 for unrelated scenario, got %d", code)
+	}
+	if code := request(PaginatedPayloadHandler, "/paginated_payload?limit=1"); code != http.StatusOK {
+		t.Errorf("Expected 200 without scenario, got %d", code)
+	}
+
+	// Freeing a slot admits requests again, and handlers give their slot back
+	releases[0]()
+	for i := 0; i < 3; i++ {
+		if code := request(PaginatedPayloadHandler, "/paginated_payload?scenario=custom&limit=1"); code != http.StatusOK {
+			t.Errorf("Expected 200 after releasing a slot, got %d", code)
+		}
+	}
+	releases[1]()
+}
+
+func TestMaxConcurrentValidation(t *testing.T) {
+	validator := NewScenarioValidator()
+	for _, tt := range []struct {
+		value       interface{}
+		expectError bool
+	}{
+		{float64(5), false},
+		{float64(0), true},
+		{float64(-1), true},
+		{1.5, true},
+		{"5", true},
+	} {
+		scenario := &Scenario{
+			ScenarioName: "Limit Test",
+			ScenarioType: "custom",
+			BaseDelay:    "10ms",
+			ScenarioParams: &ScenarioParameters{
+				SimulationConfig: map[string]interface{}{maxConcurrentKey: tt.value},
+			},
+		}
+		err := validator.ValidateScenario(scenario)
+		if tt.expectError != (err != nil) {
+			t.Errorf("max_concurrent=%v: expected error=%v, got %v", tt.value, tt.expectError, err)
+		}
+	}
+}
+
+func TestUserScenarioEnvExpansion(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("PB_TEST_DELAY", "750ms")
+
+	content := `{
+		"schema_version": "1.0.0",
+		"scenario_name": "Portable Scenario",
+		"scenario_type": "custom",
+		"base_delay": "${PB_TEST_DELAY}"
+	}`
+	if err := os.WriteFile(filepath.Join(tempDir, "portable.json"), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write test scenario file: %v", err)
+	}
+
+	sm := &ScenarioManager{
+		scenarios: make(map[string]*Scenario),
+		userPath:  tempDir,
+		validator: NewScenarioValidator(),
+	}
+	sm.loadUserScenarios()
+
+	scenario := sm.GetScenario("custom")
+	if scenario == nil {
+		t.Fatal("Failed to load scenario using an environment variable")
+	}
+	if scenario.BaseDelay != "750ms" {
+		t.Errorf("Expected base delay expanded from environment '750ms', got '%s'", scenario.BaseDelay)
+	}
+}
+
+func TestUserScenarioTestedVersions(t *testing.T) {
+	originalVersion := Version
+	Version = "0.3.0"
+	defer func() { Version = originalVersion }()
+
+	var logs strings.Builder
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	writeScenario := func(dir, name string, tested []string) {
+		t.Helper()
+		scenario := Scenario{
+			SchemaVersion: "1.0.0",
+			ScenarioName:  name,
+			ScenarioType:  "custom",
+			BaseDelay:     "10ms",
+			DelayStrategy: "fixed",
+			Metadata: &ScenarioMetadata{
+				Compatibility: &CompatibilityInfo{MinPayloadBuddyVersion: "0.1.0", TestedVersions: tested},
+			},
+		}
+		data, err := json.Marshal(scenario)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "custom.json"), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// tested_versions without the running version: loaded with a warning,
+	// but reported for strict mode
+	dir := t.TempDir()
+	writeScenario(dir, "Untested", []string{"1.0.0", "1.1.0"})
+	sm := &ScenarioManager{scenarios: make(map[string]*Scenario), userPath: dir, validator: NewScenarioValidator()}
+	err := sm.loadUserScenarios()
+	if sm.GetScenario("custom") == nil {
+		t.Error("Expected the untested scenario to load")
+	}
+	const warning = "User scenario Untested is not tested with payloadBuddy 0.3.0 (tested_versions: 1.0.0, 1.1.0)"
+	if !strings.Contains(logs.String(), warning) {
+		t.Errorf("Expected warning %q, got logs:\n%s", warning, logs.String())
+	}
+	if err == nil || !strings.Contains(err.Error(), "not tested with payloadBuddy 0.3.0") {
+		t.Errorf("Expected a strict mode error, got %v", err)
+	}
+
+	// tested_versions with the running version, or none at all
+	for _, tested := range [][]string{{"0.2.0", "0.3.0"}, nil} {
+		logs.Reset()
+		dir := t.TempDir()
+		writeScenario(dir, "Tested", tested)
+		sm := &ScenarioManager{scenarios: make(map[string]*Scenario), userPath: dir, validator: NewScenarioValidator()}
+		if err := sm.loadUserScenarios(); err != nil {
+			t.Errorf("tested_versions %v: expected no error, got %v", tested, err)
+		}
+		if strings.Contains(logs.String(), "not tested") {
+			t.Errorf("tested_versions %v: unexpected warning:\n%s", tested, logs.String())
+		}
+	}
+
+	// Development builds are not checked
+	Version = "dev"
+	scenario := &Scenario{Metadata: &ScenarioMetadata{Compatibility: &CompatibilityInfo{TestedVersions: []string{"1.0.0"}}}}
+	if err := (&ScenarioManager{validator: NewScenarioValidator()}).checkTestedVersions(scenario); err != nil {
+		t.Errorf("Expected no error for a development build, got %v", err)
+	}
+}
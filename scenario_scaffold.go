@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// scaffoldBaseDelays holds a sensible base delay per scenario type, taken
+// from the built-in scenarios
+var scaffoldBaseDelays = map[string]string{
+	"peak_hours":        "200ms",
+	"maintenance":       "500ms",
+	"network_issues":    "100ms",
+	"database_load":     "300ms",
+	"recovery":          "10ms",
+	"error_storm":       "10ms",
+	"pagination_drift":  "10ms",
+	"pagination_shrink": "10ms",
+	"slow_connect":      "10ms",
+	"circuit_breaker":   "10ms",
+	"token_expiry":      "10ms",
+	"custom":            "100ms",
+}
+
+// scaffoldScenario builds a minimal scenario of the given type as indented
+// JSON. Type specific settings (error injection for error_storm, the drift
+// for pagination_drift, the deletions for pagination_shrink, the setup delay
+// for slow_connect, the circuit for circuit_breaker, the token lifetime for
+// token_expiry) are filled in so the scenario works as is. The output is
+// validated before it is returned.
+func scaffoldScenario(scenarioType string) ([]byte, error) {
+	baseDelay, ok := scaffoldBaseDelays[scenarioType]
+	if !ok {
+		return nil, fmt.Errorf("scenario_type must be one of: %s", strings.Join(validScenarioTypes, ", "))
+	}
+
+	scenario := &Scenario{
+		SchemaVersion: "1.0.0",
+		ScenarioName:  fmt.Sprintf("My %s scenario", strings.ReplaceAll(scenarioType, "_", " ")),
+		Description:   "Describe what this scenario simulates",
+		ScenarioType:  scenarioType,
+		BaseDelay:     baseDelay,
+		DelayStrategy: "fixed",
+		Metadata: &ScenarioMetadata{
+			CreatedDate: time.Now().Format("2006-01-02"),
+			Version:     "1.0.0",
+		},
+	}
+
+	switch scenarioType {
+	case "error_storm":
+		scenario.ErrorInjection = &ErrorInjectionConfig{
+			Enabled:               true,
+			ErrorRate:             1.0,
+			ErrorTypes:            []string{"server_error"},
+			ConsecutiveErrorLimit: 10,
+		}
+		scenario.ScenarioParams = &ScenarioParameters{
+			SimulationConfig: map[string]interface{}{recoveryAfterKey: 5},
+		}
+	case "pagination_drift":
+		scenario.ScenarioParams = &ScenarioParameters{
+			SimulationConfig: map[string]interface{}{driftPerRequestKey: 5},
+		}
+	case "pagination_shrink":
+		scenario.ScenarioParams = &ScenarioParameters{
+			SimulationConfig: map[string]interface{}{shrinkPerRequestKey: 5},
+		}
+	case "slow_connect":
+		scenario.ScenarioParams = &ScenarioParameters{
+			SimulationConfig: map[string]interface{}{setupDelayKey: "5s"},
+		}
+	case "circuit_breaker":
+		scenario.ScenarioParams = &ScenarioParameters{
+			SimulationConfig: map[string]interface{}{
+				circuitBreakerKey: map[string]interface{}{"open_duration": "10s", "probe_success_rate": 1.0},
+			},
+		}
+	case "token_expiry":
+		scenario.ScenarioParams = &ScenarioParameters{
+			SimulationConfig: map[string]interface{}{tokenExpiryAfterKey: 5},
+		}
+	}
+
+	data, err := json.MarshalIndent(scenario, "", "    ")
+	if err != nil {
+		return nil, err
+	}
+
+	// Round-trip through the validator so the scaffold is guaranteed to load
+	if _, err := NewScenarioValidator().ValidateJSON(data); err != nil {
+		return nil, fmt.Errorf("generated scenario is invalid: %v", err)
+	}
+	return data, nil
+}
+
+// printScenarioScaffold writes a scenario skeleton to stdout for the -scaffold
+// flag. This function is designed for CLI usage and will exit the process on errors.
+func printScenarioScaffold(scenarioType string) {
+	data, err := scaffoldScenario(scenarioType)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(string(data))
+}
@@ -0,0 +1,29 @@
+package main
+
+import "testing"
+
+func TestScaffoldScenarioValidates(t *testing.T) {
+	validator := NewScenarioValidator()
+	for _, scenarioType := range validScenarioTypes {
+		data, err := scaffoldScenario(scenarioType)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", scenarioType, err)
+			continue
+		}
+
+		scenario, err := validator.ValidateJSON(data)
+		if err != nil {
+			t.Errorf("%s: scaffold does not validate: %v", scenarioType, err)
+			continue
+		}
+		if scenario.ScenarioType != scenarioType {
+			t.Errorf("%s: expected scenario_type %q, got %q", scenarioType, scenarioType, scenario.ScenarioType)
+		}
+	}
+}
+
+func TestScaffoldScenarioUnknownType(t *testing.T) {
+	if _, err := scaffoldScenario("black_friday"); err == nil {
+		t.Error("Expected error for unknown scenario type")
+	}
+}
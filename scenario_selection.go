@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// randomScenarioPrefix starts a weighted random scenario selection such as
+// "random:peak_hours=3,network_issues=1"
+const randomScenarioPrefix = "random:"
+
+// weightedScenario is one candidate of a random scenario selection
+type weightedScenario struct {
+	scenario string
+	weight   int
+}
+
+// parseWeightedScenarios parses a comma separated list of scenario=weight
+// pairs. A scenario without a weight counts as weight 1.
+func parseWeightedScenarios(spec string) ([]weightedScenario, error) {
+	var choices []weightedScenario
+	for _, entry := range strings.Split(spec, ",") {
+		name, weightStr, hasWeight := strings.Cut(strings.TrimSpace(entry), "=")
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return nil, fmt.Errorf("random scenario list contains an empty scenario name")
+		}
+
+		weight := 1
+		if hasWeight {
+			parsed, err := strconv.Atoi(strings.TrimSpace(weightStr))
+			if err != nil || parsed < 1 {
+				return nil, fmt.Errorf("weight for scenario %s must be a positive integer", name)
+			}
+			weight = parsed
+		}
+		choices = append(choices, weightedScenario{scenario: name, weight: weight})
+	}
+	return choices, nil
+}
+
+// pickWeightedScenario selects one of the scenarios at random, proportionally
+// to their weights
+func pickWeightedScenario(choices []weightedScenario) string {
+	total := 0
+	for _, choice := range choices {
+		total += choice.weight
+	}
+
+	n, err := secureRandIntn(total)
+	if err != nil {
+		return choices[0].scenario
+	}
+	for _, choice := range choices {
+		if n < choice.weight {
+			return choice.scenario
+		}
+		n -= choice.weight
+	}
+	return choices[len(choices)-1].scenario
+}
+
+// resolveScenario turns the scenario parameter into the scenario used for
+// this request. "random:<scenario>=<weight>,..." picks one of the listed
+// scenarios per request; a layered selection "<scenario>,<scenario>,..." is
+// normalized; any other value is returned unchanged.
+func resolveScenario(scenario string) (string, error) {
+	spec, isRandom := strings.CutPrefix(scenario, randomScenarioPrefix)
+	if !isRandom {
+		if !strings.Contains(scenario, layeredScenarioSeparator) {
+			return scenario, nil
+		}
+		names, err := parseLayeredScenarios(scenario)
+		if err != nil {
+			return "", err
+		}
+		return strings.Join(names, layeredScenarioSeparator), nil
+	}
+
+	choices, err := parseWeightedScenarios(spec)
+	if err != nil {
+		return "", err
+	}
+	return pickWeightedScenario(choices), nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestRandomScenarioSelection checks that random: selections follow the
+// configured weights over many requests
+func TestRandomScenarioSelection(t *testing.T) {
+	const requests = 4000
+	counts := make(map[string]int)
+	for range requests {
+		req := httptest.NewRequest("GET", "/stream_payload?scenario=random:peak_hours=3,network_issues=1", nil)
+		scenario, err := getScenarioParam(req)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		counts[scenario]++
+	}
+
+	if len(counts) != 2 {
+		t.Fatalf("Expected only peak_hours and network_issues, got %v", counts)
+	}
+	// Expected share is 3/4; allow a generous margin for randomness
+	share := float64(counts["peak_hours"]) / requests
+	if share < 0.70 || share > 0.80 {
+		t.Errorf("Expected peak_hours in ~75%% of requests, got %.1f%% (%v)", share*100, counts)
+	}
+}
+
+func TestResolveScenario(t *testing.T) {
+	tests := []struct {
+		scenario    string
+		expected    string
+		expectError bool
+	}{
+		{"peak_hours", "peak_hours", false},
+		{"", "", false},
+		{"random:maintenance", "maintenance", false},
+		{"random:maintenance=5", "maintenance", false},
+		{"random:", "", true},
+		{"random:peak_hours=0", "", true},
+		{"random:peak_hours=abc", "", true},
+		{"random:peak_hours=1,,maintenance=1", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.scenario, func(t *testing.T) {
+			got, err := resolveScenario(tt.scenario)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error, got scenario %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestRandomScenarioInvalidWeights(t *testing.T) {
+	originalAuth := *enableAuth
+	*enableAuth = false
+	defer func() { *enableAuth = originalAuth }()
+
+	for _, handler := range []http.HandlerFunc{StreamingPayloadHandler, PaginatedPayloadHandler} {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest("GET", "/?scenario=random:peak_hours=-1", nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for invalid weight, got %d", w.Code)
+		}
+	}
+}
@@ -6,20 +6,62 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/xeipuuv/gojsonschema"
 )
 
+// validScenarioTypes lists the allowed values of scenario_type
+var validScenarioTypes = []string{"peak_hours", "maintenance", "network_issues", "database_load", "recovery", "error_storm", "pagination_drift", "pagination_shrink", "slow_connect", "circuit_breaker", "token_expiry", "custom"}
+
 // ScenarioValidator provides JSON schema validation for scenarios
 type ScenarioValidator struct {
 	schemaVersion string
+	useSchema     bool // Also validate against the embedded JSON schema file
 }
 
-// NewScenarioValidator creates a new scenario validator
+// NewScenarioValidator creates a new scenario validator. With
+// -schema-validation it also checks scenarios against the embedded JSON
+// schema.
 func NewScenarioValidator() *ScenarioValidator {
 	return &ScenarioValidator{
 		schemaVersion: "1.0.0",
+		useSchema:     *paramSchemaValidation,
+	}
+}
+
+// loadScenarioSchema compiles the embedded scenario JSON schema once
+var loadScenarioSchema = sync.OnceValues(func() (*gojsonschema.Schema, error) {
+	data, err := embeddedScenarios.ReadFile("scenarios/" + scenarioSchemaFile)
+	if err != nil {
+		return nil, err
+	}
+	return gojsonschema.NewSchema(gojsonschema.NewBytesLoader(data))
+})
+
+// validateAgainstSchema validates a scenario document, without comments,
+// against the embedded JSON schema and reports every violation
+func (sv *ScenarioValidator) validateAgainstSchema(jsonData []byte) error {
+	schema, err := loadScenarioSchema()
+	if err != nil {
+		return fmt.Errorf("scenario schema failed to load: %v", err)
+	}
+	result, err := schema.Validate(gojsonschema.NewBytesLoader(jsonData))
+	if err != nil {
+		return fmt.Errorf("schema validation failed: %v", err)
+	}
+	if result.Valid() {
+		return nil
+	}
+
+	violations := make([]string, len(result.Errors()))
+	for i, violation := range result.Errors() {
+		violations[i] = violation.String()
 	}
+	return fmt.Errorf("schema validation failed: %s", strings.Join(violations, "; "))
 }
 
 // ValidateScenario validates a scenario against the JSON schema
@@ -37,9 +79,8 @@ func (sv *ScenarioValidator) ValidateScenario(scenario *Scenario) error {
 	}
 
 	// Validate scenario_type enum
-	validTypes := []string{"peak_hours", "maintenance", "network_issues", "database_load", "custom"}
-	if !sv.isValidEnum(scenario.ScenarioType, validTypes) {
-		return fmt.Errorf("scenario_type must be one of: %s", strings.Join(validTypes, ", "))
+	if !sv.isValidEnum(scenario.ScenarioType, validScenarioTypes) {
+		return fmt.Errorf("scenario_type must be one of: %s", strings.Join(validScenarioTypes, ", "))
 	}
 
 	if scenario.BaseDelay == "" {
@@ -109,6 +150,52 @@ func (sv *ScenarioValidator) ValidateScenario(scenario *Scenario) error {
 	return nil
 }
 
+// Thresholds above which valid values produce a validation warning
+const (
+	warnDelayThreshold    = 10 * time.Second
+	warnMaxCountThreshold = 500000
+)
+
+// ScenarioWarnings reports non-fatal issues in a scenario that passed
+// ValidateScenario, such as unusually large delays or limits. Warnings do not
+// prevent the scenario from loading.
+func (sv *ScenarioValidator) ScenarioWarnings(scenario *Scenario) []string {
+	var warnings []string
+
+	if delay, err := ParseDelay(scenario.BaseDelay); err == nil && delay > warnDelayThreshold {
+		warnings = append(warnings, fmt.Sprintf("base_delay %s is over %s and applies per item or page", scenario.BaseDelay, warnDelayThreshold))
+	}
+
+	if scenario.ScenarioParams != nil {
+		for key, value := range scenario.ScenarioParams.DelayOverrides {
+			if delay, err := ParseDelay(value); err == nil && delay > warnDelayThreshold {
+				warnings = append(warnings, fmt.Sprintf("delay_overrides.%s %s is over %s", key, value, warnDelayThreshold))
+			}
+		}
+	}
+
+	if scenario.ResponseLimits != nil && scenario.ResponseLimits.MaxCount > warnMaxCountThreshold {
+		warnings = append(warnings, fmt.Sprintf("response_limits.max_count %d is over %d, large responses need a lot of memory and time", scenario.ResponseLimits.MaxCount, warnMaxCountThreshold))
+	}
+
+	if config := scenario.ErrorInjection; config != nil && config.Enabled && config.ErrorRate >= 1 && config.ConsecutiveErrorLimit == 0 {
+		recovers := false
+		if scenario.ScenarioParams != nil {
+			_, recovers = scenario.ScenarioParams.SimulationConfig[recoveryAfterKey]
+		}
+		if !recovers {
+			warnings = append(warnings, "error_injection fails every request: error_rate is 1.0 without consecutive_error_limit or recovery_after")
+		}
+	}
+
+	if scenario.SchemaVersion == "" {
+		warnings = append(warnings, fmt.Sprintf("schema_version is not set, assuming %s", sv.schemaVersion))
+	}
+
+	sort.Strings(warnings)
+	return warnings
+}
+
 // validateDelayFormat validates delay string format
 func (sv *ScenarioValidator) validateDelayFormat(delay string) error {
 	// Pattern: ^(\d+(\.\d+)?(ns|us|μs|ms|s|m|h))|\d+$
@@ -262,6 +349,104 @@ func (sv *ScenarioValidator) validateScenarioParameters(params *ScenarioParamete
 		}
 	}
 
+	// Validate latency percentile targets
+	if value, ok := params.SimulationConfig[latencyPercentilesKey]; ok {
+		if _, err := parseLatencyPercentiles(value); err != nil {
+			return fmt.Errorf("simulation_config %v", err)
+		}
+	}
+
+	// Validate the concurrent request limit
+	if value, ok := params.SimulationConfig[maxConcurrentKey]; ok {
+		if _, err := parseMaxConcurrent(value); err != nil {
+			return fmt.Errorf("simulation_config %v", err)
+		}
+	}
+
+	// Validate the error injection recovery point
+	if value, ok := params.SimulationConfig[recoveryAfterKey]; ok {
+		if _, err := parseRecoveryAfter(value); err != nil {
+			return fmt.Errorf("simulation_config %v", err)
+		}
+	}
+
+	// Validate the pagination shrink rate
+	if value, ok := params.SimulationConfig[shrinkPerRequestKey]; ok {
+		if _, err := parseShrinkPerRequest(value); err != nil {
+			return fmt.Errorf("simulation_config %v", err)
+		}
+	}
+
+	// Validate the pagination drift rate
+	if value, ok := params.SimulationConfig[driftPerRequestKey]; ok {
+		if _, err := parseDriftPerRequest(value); err != nil {
+			return fmt.Errorf("simulation_config %v", err)
+		}
+	}
+
+	// Validate the page size growth
+	if value, ok := params.SimulationConfig[pageGrowthKey]; ok {
+		if _, err := parsePageGrowth(value); err != nil {
+			return fmt.Errorf("simulation_config %v", err)
+		}
+	}
+
+	// Validate the empty page probability
+	if value, ok := params.SimulationConfig[emptyPageRateKey]; ok {
+		if _, err := parseEmptyPageRate(value); err != nil {
+			return fmt.Errorf("simulation_config %v", err)
+		}
+	}
+
+	// Validate the alternate page content type
+	if value, ok := params.SimulationConfig[alternateContentTypeKey]; ok {
+		if _, err := parseAlternateContentType(value); err != nil {
+			return fmt.Errorf("simulation_config %v", err)
+		}
+	}
+
+	// Validate the random seed switch
+	if value, ok := params.SimulationConfig[randomSeedKey]; ok {
+		if _, err := parseRandomSeed(value); err != nil {
+			return fmt.Errorf("simulation_config %v", err)
+		}
+	}
+
+	// Validate the circuit breaker
+	if value, ok := params.SimulationConfig[circuitBreakerKey]; ok {
+		if _, err := parseCircuitBreaker(value); err != nil {
+			return fmt.Errorf("simulation_config %v", err)
+		}
+	}
+
+	// Validate the token expiry
+	if value, ok := params.SimulationConfig[tokenExpiryAfterKey]; ok {
+		if _, err := parseTokenExpiryAfter(value); err != nil {
+			return fmt.Errorf("simulation_config %v", err)
+		}
+	}
+
+	// Validate the connection setup delay
+	if value, ok := params.SimulationConfig[setupDelayKey]; ok {
+		if _, err := parseSetupDelay(value); err != nil {
+			return fmt.Errorf("simulation_config %v", err)
+		}
+	}
+
+	// Validate custom response headers
+	if value, ok := params.SimulationConfig[responseHeadersKey]; ok {
+		if _, err := parseResponseHeaders(value); err != nil {
+			return fmt.Errorf("simulation_config %v", err)
+		}
+	}
+
+	// Validate the item template
+	if value, ok := params.SimulationConfig[itemTemplateKey]; ok {
+		if _, err := parseItemTemplate(value); err != nil {
+			return fmt.Errorf("simulation_config %v", err)
+		}
+	}
+
 	return nil
 }
 
@@ -290,13 +475,24 @@ func (sv *ScenarioValidator) isValidIdentifier(identifier string) bool {
 	return pattern.MatchString(identifier)
 }
 
-// ValidateJSON validates raw JSON against the scenario schema
+// ValidateJSON validates raw JSON against the scenario schema.
+// Comments (JSONC) are stripped before parsing. With -schema-validation the
+// document is checked against the embedded JSON schema before the built-in
+// checks run.
 func (sv *ScenarioValidator) ValidateJSON(jsonData []byte) (*Scenario, error) {
+	jsonData = stripJSONComments(jsonData)
+
 	var scenario Scenario
 	if err := json.Unmarshal(jsonData, &scenario); err != nil {
 		return nil, fmt.Errorf("JSON parsing failed: %v", err)
 	}
 
+	if sv.useSchema {
+		if err := sv.validateAgainstSchema(jsonData); err != nil {
+			return nil, err
+		}
+	}
+
 	if err := sv.ValidateScenario(&scenario); err != nil {
 		return nil, err
 	}
@@ -322,8 +518,9 @@ func (sv *ScenarioValidator) ValidateScenarioFile(filePath string) {
 		os.Exit(1)
 	}
 
-	// Success - show scenario details
+	// Success - show scenario details and any non-fatal issues
 	sv.printScenarioDetails(scenario)
+	sv.printScenarioWarnings(scenario)
 }
 
 // ValidateScenarioFileContent reads and validates a scenario file, returning the scenario or error
@@ -346,8 +543,8 @@ func (sv *ScenarioValidator) ValidateScenarioFileContent(filePath string) (*Scen
 		return nil, fmt.Errorf("error reading file: %v", err)
 	}
 
-	// Validate JSON and scenario
-	scenario, err := sv.ValidateJSON(content)
+	// Validate JSON and scenario, expanding environment variables like the loader
+	scenario, err := sv.ValidateJSON(expandScenarioEnv(content))
 	if err != nil {
 		return nil, fmt.Errorf("validation failed:\n%v", err)
 	}
@@ -355,6 +552,18 @@ func (sv *ScenarioValidator) ValidateScenarioFileContent(filePath string) (*Scen
 	return scenario, nil
 }
 
+// printScenarioWarnings prints the non-fatal issues found in a valid scenario
+func (sv *ScenarioValidator) printScenarioWarnings(scenario *Scenario) {
+	warnings := sv.ScenarioWarnings(scenario)
+	if len(warnings) == 0 {
+		return
+	}
+	fmt.Printf("\n⚠️  Warnings:\n")
+	for _, warning := range warnings {
+		fmt.Printf("   - %s\n", warning)
+	}
+}
+
 // printScenarioDetails prints detailed information about a validated scenario
 func (sv *ScenarioValidator) printScenarioDetails(scenario *Scenario) {
 	fmt.Printf("✅ Validation successful!\n\n")
@@ -372,6 +581,12 @@ func (sv *ScenarioValidator) printScenarioDetails(scenario *Scenario) {
 	if scenario.BatchSize > 0 {
 		fmt.Printf("   Batch Size: %d\n", scenario.BatchSize)
 	}
+	if scenario.StreamBatchSize > 0 {
+		fmt.Printf("   Stream Batch Size: %d\n", scenario.StreamBatchSize)
+	}
+	if scenario.PageSize > 0 {
+		fmt.Printf("   Page Size: %d\n", scenario.PageSize)
+	}
 
 	if scenario.ResponseLimits != nil {
 		if scenario.ResponseLimits.MaxCount > 0 {
@@ -485,3 +485,163 @@ func TestValidateScenarioFileContent(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateScenarioFileContentWithComments(t *testing.T) {
+	validator := NewScenarioValidator()
+	filePath := filepath.Join(t.TempDir(), "commented.jsonc")
+
+	content := `{
+    // Annotated scenario for the nightly sync test
+    "schema_version": "1.0.0",
+    "scenario_name": "Commented // not a comment",
+    "description": "URLs like http://example.com/* stay intact",
+    "scenario_type": "custom", /* inline block comment */
+    "base_delay": "100ms"
+    /*
+     * Multi-line block comment
+     */
+}`
+	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	scenario, err := validator.ValidateScenarioFileContent(filePath)
+	if err != nil {
+		t.Fatalf("Expected commented scenario to validate, got: %v", err)
+	}
+	if scenario.ScenarioName != "Commented // not a comment" {
+		t.Errorf("Comment markers inside strings must be kept, got name %q", scenario.ScenarioName)
+	}
+	if scenario.Description != "URLs like http://example.com/* stay intact" {
+		t.Errorf("Comment markers inside strings must be kept, got description %q", scenario.Description)
+	}
+}
+
+func TestStripJSONComments(t *testing.T) {
+	tests := map[string]string{
+		`{"a": 1}`:                  `{"a": 1}`,
+		`{"a": "x\"//y"} // c`:      `{"a": "x\"//y"}     `,
+		"{/* c */\"a\": 1}":         "{       \"a\": 1}",
+		"{\"a\": 1 // c\n}":         "{\"a\": 1     \n}",
+		"{\"a\": 1 /* x\ny */}":     "{\"a\": 1     \n    }",
+		`{"a": "/* not a comment"}`: `{"a": "/* not a comment"}`,
+	}
+	for input, expected := range tests {
+		if got := string(stripJSONComments([]byte(input))); got != expected {
+			t.Errorf("stripJSONComments(%q) = %q, expected %q", input, got, expected)
+		}
+	}
+}
+
+func TestScenarioWarnings(t *testing.T) {
+	validator := NewScenarioValidator()
+
+	scenario, err := validator.ValidateJSON([]byte(`{
+		"schema_version": "1.0.0",
+		"scenario_name": "Very Slow",
+		"scenario_type": "custom",
+		"base_delay": "30s",
+		"response_limits": {"max_count": 800000}
+	}`))
+	if err != nil {
+		t.Fatalf("Expected high-delay scenario to validate, got: %v", err)
+	}
+
+	warnings := validator.ScenarioWarnings(scenario)
+	if len(warnings) != 2 {
+		t.Fatalf("Expected 2 warnings, got %d: %v", len(warnings), warnings)
+	}
+	if !strings.Contains(warnings[0], "base_delay 30s") {
+		t.Errorf("Expected base_delay warning, got %q", warnings[0])
+	}
+	if !strings.Contains(warnings[1], "max_count 800000") {
+		t.Errorf("Expected max_count warning, got %q", warnings[1])
+	}
+
+	// Built-in scenarios are free of warnings
+	entries, err := embeddedScenarios.ReadDir("scenarios")
+	if err != nil {
+		t.Fatalf("Failed to read embedded scenarios: %v", err)
+	}
+	for _, entry := range entries {
+		if entry.Name() == "scenario_schema_v1.0.0.json" {
+			continue
+		}
+		content, _ := embeddedScenarios.ReadFile("scenarios/" + entry.Name())
+		scenario, err := validator.ValidateJSON(content)
+		if err != nil {
+			t.Fatalf("%s: %v", entry.Name(), err)
+		}
+		if warnings := validator.ScenarioWarnings(scenario); len(warnings) != 0 {
+			t.Errorf("%s: expected no warnings, got %v", entry.Name(), warnings)
+		}
+	}
+}
+
+// TestSchemaValidationAgreement runs the built-in checks and the embedded
+// JSON schema on the same documents, so the two cannot drift apart unnoticed
+func TestSchemaValidationAgreement(t *testing.T) {
+	handValidator := &ScenarioValidator{schemaVersion: "1.0.0"}
+	schemaValidator := &ScenarioValidator{schemaVersion: "1.0.0"}
+
+	documents := map[string]string{
+		"minimal":            `{"scenario_name": "Minimal", "scenario_type": "custom", "base_delay": "10ms"}`,
+		"missing name":       `{"scenario_type": "custom", "base_delay": "10ms"}`,
+		"missing delay":      `{"scenario_name": "No delay", "scenario_type": "custom"}`,
+		"unknown type":       `{"scenario_name": "Unknown", "scenario_type": "bogus", "base_delay": "10ms"}`,
+		"long name":          `{"scenario_name": "` + strings.Repeat("n", 101) + `", "scenario_type": "custom", "base_delay": "10ms"}`,
+		"bad strategy":       `{"scenario_name": "Strategy", "scenario_type": "custom", "base_delay": "10ms", "delay_strategy": "sometimes"}`,
+		"bad schema version": `{"schema_version": "1.0", "scenario_name": "Version", "scenario_type": "custom", "base_delay": "10ms"}`,
+		"error rate":         `{"scenario_name": "Rate", "scenario_type": "custom", "base_delay": "10ms", "error_injection": {"enabled": true, "error_rate": 1.5}}`,
+		"error type":         `{"scenario_name": "Type", "scenario_type": "custom", "base_delay": "10ms", "error_injection": {"error_types": ["meteor"]}}`,
+		"record type":        `{"scenario_name": "Record", "scenario_type": "custom", "base_delay": "10ms", "servicenow_config": {"record_types": ["ticket"]}}`,
+		"metadata version":   `{"scenario_name": "Meta", "scenario_type": "custom", "base_delay": "10ms", "metadata": {"version": "one"}}`,
+		"metadata date":      `{"scenario_name": "Date", "scenario_type": "custom", "base_delay": "10ms", "metadata": {"created_date": "2024-13-45"}}`,
+		"metrics interval":   `{"scenario_name": "Metrics", "scenario_type": "custom", "base_delay": "10ms", "performance_monitoring": {"metrics_interval": 0}}`,
+	}
+
+	// The built-in scenarios must pass both
+	entries, err := embeddedScenarios.ReadDir("scenarios")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if entry.Name() == scenarioSchemaFile {
+			continue
+		}
+		content, err := embeddedScenarios.ReadFile("scenarios/" + entry.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		documents[entry.Name()] = string(content)
+	}
+
+	for name, document := range documents {
+		_, handErr := handValidator.ValidateJSON([]byte(document))
+		schemaErr := schemaValidator.validateAgainstSchema([]byte(document))
+		if (handErr == nil) != (schemaErr == nil) {
+			t.Errorf("%s: built-in checks and schema disagree: built-in %v, schema %v", name, handErr, schemaErr)
+		}
+	}
+}
+
+func TestSchemaValidation(t *testing.T) {
+	validator := &ScenarioValidator{schemaVersion: "1.0.0", useSchema: true}
+
+	if _, err := validator.ValidateJSON([]byte(`{
+		// Comments are stripped before the schema applies
+		"scenario_name": "Commented", "scenario_type": "custom", "base_delay": "10ms"
+	}`)); err != nil {
+		t.Errorf("Expected a valid JSONC scenario, got %v", err)
+	}
+
+	// The schema rejects properties the built-in checks ignore
+	document := []byte(`{"scenario_name": "Typo", "scenario_type": "custom", "base_delay": "10ms", "servicenow_mod": true}`)
+	if _, err := (&ScenarioValidator{schemaVersion: "1.0.0"}).ValidateJSON(document); err != nil {
+		t.Fatalf("Expected the built-in checks to accept an unknown property, got %v", err)
+	}
+	_, err := validator.ValidateJSON(document)
+	if err == nil || !strings.Contains(err.Error(), "servicenow_mod") {
+		t.Errorf("Expected the schema to reject the unknown property, got %v", err)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	mathrand "math/rand/v2"
+	"time"
+)
+
+// Words combined into seeded string values. Two words from these lists give
+// 256 combinations, enough for neighbouring items to look different.
+var (
+	seededAdjectives = []string{"amber", "brisk", "calm", "dusty", "eager", "frosty", "gentle", "hollow", "ivory", "jolly", "keen", "lunar", "misty", "noble", "quiet", "rapid"}
+	seededNouns      = []string{"falcon", "harbor", "meadow", "canyon", "lantern", "orchid", "summit", "willow", "glacier", "comet", "river", "thicket", "beacon", "quarry", "delta", "prairie"}
+)
+
+// seededTimestampBase is the start of the year seeded timestamps fall into
+var seededTimestampBase = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// fieldRand returns a pseudo-random generator for one field of one item. Its
+// output only depends on the seed, the item ID and the field name: the same
+// request reproduces every value, while the fields of an item and the same
+// field of neighbouring items differ. Reproducibility matters here, not
+// unpredictability.
+func fieldRand(seed uint64, id int, field string) *mathrand.Rand {
+	h := fnv.New64a()
+	var buf [16]byte
+	binary.LittleEndian.PutUint64(buf[:8], seed)
+	binary.LittleEndian.PutUint64(buf[8:], uint64(id))
+	h.Write(buf[:])
+	h.Write([]byte(field))
+	return mathrand.New(mathrand.NewPCG(seed, h.Sum64()))
+}
+
+// seededWords returns two words such as "misty harbor"
+func seededWords(rng *mathrand.Rand) string {
+	return seededAdjectives[rng.IntN(len(seededAdjectives))] + " " + seededNouns[rng.IntN(len(seededNouns))]
+}
+
+// seededValue appends seeded words to the value of the item with the given
+// ID, e.g. "Item 5 misty harbor"
+func seededValue(seed uint64, id int, value string) string {
+	return value + " " + seededWords(fieldRand(seed, id, "value"))
+}
+
+// seededSysID returns a reproducible ServiceNow-style sys_id of 32 hex
+// characters for the item with the given ID
+func seededSysID(seed uint64, id int) string {
+	rng := fieldRand(seed, id, "sys_id")
+	return fmt.Sprintf("%016x%016x", rng.Uint64(), rng.Uint64())
+}
+
+// seededTimestamp returns a reproducible time within the year after
+// seededTimestampBase, at whole seconds
+func seededTimestamp(rng *mathrand.Rand) time.Time {
+	return seededTimestampBase.Add(time.Duration(rng.Int64N(365*24*60*60)) * time.Second)
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSeededValues(t *testing.T) {
+	*enableAuth = false
+
+	get := func(handler http.HandlerFunc, url string) []byte {
+		t.Helper()
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest("GET", url, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected status 200, got %d: %s", url, w.Code, w.Body.String())
+		}
+		return w.Body.Bytes()
+	}
+
+	// The same request is byte-identical
+	const url = "/stream_payload?count=50&delay=0&servicenow=true&seed=42&fixed_timestamp=2024-01-15T10:00:00Z"
+	first := get(StreamingPayloadHandler, url)
+	if !bytes.Equal(first, get(StreamingPayloadHandler, url)) {
+		t.Fatal("Expected the same seed to reproduce the response byte for byte")
+	}
+	if bytes.Equal(first, get(StreamingPayloadHandler, strings.Replace(url, "seed=42", "seed=43", 1))) {
+		t.Error("Expected another seed to change the response")
+	}
+
+	var items []StreamItem
+	if err := json.Unmarshal(first, &items); err != nil {
+		t.Fatalf("Failed to parse JSON response: %v", err)
+	}
+	values := make(map[string]bool)
+	sysIDs := make(map[string]bool)
+	for _, item := range items {
+		if !strings.HasPrefix(item.Value, "ServiceNow Record ") {
+			t.Errorf("Item %d: unexpected value %q", item.ID, item.Value)
+		}
+		if len(item.SysID) != 32 {
+			t.Errorf("Item %d: expected a 32 character sys_id, got %q", item.ID, item.SysID)
+		}
+		values[strings.TrimPrefix(item.Value, "ServiceNow Record ")] = true
+		sysIDs[item.SysID] = true
+	}
+	if len(sysIDs) != len(items) {
+		t.Errorf("Expected %d distinct sys_ids, got %d", len(items), len(sysIDs))
+	}
+	if len(values) != len(items) {
+		t.Errorf("Expected the values of different items to differ, got %d distinct of %d", len(values), len(items))
+	}
+
+	// Items of the same ID match across endpoints
+	var page PaginatedResponse
+	if err := json.Unmarshal(get(PaginatedPayloadHandler, "/paginated_payload?limit=5&offset=10&id_start=0&servicenow=true&seed=42"), &page); err != nil {
+		t.Fatalf("Failed to parse paginated response: %v", err)
+	}
+	for _, item := range page.Result {
+		if item.Value != items[item.ID].Value || item.SysID != items[item.ID].SysID {
+			t.Errorf("Item %d: expected %q/%s on both endpoints, got %q/%s", item.ID, items[item.ID].Value, items[item.ID].SysID, item.Value, item.SysID)
+		}
+	}
+
+	// Without a seed values keep their historical form
+	if err := json.Unmarshal(get(StreamingPayloadHandler, "/stream_payload?count=1&delay=0"), &items); err != nil || items[0].Value != "streamed data 0" {
+		t.Errorf("Expected an unseeded value of %q, got %v (%v)", "streamed data 0", items, err)
+	}
+}
+
+func TestGenerateSeeded(t *testing.T) {
+	*enableAuth = false
+
+	generate := func(url string) []map[string]interface{} {
+		t.Helper()
+		w := httptest.NewRecorder()
+		GenerateHandler(w, httptest.NewRequest("GET", url, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected status 200, got %d: %s", url, w.Code, w.Body.String())
+		}
+		var items []map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil {
+			t.Fatalf("%s: failed to parse JSON response: %v", url, err)
+		}
+		return items
+	}
+
+	const url = "/generate?count=20&seed=7&fields=first:string,second:string,a:int,b:int,created:timestamp"
+	items := generate(url)
+	again, _ := json.Marshal(generate(url))
+	if encoded, _ := json.Marshal(items); !bytes.Equal(encoded, again) {
+		t.Fatal("Expected the same seed to reproduce the items")
+	}
+
+	// Fields of the same type differ from each other and between items
+	sameStrings, sameInts := 0, 0
+	firsts := make(map[interface{}]bool)
+	for _, item := range items {
+		if item["first"] == item["second"] {
+			sameStrings++
+		}
+		if item["a"] == item["b"] {
+			sameInts++
+		}
+		firsts[item["first"]] = true
+	}
+	if sameStrings > 2 || sameInts > 2 {
+		t.Errorf("Expected fields of the same type to vary, got %d equal strings and %d equal ints", sameStrings, sameInts)
+	}
+	if len(firsts) < 10 {
+		t.Errorf("Expected values to vary between items, got %d distinct of %d", len(firsts), len(items))
+	}
+
+	w := httptest.NewRecorder()
+	GenerateHandler(w, httptest.NewRequest("GET", "/generate?fields=a:int&seed=-1", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("seed=-1: expected status 400, got %d", w.Code)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	mathrand "math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Limits for the item size parameters
+const (
+	defaultValueSize = 256
+	maxValueSize     = 64 << 10
+)
+
+// sizeOptions pads item values to varying lengths, modelling records of
+// heterogeneous size
+type sizeOptions struct {
+	Variation int    // Percentage the value length may differ from ValueSize, 0 disables padding
+	ValueSize int    // Base length of item values in bytes
+	Seed      uint64 // Seed for reproducible sizes and values, only used if Seeded
+	Seeded    bool
+}
+
+// getSizeOptions parses the size_variation, value_size and seed query
+// parameters
+func getSizeOptions(r *http.Request) (sizeOptions, error) {
+	opts := sizeOptions{ValueSize: defaultValueSize}
+	query := r.URL.Query()
+
+	if val := query.Get("size_variation"); val != "" {
+		variation, err := strconv.Atoi(strings.TrimSuffix(val, "%"))
+		if err != nil || variation < 0 || variation > 100 {
+			return opts, fmt.Errorf("size_variation must be a percentage between 0 and 100")
+		}
+		opts.Variation = variation
+	}
+	if val := query.Get("value_size"); val != "" {
+		size, err := strconv.Atoi(val)
+		if err != nil || size < 1 || size > maxValueSize {
+			return opts, fmt.Errorf("value_size must be between 1 and %d", maxValueSize)
+		}
+		opts.ValueSize = size
+	}
+	if val := query.Get("seed"); val != "" {
+		seed, err := strconv.ParseUint(val, 10, 64)
+		if err != nil {
+			return opts, fmt.Errorf("seed must be a non-negative integer")
+		}
+		opts.Seed, opts.Seeded = seed, true
+	}
+	return opts, nil
+}
+
+// enabled reports whether item values are padded
+func (o sizeOptions) enabled() bool {
+	return o.Variation > 0
+}
+
+// valueSize returns the target value length of the item with the given ID,
+// uniformly distributed within Variation percent around ValueSize. With a
+// seed the length only depends on the seed and the ID, so pages and resumed
+// streams reproduce it.
+func (o sizeOptions) valueSize(id int) int {
+	band := o.ValueSize * o.Variation / 100
+	var offset int
+	if o.Seeded {
+		// Reproducibility matters here, not unpredictability
+		rng := mathrand.New(mathrand.NewPCG(o.Seed, uint64(id)))
+		offset = rng.IntN(2*band + 1)
+	} else if n, err := secureRandIntn(2*band + 1); err == nil {
+		offset = n
+	} else {
+		offset = band
+	}
+	return o.ValueSize - band + offset
+}
+
+// pad appends filler to value up to the item's target length. Values that
+// are already longer stay unchanged.
+func (o sizeOptions) pad(value string, id int) string {
+	if !o.enabled() {
+		return value
+	}
+	missing := o.valueSize(id) - len(value)
+	if missing <= 0 {
+		return value
+	}
+	return value + strings.Repeat("x", missing)
+}
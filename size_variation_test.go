@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSizeVariation(t *testing.T) {
+	*enableAuth = false
+
+	valueLengths := func(url string) []int {
+		w := httptest.NewRecorder()
+		StreamingPayloadHandler(w, httptest.NewRequest("GET", url, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected status 200, got %d", url, w.Code)
+		}
+		var items []StreamItem
+		if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil {
+			t.Fatalf("%s: failed to parse JSON response: %v", url, err)
+		}
+		lengths := make([]int, len(items))
+		for i, item := range items {
+			lengths[i] = len(item.Value)
+		}
+		return lengths
+	}
+
+	const url = "/stream_payload?count=200&delay=0&size_variation=25&value_size=200&seed=7"
+	lengths := valueLengths(url)
+	distinct := make(map[int]bool)
+	for i, n := range lengths {
+		if n < 150 || n > 250 {
+			t.Errorf("Item %d: value length %d outside the band 150-250", i, n)
+		}
+		distinct[n] = true
+	}
+	if len(distinct) < 10 {
+		t.Errorf("Expected value lengths to vary, got %d distinct lengths", len(distinct))
+	}
+
+	// The same seed reproduces the sizes
+	for i, n := range valueLengths(url) {
+		if n != lengths[i] {
+			t.Fatalf("Item %d: expected length %d with the same seed, got %d", i, lengths[i], n)
+		}
+	}
+
+	// Paginated items of the same IDs get the same sizes
+	w := httptest.NewRecorder()
+	PaginatedPayloadHandler(w, httptest.NewRequest("GET", "/paginated_payload?limit=10&offset=20&id_start=0&size_variation=25&value_size=200&seed=7", nil))
+	var page PaginatedResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+		t.Fatalf("Failed to parse paginated response: %v", err)
+	}
+	for _, item := range page.Result {
+		if len(item.Value) != lengths[item.ID] {
+			t.Errorf("Item %d: expected paginated length %d, got %d", item.ID, lengths[item.ID], len(item.Value))
+		}
+	}
+
+	for _, query := range []string{"size_variation=101", "value_size=0", "seed=-1"} {
+		w := httptest.NewRecorder()
+		StreamingPayloadHandler(w, httptest.NewRequest("GET", "/stream_payload?"+query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", query, w.Code)
+		}
+	}
+}
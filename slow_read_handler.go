@@ -0,0 +1,210 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Limits for the slow read endpoint
+const (
+	defaultSlowReadRate = 1024              // Bytes per second
+	maxSlowReadBody     = 100 * 1024 * 1024 // Larger bodies are rejected
+
+	// slowReadIdleTimeout is how long the client may stall between two
+	// chunks, replacing the server's ReadTimeout and WriteTimeout
+	slowReadIdleTimeout = 30 * time.Second
+)
+
+// SlowReadPlugin implements PayloadPlugin for throttled request body reads
+type SlowReadPlugin struct{}
+
+// Path returns the HTTP path for the slow read endpoint
+func (p SlowReadPlugin) Path() string {
+	return "/slow-read"
+}
+
+// Handler returns the handler function for the slow read endpoint
+func (p SlowReadPlugin) Handler() http.HandlerFunc {
+	return SlowReadHandler
+}
+
+func init() {
+	registerPlugin(SlowReadPlugin{})
+}
+
+// SlowReadResult reports how a request body was consumed
+type SlowReadResult struct {
+	BytesRead  int64  `json:"bytes_read"`
+	Rate       int    `json:"rate"`
+	Duration   string `json:"duration"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// SlowReadHandler consumes the POST body at a throttled rate, given in bytes
+// per second by the rate parameter (default: 1024), and reports how many
+// bytes were read and how long it took. This keeps the client's upload
+// stalled so its write timeout handling can be tested. Reading stops when the
+// client disconnects.
+//
+// The server's read and write deadlines are pushed back with every chunk, so
+// a body may take as long as its size and rate require; only a client that
+// sends nothing for slowReadIdleTimeout is cut off.
+func SlowReadHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeErrorResponse(w, r, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed, use POST")
+		return
+	}
+
+	rate := defaultSlowReadRate
+	if val := r.URL.Query().Get("rate"); val != "" {
+		parsed, err := strconv.Atoi(val)
+		if err != nil || parsed < 1 {
+			writeErrorResponse(w, r, http.StatusBadRequest, errCodeBadRequest, "rate must be a positive number of bytes per second")
+			return
+		}
+		rate = parsed
+	}
+
+	body := http.MaxBytesReader(w, r.Body, maxSlowReadBody)
+	start := time.Now()
+	bytesRead, err := throttledRead(r, http.NewResponseController(w), body, rate)
+	duration := time.Since(start)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		switch {
+		case r.Context().Err() != nil:
+			// Client went away, nobody is listening for a response
+		case errors.As(err, &maxBytesErr):
+			writeErrorResponse(w, r, http.StatusRequestEntityTooLarge, errCodePayloadTooLarge, "Request body too large")
+		default:
+			writeErrorResponse(w, r, http.StatusBadRequest, errCodeBadRequest, "Failed to read request body: "+err.Error())
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	result := SlowReadResult{
+		BytesRead:  bytesRead,
+		Rate:       rate,
+		Duration:   duration.String(),
+		DurationMs: duration.Milliseconds(),
+	}
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, errCodeInternal, "Failed to encode response")
+	}
+}
+
+// throttledRead reads body until EOF at roughly rate bytes per second. Small
+// chunks are read ten times per second so the client sees a steady trickle
+// instead of bursts. Before every chunk the connection deadlines are extended
+// by slowReadIdleTimeout; not every ResponseWriter supports deadlines, in
+// which case the server defaults apply.
+func throttledRead(r *http.Request, rc *http.ResponseController, body io.Reader, rate int) (int64, error) {
+	chunk := make([]byte, max(rate/10, 1))
+	start := time.Now()
+	var total int64
+
+	for {
+		deadline := time.Now().Add(slowReadIdleTimeout)
+		rc.SetReadDeadline(deadline)
+		rc.SetWriteDeadline(deadline)
+		n, err := body.Read(chunk)
+		total += int64(n)
+		if err == io.EOF {
+			return total, nil
+		}
+		if err != nil {
+			return total, err
+		}
+
+		// Wait until the bytes read so far match the allowed rate
+		due := time.Duration(float64(total) / float64(rate) * float64(time.Second))
+		if err := sleepContext(r.Context(), due-time.Since(start)); err != nil {
+			return total, err
+		}
+	}
+}
+
+// OpenAPISpec returns the OpenAPI specification for the slow read endpoint
+func (p SlowReadPlugin) OpenAPISpec() OpenAPIPathSpec {
+	params := []OpenAPIParameter{
+		{
+			Name:        "rate",
+			In:          "query",
+			Description: "Read rate in bytes per second (default: 1024)",
+			Required:    false,
+			Schema: &OpenAPISchema{
+				Type:    "integer",
+				Minimum: &[]int{1}[0],
+				Example: 1024,
+			},
+		},
+	}
+	params = append(params, expectContinueOpenAPIParameters()...)
+
+	return OpenAPIPathSpec{
+		Path: "/slow-read",
+		Operation: OpenAPIPath{
+			Post: &OpenAPIOperation{
+				OperationID: "postSlowRead",
+				Summary:     "Read the request body slowly",
+				Description: "Consumes the request body at a throttled rate to test client write timeouts, then reports the bytes read and the time taken. The server's 30s read timeout still applies",
+				Tags:        []string{"resilience"},
+				Parameters:  params,
+				RequestBody: &OpenAPIRequestBody{
+					Description: "Arbitrary data, up to 100 MiB",
+					Content: map[string]OpenAPIMediaType{
+						"application/octet-stream": {
+							Schema: &OpenAPISchema{
+								Type:   "string",
+								Format: "binary",
+							},
+						},
+					},
+				},
+				Responses: map[string]OpenAPIResponse{
+					"200": {
+						Description: "Body fully read",
+						Content: map[string]OpenAPIMediaType{
+							"application/json": {
+								Schema: &OpenAPISchema{
+									Type: "object",
+									Properties: map[string]*OpenAPISchema{
+										"bytes_read":  {Type: "integer", Description: "Number of body bytes read"},
+										"rate":        {Type: "integer", Description: "Effective read rate in bytes per second"},
+										"duration":    {Type: "string", Description: "Time taken to read the body"},
+										"duration_ms": {Type: "integer", Description: "Time taken in milliseconds"},
+									},
+								},
+								Example: SlowReadResult{BytesRead: 2048, Rate: 1024, Duration: "2.001s", DurationMs: 2001},
+							},
+						},
+					},
+					"400": {
+						Description: "Bad request - invalid rate or failed body read",
+						Content: map[string]OpenAPIMediaType{
+							"text/plain": {
+								Schema: &OpenAPISchema{
+									Type:    "string",
+									Example: "rate must be a positive number of bytes per second",
+								},
+							},
+						},
+					},
+					"405": {
+						Description: "Method not allowed - only POST is supported",
+					},
+					"413": {
+						Description: "Request body larger than 100 MiB",
+					},
+					"417": expectContinueOpenAPIResponse(),
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSlowReadHandler(t *testing.T) {
+	body := strings.Repeat("x", 2000)
+	req := httptest.NewRequest("POST", "/slow-read?rate=10000", strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	start := time.Now()
+	SlowReadHandler(w, req)
+	elapsed := time.Since(start)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var result SlowReadResult
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse JSON response: %v", err)
+	}
+	if result.BytesRead != 2000 {
+		t.Errorf("Expected 2000 bytes read, got %d", result.BytesRead)
+	}
+
+	// 2000 bytes at 10000 bytes/s take 200ms
+	if elapsed < 180*time.Millisecond || result.DurationMs < 180 {
+		t.Errorf("Expected throttled read of ~200ms, took %v (reported %dms)", elapsed, result.DurationMs)
+	}
+	if elapsed > 2*time.Second {
+		t.Errorf("Throttled read took too long: %v", elapsed)
+	}
+}
+
+func TestSlowReadHandler_InvalidRequests(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		url      string
+		expected int
+	}{
+		{"GET not allowed", "GET", "/slow-read", http.StatusMethodNotAllowed},
+		{"invalid rate", "POST", "/slow-read?rate=fast", http.StatusBadRequest},
+		{"zero rate", "POST", "/slow-read?rate=0", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			SlowReadHandler(w, httptest.NewRequest(tt.method, tt.url, strings.NewReader("data")))
+			if w.Code != tt.expected {
+				t.Errorf("Expected status %d, got %d", tt.expected, w.Code)
+			}
+		})
+	}
+}
+
+func TestSlowReadHandler_ClientDisconnect(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// 10000 bytes at 100 bytes/s would take 100s
+	req := httptest.NewRequest("POST", "/slow-read?rate=100", strings.NewReader(strings.Repeat("x", 10000))).WithContext(ctx)
+	w := httptest.NewRecorder()
+
+	start := time.Now()
+	SlowReadHandler(w, req)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected reading to stop after disconnect, took %v", elapsed)
+	}
+}
+
+// TestSlowReadHandler_ServerTimeouts checks that a body taking longer to
+// read than the server's read and write timeouts still succeeds
+func TestSlowReadHandler_ServerTimeouts(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(SlowReadHandler))
+	server.Config.ReadTimeout = 200 * time.Millisecond
+	server.Config.WriteTimeout = 200 * time.Millisecond
+	server.Start()
+	defer server.Close()
+
+	// 1500 bytes at 2000 bytes/s take ~750ms
+	resp, err := http.Post(server.URL+"/slow-read?rate=2000", "application/octet-stream", strings.NewReader(strings.Repeat("x", 1500)))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", resp.StatusCode, body)
+	}
+	var result SlowReadResult
+	if err := json.Unmarshal(body, &result); err != nil || result.BytesRead != 1500 {
+		t.Errorf("Expected 1500 bytes read, got %+v (%v)", result, err)
+	}
+	if result.DurationMs < 500 {
+		t.Errorf("Expected the read to outlast the 200ms server timeouts, took %dms", result.DurationMs)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// callbackTimeout bounds how long a completion callback may take
+const callbackTimeout = 10 * time.Second
+
+// StreamCompletion is the payload POSTed to the callback_url once a stream ends
+type StreamCompletion struct {
+	Endpoint           string    `json:"endpoint"`
+	ItemsRequested     int       `json:"items_requested"`
+	ItemsSent          int       `json:"items_sent"`
+	Duration           string    `json:"duration"`
+	DurationMs         int64     `json:"duration_ms"`
+	ClientDisconnected bool      `json:"client_disconnected"`
+	CompletedAt        time.Time `json:"completed_at"`
+}
+
+// getCallbackURL parses the callback_url query parameter. It returns an empty
+// string if no callback was requested and an error if the URL is not an
+// absolute http or https URL.
+func getCallbackURL(r *http.Request) (string, error) {
+	raw := r.URL.Query().Get("callback_url")
+	if raw == "" {
+		return "", nil
+	}
+
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return "", fmt.Errorf("callback_url must be an absolute http or https URL")
+	}
+	return parsed.String(), nil
+}
+
+// sendStreamCallback POSTs the completion payload to the callback URL.
+// Failures are logged and otherwise ignored since the stream has already
+// finished. It is meant to be run in its own goroutine.
+func sendStreamCallback(callbackURL string, completion StreamCompletion) {
+	body, err := json.Marshal(completion)
+	if err != nil {
+		log.Printf("Warning: Failed to encode stream completion callback: %v", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), callbackTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, callbackURL, bytes.NewReader(body))
+	if err != nil {
+		log.Printf("Warning: Failed to create stream completion callback: %v", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log.Printf("Warning: Stream completion callback to %s failed: %v", callbackURL, err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		log.Printf("Warning: Stream completion callback to %s returned %s", callbackURL, resp.Status)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// maxChunkBytes bounds the chunk_bytes parameter
+const maxChunkBytes = 1 << 20
+
+// tinyChunkBytes is the flush size of bounded responses with tiny_chunks=true
+const tinyChunkBytes = 16
+
+// isTinyChunks checks if the request asks for the body in tiny flushed
+// chunks, to stress the client's chunk reassembly
+func isTinyChunks(r *http.Request) bool {
+	return r.URL.Query().Get("tiny_chunks") == "true"
+}
+
+// getChunkBytes parses the chunk_bytes parameter, 0 (default) keeps the
+// per-item batch flushing
+func getChunkBytes(r *http.Request) (int, error) {
+	val := r.URL.Query().Get("chunk_bytes")
+	if val == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(val)
+	if err != nil || n < 0 || n > maxChunkBytes {
+		return 0, fmt.Errorf("chunk_bytes must be between 0 and %d", maxChunkBytes)
+	}
+	return n, nil
+}
+
+// chunkFlusher buffers streamed output and sends it to the client in flushes
+// of exactly size bytes, independent of item boundaries
+type chunkFlusher struct {
+	w       io.Writer
+	flusher http.Flusher
+	size    int
+	buf     []byte
+}
+
+// newChunkFlusher creates a chunkFlusher writing to w
+func newChunkFlusher(w io.Writer, flusher http.Flusher, size int) *chunkFlusher {
+	return &chunkFlusher{w: w, flusher: flusher, size: size, buf: make([]byte, 0, 2*size)}
+}
+
+// Write buffers p and flushes every complete chunk. Each chunk is handed to
+// the underlying writer in a single call so it is sent as one unit.
+func (c *chunkFlusher) Write(p []byte) (int, error) {
+	c.buf = append(c.buf, p...)
+	for len(c.buf) >= c.size {
+		if _, err := c.w.Write(c.buf[:c.size]); err != nil {
+			return 0, err
+		}
+		c.flusher.Flush()
+		c.buf = c.buf[:copy(c.buf, c.buf[c.size:])]
+	}
+	return len(p), nil
+}
+
+// Flush sends the buffered remainder, which may be shorter than a chunk
+func (c *chunkFlusher) Flush() {
+	if len(c.buf) > 0 {
+		if _, err := c.w.Write(c.buf); err != nil {
+			return
+		}
+		c.buf = c.buf[:0]
+	}
+	c.flusher.Flush()
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Envelopes supported by the streaming endpoint's envelope parameter
+const (
+	EnvelopeArray  = "array"  // A bare JSON array of items (default)
+	EnvelopeObject = "object" // {"result":[...],"metadata":{...}} like /paginated_payload
+)
+
+// StreamMetadata is appended after the items when streaming with envelope=object
+type StreamMetadata struct {
+	TotalCount int    `json:"total_count"` // Items requested for this stream
+	ItemsSent  int    `json:"items_sent"`  // Items actually sent
+	Complete   bool   `json:"complete"`    // False if the stream ended early
+	Duration   string `json:"duration"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// getEnvelope parses the envelope query parameter
+func getEnvelope(r *http.Request) (string, error) {
+	envelope := strings.ToLower(r.URL.Query().Get("envelope"))
+	switch envelope {
+	case "":
+		return EnvelopeArray, nil
+	case EnvelopeArray, EnvelopeObject:
+		return envelope, nil
+	default:
+		return "", fmt.Errorf("envelope must be one of: %s, %s", EnvelopeArray, EnvelopeObject)
+	}
+}
+
+// streamEnvelopeStart returns the bytes that open the streamed document.
+// Inline JSON styles keep the whole document on one line, otherwise every
+// item starts on its own line.
+func streamEnvelopeStart(envelope string, style JSONStyle) string {
+	switch envelope {
+	case EnvelopeObject:
+		switch style {
+		case JSONStyleCompact:
+			return "{\"result\":["
+		case JSONStyleSpaced:
+			return "{\"result\": ["
+		}
+		return "{\"result\":[\n"
+	case EnvelopeJSONRPC, EnvelopeGRPCWeb:
+		return ""
+	}
+	if style.inline() {
+		return "["
+	}
+	return "[\n"
+}
+
+// streamItemSeparator returns the bytes written between two streamed items
+func streamItemSeparator(envelope string, style JSONStyle) string {
+	switch envelope {
+	case EnvelopeJSONRPC:
+		return "\n"
+	case EnvelopeGRPCWeb:
+		return ""
+	}
+	if style.inline() {
+		return style.arraySeparator()
+	}
+	return ",\n"
+}
+
+// wrapStreamItem frames an encoded item for formats that wrap every item:
+// a JSON-RPC notification or a gRPC-Web data frame
+func wrapStreamItem(envelope string, item []byte) []byte {
+	switch envelope {
+	case EnvelopeJSONRPC:
+		return wrapJSONRPCNotification(item)
+	case EnvelopeGRPCWeb:
+		return grpcWebFrame(grpcWebDataFrame, item)
+	}
+	return item
+}
+
+// writeStreamEnvelopeEnd closes the item array and, for envelope=object,
+// appends the metadata and closes the object. JSON-RPC streams only end
+// their last line, gRPC-Web streams end with the trailer frame.
+func writeStreamEnvelopeEnd(w io.Writer, envelope string, style JSONStyle, itemsRequested, itemsSent int, elapsed time.Duration) error {
+	switch envelope {
+	case EnvelopeJSONRPC:
+		_, err := io.WriteString(w, "\n")
+		return err
+	case EnvelopeGRPCWeb:
+		_, err := w.Write(grpcWebFrame(grpcWebTrailerFrame, []byte(grpcWebTrailers)))
+		return err
+	}
+	if envelope != EnvelopeObject {
+		end := "\n]"
+		if style.inline() {
+			end = "]"
+		}
+		_, err := io.WriteString(w, end)
+		return err
+	}
+
+	metadata, err := json.Marshal(StreamMetadata{
+		TotalCount: itemsRequested,
+		ItemsSent:  itemsSent,
+		Complete:   itemsSent == itemsRequested,
+		Duration:   elapsed.String(),
+		DurationMs: elapsed.Milliseconds(),
+	})
+	if err != nil {
+		return err
+	}
+	switch style {
+	case JSONStyleCompact:
+		_, err = fmt.Fprintf(w, "],\"metadata\":%s}\n", metadata)
+	case JSONStyleSpaced:
+		_, err = fmt.Fprintf(w, "], \"metadata\": %s}\n", appendSpacedJSON(nil, metadata))
+	default:
+		_, err = fmt.Fprintf(w, "\n],\"metadata\":%s}\n", metadata)
+	}
+	return err
+}
+
+// streamEnvelopeOverhead estimates the bytes the envelope adds around the
+// items, for dry-run size estimates
+func streamEnvelopeOverhead(envelope string, itemCount int) int {
+	var end strings.Builder
+	_ = writeStreamEnvelopeEnd(&end, envelope, JSONStyleDefault, itemCount, itemCount, 0)
+	return len(streamEnvelopeStart(envelope, JSONStyleDefault)) + end.Len()
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"encoding/binary"
+)
+
+// StreamFormatGRPCWeb frames every item as a gRPC-Web data frame
+const StreamFormatGRPCWeb = "grpc-web"
+
+// EnvelopeGRPCWeb frames the stream as gRPC-Web messages. It is selected
+// with format=grpc-web, not via envelope.
+const EnvelopeGRPCWeb = "grpc-web"
+
+// grpcWebContentType labels gRPC-Web streams whose messages are JSON
+// encoded instead of protobuf
+const grpcWebContentType = "application/grpc-web+json"
+
+// gRPC-Web frame flags: data frames carry a message, the trailer frame ends
+// the stream with the status as HTTP/1-style header lines
+const (
+	grpcWebDataFrame    byte = 0x00
+	grpcWebTrailerFrame byte = 0x80
+)
+
+// grpcWebTrailers ends a successful gRPC-Web stream
+const grpcWebTrailers = "grpc-status: 0\r\ngrpc-message: \r\n"
+
+// grpcWebFrame prefixes payload with the 5 byte gRPC-Web frame header: the
+// flag byte and the payload length as a big-endian uint32
+func grpcWebFrame(flag byte, payload []byte) []byte {
+	frame := make([]byte, 5, 5+len(payload))
+	frame[0] = flag
+	binary.BigEndian.PutUint32(frame[1:5], uint32(len(payload)))
+	return append(frame, payload...)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Formats supported by the streaming endpoint's format parameter
+const (
+	StreamFormatJSON    = "json"    // A JSON document framed by the envelope parameter (default)
+	StreamFormatJSONRPC = "jsonrpc" // One JSON-RPC 2.0 notification per line
+)
+
+// jsonRPCMethod is the method name of the streamed JSON-RPC notifications
+const jsonRPCMethod = "item"
+
+// EnvelopeJSONRPC frames the stream as newline-delimited JSON-RPC
+// notifications. It is selected with format=jsonrpc, not via envelope.
+const EnvelopeJSONRPC = "jsonrpc"
+
+// getStreamEnvelope parses the format and envelope query parameters into the
+// framing of the stream. format=jsonrpc and format=grpc-web cannot be
+// combined with an envelope.
+func getStreamEnvelope(r *http.Request) (string, error) {
+	envelope, err := getEnvelope(r)
+	if err != nil {
+		return "", err
+	}
+	switch format := strings.ToLower(r.URL.Query().Get("format")); format {
+	case "", StreamFormatJSON:
+		return envelope, nil
+	case StreamFormatJSONRPC, StreamFormatGRPCWeb:
+		if r.URL.Query().Has("envelope") {
+			return "", fmt.Errorf("envelope cannot be combined with format=%s", format)
+		}
+		// The format names double as envelope names
+		return format, nil
+	default:
+		return "", fmt.Errorf("format must be one of: %s, %s, %s", StreamFormatJSON, StreamFormatJSONRPC, StreamFormatGRPCWeb)
+	}
+}
+
+// wrapJSONRPCNotification wraps an encoded item as the params of a JSON-RPC
+// 2.0 notification. Notifications carry no id, so clients must not reply.
+func wrapJSONRPCNotification(item []byte) []byte {
+	buf := make([]byte, 0, len(item)+48)
+	buf = append(buf, `{"jsonrpc":"2.0","method":"`+jsonRPCMethod+`","params":`...)
+	buf = append(buf, item...)
+	return append(buf, '}')
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/json"
+	"flag"
 	"fmt"
+	"io"
 	"math/big"
 	"net/http"
 	"strconv"
@@ -12,6 +14,13 @@ import (
 	"time"
 )
 
+// paramStreamDefaultDelay is the base delay between items when delay is omitted
+var paramStreamDefaultDelay = flag.Duration("stream-default-delay", 10*time.Millisecond, "Base delay between /stream_payload items when delay is omitted")
+
+// truncatedTrailer is the HTTP trailer reporting whether max_duration cut
+// the stream short
+const truncatedTrailer = "X-PayloadBuddy-Truncated"
+
 // StreamItem represents a single object in the streamed JSON payload
 type StreamItem struct {
 	ID        int       `json:"id"`
@@ -33,6 +42,24 @@ const (
 	BurstDelay
 )
 
+// String returns the query parameter name of the delay strategy
+func (d DelayStrategy) String() string {
+	switch d {
+	case NoDelay:
+		return "none"
+	case FixedDelay:
+		return "fixed"
+	case RandomDelay:
+		return "random"
+	case ProgressiveDelay:
+		return "progressive"
+	case BurstDelay:
+		return "burst"
+	default:
+		return "unknown"
+	}
+}
+
 // secureRandFloat32 generates a cryptographically secure random float32 between 0 and 1
 func secureRandFloat32() (float32, error) {
 	n, err := rand.Int(rand.Reader, big.NewInt(1<<24))
@@ -66,18 +93,26 @@ func getDurationParam(r *http.Request, param string, defaultValue time.Duration)
 	if val == "" {
 		return defaultValue
 	}
+	if duration, ok := parseDurationValue(val); ok {
+		return duration
+	}
+	return defaultValue
+}
 
+// parseDurationValue parses a Go duration (e.g., "100ms", "1s") or a plain
+// number of milliseconds
+func parseDurationValue(val string) (time.Duration, bool) {
 	// Try parsing as duration first (e.g., "100ms", "1s")
 	if duration, err := time.ParseDuration(val); err == nil {
-		return duration
+		return duration, true
 	}
 
 	// Fallback: parse as milliseconds
 	if ms, err := strconv.Atoi(val); err == nil {
-		return time.Duration(ms) * time.Millisecond
+		return time.Duration(ms) * time.Millisecond, true
 	}
 
-	return defaultValue
+	return 0, false
 }
 
 // Helper function to parse integer parameters
@@ -111,6 +146,53 @@ func getDelayStrategy(r *http.Request) DelayStrategy {
 	}
 }
 
+// Helper function to parse a probability parameter such as slow_rate, the
+// fraction of items that are delayed, or reset_rate
+func getRateParam(r *http.Request, name string, defaultRate float64) (float64, error) {
+	val := r.URL.Query().Get(name)
+	if val == "" {
+		return defaultRate, nil
+	}
+	rate, err := strconv.ParseFloat(val, 64)
+	if err != nil || rate < 0 || rate > 1 {
+		return 0, fmt.Errorf("%s must be between 0.0 and 1.0", name)
+	}
+	return rate, nil
+}
+
+// Helper function to parse the scenario name. The scenario query parameter
+// takes precedence over the X-Scenario header for clients that cannot easily
+// append query parameters. A "random:" selection is resolved to one of its
+// scenarios (see resolveScenario). Scenarios denied by -allow-scenarios or
+// -deny-scenarios yield errScenarioNotAllowed.
+func getScenarioParam(r *http.Request) (string, error) {
+	scenario := r.URL.Query().Get("scenario")
+	if scenario == "" {
+		scenario = r.Header.Get("X-Scenario")
+	}
+	scenario = strings.ToLower(strings.TrimSpace(scenario))
+	if err := checkScenarioAccess(scenario); err != nil {
+		return "", err
+	}
+	return resolveScenario(scenario)
+}
+
+// Helper function to enforce a scenario's max_concurrent limit. If the limit
+// is reached a 503 response (a ServiceNow error envelope in ServiceNow mode)
+// is sent and ok is false; otherwise release must be called once the request
+// is done.
+func acquireScenarioSlot(w http.ResponseWriter, scenario string, serviceNow bool) (release func(), ok bool) {
+	if scenarioManager == nil || scenario == "" {
+		return func() {}, true
+	}
+	release, ok = scenarioManager.AcquireScenarioSlot(scenario)
+	if !ok {
+		w.Header().Set("Retry-After", "1")
+		writeError(w, fmt.Sprintf("Scenario %s is at its concurrent request limit", scenario), http.StatusServiceUnavailable, serviceNow)
+	}
+	return release, ok
+}
+
 // Helper function to generate ServiceNow-style sys_id
 func generateSysID() string {
 	chars := "abcdef0123456789"
@@ -127,16 +209,141 @@ func generateSysID() string {
 	return string(result)
 }
 
-// Helper function to apply delay based on strategy and scenario
-func applyDelay(ctx context.Context, strategy DelayStrategy, baseDelay time.Duration, scenario string, itemIndex int) error {
+// marshalStreamItem encodes an item, using the map form only when the
+// timestamp or key order is customized and the scenario's item template if
+// there is one
+func marshalStreamItem(item StreamItem, timestampOpts TimestampOptions) ([]byte, error) {
+	if timestampOpts.IsDefault() {
+		return json.Marshal(item)
+	}
+	if timestampOpts.Template != nil {
+		return renderItemTemplate(timestampOpts.Template, item)
+	}
+	if timestampOpts.ShuffleFields {
+		return marshalShuffled(timestampOpts.itemMap(item))
+	}
+	return json.Marshal(timestampOpts.itemMap(item))
+}
+
+// writeStreamingDryRun reports the size and duration a streaming request
+// would produce, without streaming any items
+func writeStreamingDryRun(w http.ResponseWriter, r *http.Request, count, start int, baseDelay time.Duration, strategy DelayStrategy, slowRate float64, envelope, scenario string, batchSize int, itemOpts itemOptions, timestampOpts TimestampOptions) {
+	first, err := marshalStreamItem(generateItem(itemOpts, start), timestampOpts)
+	if err != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, errCodeInternal, "JSON encoding failed")
+		return
+	}
+	last, err := marshalStreamItem(generateItem(itemOpts, count-1), timestampOpts)
+	if err != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, errCodeInternal, "JSON encoding failed")
+		return
+	}
+	first, last = wrapStreamItem(envelope, first), wrapStreamItem(envelope, last)
+
+	itemCount := count - start
+	summary := DryRunSummary{
+		Endpoint:            r.URL.Path,
+		ItemCount:           itemCount,
+		EstimatedBytes:      estimateArrayBytes(first, last, itemCount, len(streamItemSeparator(envelope, JSONStyleDefault)), streamEnvelopeOverhead(envelope, itemCount)),
+		EffectiveParameters: streamingEffectiveParams(count, start, baseDelay, strategy, slowRate, envelope, scenario, batchSize, itemOpts, timestampOpts),
+	}
+	// Only the slow fraction of items is delayed
+	estimated := estimateStreamDelay(strategy, baseDelay, scenario, start, count)
+	summary.setEstimatedDuration(time.Duration(float64(estimated) * slowRate))
+	writeDryRunSummary(w, r, summary)
+}
+
+// streamingEffectiveParams lists the resolved streaming parameters for the
+// dry-run summary and the X-PayloadBuddy-Effective header
+func streamingEffectiveParams(count, start int, baseDelay time.Duration, strategy DelayStrategy, slowRate float64, envelope, scenario string, batchSize int, itemOpts itemOptions, timestampOpts TimestampOptions) map[string]interface{} {
+	params := map[string]interface{}{
+		"count":            count,
+		"start":            start,
+		"id_start":         itemOpts.IDStart,
+		"delay":            baseDelay.String(),
+		"strategy":         strategy.String(),
+		"slow_rate":        slowRate,
+		"envelope":         envelope,
+		"scenario":         scenario,
+		"batch_size":       batchSize,
+		"servicenow":       itemOpts.ServiceNow,
+		"timestamp_field":  timestampOpts.Field,
+		"timestamp_format": timestampOpts.Format,
+		"shuffle_fields":   timestampOpts.ShuffleFields,
+		"string_ids":       timestampOpts.StringIDs,
+	}
+	if timestampOpts.NullRate > 0 {
+		params["null_rate"] = timestampOpts.NullRate
+	}
+	if itemOpts.Size.enabled() {
+		params["size_variation"] = itemOpts.Size.Variation
+		params["value_size"] = itemOpts.Size.ValueSize
+	}
+	if itemOpts.Size.Seeded {
+		params["seed"] = itemOpts.Size.Seed
+	}
+	if itemOpts.Cardinality > 0 {
+		params["cardinality"] = itemOpts.Cardinality
+	}
+	if itemOpts.ValueTemplate != nil {
+		params["value_template"] = itemOpts.ValueTemplate.Root.String()
+	}
+	if envelope == EnvelopeJSONRPC || envelope == EnvelopeGRPCWeb {
+		delete(params, "envelope")
+		params["format"] = envelope
+	}
+	if !itemOpts.FixedTimestamp.IsZero() {
+		params["fixed_timestamp"] = itemOpts.FixedTimestamp.Format(time.RFC3339)
+	}
+	return params
+}
+
+// calculateStrategyDelay derives the delay for an item from a base delay
+// using the given delay strategy
+func calculateStrategyDelay(strategy DelayStrategy, baseDelay time.Duration, itemIndex int) time.Duration {
+	switch strategy {
+	case NoDelay:
+		return 0
+	case RandomDelay:
+		if baseDelay <= 0 {
+			return 0
+		}
+		randInt64, err := secureRandInt63n(int64(baseDelay * 2))
+		if err != nil {
+			return baseDelay // Fallback to fixed delay if crypto/rand fails
+		}
+		return time.Duration(randInt64)
+	case ProgressiveDelay:
+		return baseDelay * time.Duration(itemIndex/1000+1)
+	case BurstDelay:
+		if itemIndex%100 == 0 && itemIndex > 0 {
+			return baseDelay * 10 // Long pause after burst
+		}
+		return baseDelay / 10 // Short pause between items
+	default:
+		return baseDelay
+	}
+}
+
+// Helper function to apply delay based on strategy and scenario. With a
+// slowRate below 1 only that random fraction of items is delayed; all other
+// items are sent without delay.
+func applyDelay(ctx context.Context, strategy DelayStrategy, baseDelay time.Duration, scenario string, itemIndex int, slowRate float64) error {
+	if !rollProbability(slowRate) {
+		return nil
+	}
+
 	var delay time.Duration
 
 	// Check if we have a scenario configured
 	if scenarioManager != nil && scenario != "" {
 		calculatedDelay, calculatedStrategy := scenarioManager.GetScenarioDelay(scenario, itemIndex)
 
-		// For network_issues scenario, we still need to apply random logic
-		if scenario == "network_issues" {
+		// Percentile targets replace the scenario's own delay calculation
+		if dist := scenarioManager.GetLatencyDistribution(scenario); dist != nil {
+			delay = dist.Sample()
+		} else if scenarioManager.GetScenarioType(scenario) == "network_issues" && calculatedStrategy == RandomDelay {
+			// The random strategy of network_issues adds occasional spikes
 			randFloat, err := secureRandFloat32()
 			if err != nil {
 				delay = calculatedDelay
@@ -151,8 +358,8 @@ func applyDelay(ctx context.Context, strategy DelayStrategy, baseDelay time.Dura
 				delay = calculatedDelay
 			}
 		} else {
-			delay = calculatedDelay
-			strategy = calculatedStrategy
+			// Any other strategy is applied on top of the calculated delay
+			delay = calculateStrategyDelay(calculatedStrategy, calculatedDelay, itemIndex)
 		}
 	} else {
 		// Fallback to legacy hardcoded scenario logic for backward compatibility
@@ -182,29 +389,12 @@ func applyDelay(ctx context.Context, strategy DelayStrategy, baseDelay time.Dura
 		case "database_load":
 			dbLoadDelay := time.Duration(itemIndex/100) * 10 * time.Millisecond
 			delay = baseDelay + dbLoadDelay
+		case "recovery":
+			remaining := max(1000-itemIndex, 0)
+			delay = baseDelay + time.Duration((remaining+99)/100)*50*time.Millisecond
 		default:
 			// Apply strategy-based delay
-			switch strategy {
-			case NoDelay:
-				return nil
-			case FixedDelay:
-				delay = baseDelay
-			case RandomDelay:
-				randInt64, err := secureRandInt63n(int64(baseDelay * 2))
-				if err != nil {
-					delay = baseDelay // Fallback to fixed delay if crypto/rand fails
-				} else {
-					delay = time.Duration(randInt64)
-				}
-			case ProgressiveDelay:
-				delay = baseDelay * time.Duration(itemIndex/1000+1)
-			case BurstDelay:
-				if itemIndex%100 == 0 && itemIndex > 0 {
-					delay = baseDelay * 10 // Long pause after burst
-				} else {
-					delay = baseDelay / 10 // Short pause between items
-				}
-			}
+			delay = calculateStrategyDelay(strategy, baseDelay, itemIndex)
 		}
 	}
 
@@ -215,62 +405,107 @@ func applyDelay(ctx context.Context, strategy DelayStrategy, baseDelay time.Dura
 			return nil
 		case FixedDelay:
 			// delay already set
-		case RandomDelay:
-			randInt64, err := secureRandInt63n(int64(baseDelay * 2))
-			if err != nil {
-				delay = baseDelay // Fallback to fixed delay if crypto/rand fails
-			} else {
-				delay = time.Duration(randInt64)
-			}
-		case ProgressiveDelay:
-			delay = baseDelay * time.Duration(itemIndex/1000+1)
-		case BurstDelay:
-			if itemIndex%100 == 0 && itemIndex > 0 {
-				delay = baseDelay * 10 // Long pause after burst
-			} else {
-				delay = baseDelay / 10 // Short pause between items
-			}
+		default:
+			delay = calculateStrategyDelay(strategy, baseDelay, itemIndex)
 		}
 	}
 
-	if delay <= 0 {
+	return sleepContext(ctx, delay)
+}
+
+// sleepContext pauses for the given duration or until the context is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
 		return nil
 	}
 
 	// Context-aware delay
 	select {
-	case <-time.After(delay):
+	case <-time.After(d):
 		return nil
 	case <-ctx.Done():
 		return ctx.Err()
 	}
 }
 
+// getTTFB returns the artificial time-to-first-byte requested via the ttfb
+// query parameter, falling back to the scenario's setup_delay. It is
+// independent of the inter-item delay.
+func getTTFB(r *http.Request, scenario string) time.Duration {
+	if r.URL.Query().Has("ttfb") || scenarioManager == nil || scenario == "" {
+		return getDurationParam(r, "ttfb", 0)
+	}
+	return scenarioManager.GetSetupDelay(scenario)
+}
+
+// waitTTFB applies the time-to-first-byte returned by getTTFB
+func waitTTFB(r *http.Request, scenario string) error {
+	return sleepContext(r.Context(), getTTFB(r, scenario))
+}
+
 // StreamingPayloadHandler streams large JSON data in chunks with configurable delays
 //
 // Query Parameters:
 //   - count: Number of items to stream (default: 10000)
-//   - delay: Base delay between items (e.g., "100ms", "1s", or milliseconds as integer)
+//   - delay: Base delay between items (e.g., "100ms", "1s", or milliseconds as integer, default: -stream-default-delay, 10ms)
 //   - strategy: Delay strategy ("fixed", "random", "progressive", "burst")
-//   - scenario: ServiceNow scenarios ("peak_hours", "maintenance", "network_issues", "database_load")
+//   - scenario: ServiceNow scenarios ("peak_hours", "maintenance", "network_issues", "database_load", "recovery", "error_storm", "slow_connect", "circuit_breaker", "token_expiry"), "<scenario>,<scenario>,..." to layer several, or "random:<scenario>=<weight>,..." to pick one per request
 //   - batch_size: Items per flush batch (default: 100)
 //   - servicenow: Generate ServiceNow-style fields (default: false)
+//   - start: Absolute item position to start streaming from, for resuming (default: 0)
+//   - pretty: Indent items for readability (default: false, compact)
+//   - json_style: Whitespace between tokens ("compact", "spaced" or "pretty", overrides pretty); compact and spaced stream on a single line
+//   - reset_rate: Probability (0.0-1.0) per item of abruptly closing the connection, leaving the body truncated (default: 0)
+//   - chunk_bytes: Flush the body in chunks of exactly this many bytes instead of per batch_size items (default: 0, off)
+//   - max_duration: End the stream cleanly after this wall-clock time, reporting truncation in the X-PayloadBuddy-Truncated trailer (e.g., "30s")
+//   - final_delay: Wait this long after the last item before closing the array, like a backend that is slow to finalize (e.g., "5s", default: 0)
+//   - bandwidth: Pace the body to at most this many bytes per second, like a bandwidth-limited link (e.g., "1MBps", "500KBps", default: unlimited)
+//   - envelope: "array" for a bare JSON array (default) or "object" for {"result":[...],"metadata":{...}}
+//   - format: "json" (default), "jsonrpc" for one JSON-RPC 2.0 notification per line, {"jsonrpc":"2.0","method":"item","params":{...}},
+//     or "grpc-web" for one length-prefixed gRPC-Web frame per JSON item (application/grpc-web+json)
+//   - slow_rate: Fraction of items that get the delay, the rest are sent immediately (default: 1.0)
+//   - id_start: ID of the item at position 0 (default: 0; /rest_payload and /paginated_payload default to 1)
+//   - value_template: Go template for item values (e.g., "User-{{.ID}}")
+//   - timestamp_field: JSON key for the item timestamp (default: "timestamp")
+//   - timestamp_format: Timestamp format ("rfc3339" or "epoch" milliseconds, default: "rfc3339")
+//   - shuffle_fields: Emit item keys in a random order per item (default: false)
+//   - string_ids: Emit the item id as a JSON string (default: false)
+//   - null_rate: Probability (0.0-1.0) of each item field except id being null (default: 0)
+//   - size_variation: Pad item values to a random length within this percentage around value_size (default: 0, off)
+//   - value_size: Base value length in bytes for size_variation (default: 256)
+//   - seed: Derives values, sys_ids and size_variation lengths from the seed, item ID and field name, reproducibly (default: none, or a random seed reported in X-PayloadBuddy-Seed if the scenario sets random_seed)
+//   - cardinality: Cycle item values and ServiceNow states through this many distinct values (default: one per item)
+//   - fixed_timestamp: RFC 3339 time used for all item timestamps instead of the current time
+//   - dry_run: Return a JSON summary of the planned stream instead of streaming (default: false)
+//   - ttfb: Time to wait after sending headers before the first body byte (default: 0, or the scenario's setup_delay)
+//   - callback_url: http(s) URL that receives a POST with completion details when the stream ends
+//   - big_header: Add an X-PayloadBuddy-Big-Header response header of this many bytes (default: none, max: 1 MiB)
 //
 // Examples:
 //   - /stream?count=1000&delay=100ms&strategy=random
 //   - /stream?scenario=peak_hours&servicenow=true
 //   - /stream?delay=50ms&strategy=progressive&batch_size=50
+//   - /stream?count=10000&start=5000 (resume after item 4999)
 func StreamingPayloadHandler(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	// Parse basic parameters
-	scenario := strings.ToLower(r.URL.Query().Get("scenario"))
+	scenario, err := getScenarioParam(r)
+	if err != nil {
+		writeScenarioParamError(w, r, err)
+		return
+	}
+	applyScenarioHeaders(w, scenario)
+	if err := applyBigHeader(w, r); err != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, errCodeBadRequest, err.Error())
+		return
+	}
 
 	// Get scenario-based defaults if scenario manager is available and scenario is specified
 	var defaultCount, maxCount, defaultBatchSize int
 	var defaultServiceNowMode bool
 	if scenarioManager != nil && scenario != "" {
-		defaultBatchSize, defaultServiceNowMode, maxCount, defaultCount = scenarioManager.GetScenarioConfig(scenario)
+		defaultBatchSize, _, defaultServiceNowMode, maxCount, defaultCount = scenarioManager.GetScenarioConfig(scenario)
 	} else {
 		// Use hardcoded defaults for backward compatibility
 		defaultCount = 10000
@@ -281,113 +516,272 @@ func StreamingPayloadHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Parse parameters with scenario-aware defaults
 	count := getIntParam(r, "count", defaultCount)
-	baseDelay := getDurationParam(r, "delay", 10*time.Millisecond)
+	baseDelay := getDurationParam(r, "delay", *paramStreamDefaultDelay)
 	strategy := getDelayStrategy(r)
 	batchSize := getIntParam(r, "batch_size", defaultBatchSize)
-
-	// ServiceNow mode: use scenario default unless explicitly overridden
-	serviceNowMode := defaultServiceNowMode
-	if serviceNowParam := r.URL.Query().Get("servicenow"); serviceNowParam != "" {
-		serviceNowMode = serviceNowParam == "true"
-	}
+	start := getIntParam(r, "start", 0)
 
 	// Validate parameters
 	if count <= 0 || count > maxCount {
-		http.Error(w, fmt.Sprintf("Count must be between 1 and %d", maxCount), http.StatusBadRequest)
+		writeErrorResponse(w, r, http.StatusBadRequest, errCodeBadRequest, fmt.Sprintf("Count must be between 1 and %d", maxCount))
+		return
+	}
+	if start < 0 || start >= count {
+		writeErrorResponse(w, r, http.StatusBadRequest, errCodeBadRequest, fmt.Sprintf("Start must be between 0 and %d", count-1))
+		return
+	}
+	timestampOpts, err := getTimestampOptions(r)
+	if err != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, errCodeBadRequest, err.Error())
+		return
+	}
+	if scenarioManager != nil && scenario != "" {
+		timestampOpts.Template = scenarioManager.GetItemTemplate(scenario)
+	}
+	callbackURL, err := getCallbackURL(r)
+	if err != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, errCodeBadRequest, err.Error())
+		return
+	}
+	itemOpts, err := parseItemOptions(r, 0, defaultServiceNowMode)
+	if err != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, errCodeBadRequest, err.Error())
+		return
+	}
+	applyRandomSeed(w, scenario, &itemOpts)
+	itemOpts.ValueFormat = streamItemValueFormat
+	serviceNowMode := itemOpts.ServiceNow
+	slowRate, err := getRateParam(r, "slow_rate", 1)
+	if err != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, errCodeBadRequest, err.Error())
+		return
+	}
+	resetRate, err := getRateParam(r, "reset_rate", 0)
+	if err != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, errCodeBadRequest, err.Error())
+		return
+	}
+	envelope, err := getStreamEnvelope(r)
+	if err != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, errCodeBadRequest, err.Error())
+		return
+	}
+	style, err := getJSONStyle(r)
+	if err != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, errCodeBadRequest, err.Error())
+		return
+	}
+	// Styled items would break the one-notification-per-line framing and
+	// make no sense inside binary frames
+	if envelope == EnvelopeJSONRPC || envelope == EnvelopeGRPCWeb {
+		style = JSONStyleDefault
+	}
+	chunkBytes, err := getChunkBytes(r)
+	if err != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, errCodeBadRequest, err.Error())
+		return
+	}
+	maxDuration := getDurationParam(r, "max_duration", 0)
+	finalDelay := getDurationParam(r, "final_delay", 0)
+	bandwidth, err := getBandwidth(r)
+	if err != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, errCodeBadRequest, err.Error())
+		return
+	}
+
+	setEffectiveParams(w, streamingEffectiveParams(count, start, baseDelay, strategy, slowRate, envelope, scenario, batchSize, itemOpts, timestampOpts))
+
+	// Report the planned response instead of generating it
+	if isDryRun(r) {
+		writeStreamingDryRun(w, r, count, start, baseDelay, strategy, slowRate, envelope, scenario, batchSize, itemOpts, timestampOpts)
+		return
+	}
+
+	// Enforce the scenario's concurrent request limit
+	release, ok := acquireScenarioSlot(w, scenario, serviceNowMode)
+	if !ok {
+		return
+	}
+	defer release()
+
+	// Fail the request if the scenario injects an error
+	if injectScenarioError(w, r, scenario, serviceNowMode) {
 		return
 	}
 
 	// Set headers
-	w.Header().Set("Content-Type", "application/json")
+	switch envelope {
+	case EnvelopeJSONRPC:
+		w.Header().Set("Content-Type", "application/x-ndjson")
+	case EnvelopeGRPCWeb:
+		w.Header().Set("Content-Type", grpcWebContentType)
+	default:
+		w.Header().Set("Content-Type", "application/json")
+	}
 	w.Header().Set("Transfer-Encoding", "chunked")
 	w.Header().Set("Cache-Control", "no-cache")
+	if maxDuration > 0 {
+		w.Header().Set("Trailer", truncatedTrailer)
+	}
 
 	// Get flusher for real-time streaming
 	flusher, ok := w.(http.Flusher)
 	if !ok {
-		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		writeErrorResponse(w, r, http.StatusInternalServerError, errCodeInternal, "Streaming not supported")
 		return
 	}
 
-	// Start JSON array
-	if _, err := w.Write([]byte("[\n")); err != nil {
+	// Report the outcome to the callback URL once the stream ends, however it ends
+	streamStart := time.Now()
+	itemsSent := 0
+	if callbackURL != "" {
+		defer func() {
+			elapsed := time.Since(streamStart)
+			go sendStreamCallback(callbackURL, StreamCompletion{
+				Endpoint:           r.URL.Path,
+				ItemsRequested:     count - start,
+				ItemsSent:          itemsSent,
+				Duration:           elapsed.String(),
+				DurationMs:         elapsed.Milliseconds(),
+				ClientDisconnected: ctx.Err() != nil || itemsSent < count-start,
+				CompletedAt:        time.Now(),
+			})
+		}()
+	}
+
+	// Open the connection by sending headers, then hold back the body for
+	// the requested time-to-first-byte
+	if r.URL.Query().Has("ttfb") || getTTFB(r, scenario) > 0 {
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+		if err := waitTTFB(r, scenario); err != nil {
+			return
+		}
+	}
+
+	// With chunk_bytes the body is flushed in fixed-size chunks instead of
+	// per item batch, so all output goes through the chunk flusher
+	var out io.Writer = w
+	flushOut := flusher.Flush
+	if chunkBytes > 0 {
+		chunker := newChunkFlusher(w, flusher, chunkBytes)
+		out, flushOut = chunker, chunker.Flush
+	}
+	if bandwidth > 0 {
+		out = newThrottledWriter(ctx, out, bandwidth)
+	}
+
+	// Start JSON array, or the result array inside the envelope object
+	if _, err := io.WriteString(out, streamEnvelopeStart(envelope, style)); err != nil {
 		return
 	}
-	flusher.Flush()
+	if chunkBytes == 0 {
+		flusher.Flush()
+	}
+	closeStream := func() {
+		_ = writeStreamEnvelopeEnd(out, envelope, style, count-start, itemsSent, time.Since(streamStart))
+	}
+
+	// End the stream cleanly once max_duration has elapsed, measured from
+	// the first body byte
+	streamCtx := ctx
+	if maxDuration > 0 {
+		var cancel context.CancelFunc
+		streamCtx, cancel = context.WithTimeout(ctx, maxDuration)
+		defer cancel()
+	}
+	truncated := false
 
-	// Stream items
-	for i := 0; i < count; i++ {
+	// Stream items. i is the absolute item position so that IDs and
+	// ServiceNow numbers stay stable when a client resumes via start.
+	for i := start; i < count; i++ {
 		// Check for client cancellation
 		select {
 		case <-ctx.Done():
 			// Client disconnected, clean exit
-			_, _ = w.Write([]byte("\n]"))
+			closeStream()
 			return
 		default:
 		}
-
-		// Create item
-		var item StreamItem
-		if serviceNowMode {
-			item = StreamItem{
-				ID:        i,
-				Value:     fmt.Sprintf("ServiceNow Record %d", i),
-				Timestamp: time.Now(),
-				SysID:     generateSysID(),
-				Number:    fmt.Sprintf("INC%07d", i),
-				State:     []string{"New", "In Progress", "Resolved", "Closed"}[i%4],
-			}
-		} else {
-			item = StreamItem{
-				ID:        i,
-				Value:     fmt.Sprintf("streamed data %d", i),
-				Timestamp: time.Now(),
-			}
+		if streamCtx.Err() != nil {
+			truncated = true
+			break
 		}
 
-		// Marshal item
-		data, err := json.Marshal(item)
+		// Create and marshal item
+		data, err := marshalStreamItem(generateItem(itemOpts, i), timestampOpts)
+		if err == nil {
+			data, err = style.format(data)
+		}
+		data = wrapStreamItem(envelope, data)
 		if err != nil {
-			http.Error(w, "JSON encoding failed", http.StatusInternalServerError)
+			writeErrorResponse(w, r, http.StatusInternalServerError, errCodeInternal, "JSON encoding failed")
 			return
 		}
 
 		// Write separator for items after the first
-		if i > 0 {
-			if _, err := w.Write([]byte(",\n")); err != nil {
+		if i > start {
+			if _, err := io.WriteString(out, streamItemSeparator(envelope, style)); err != nil {
 				return
 			}
 		}
 
 		// Write item
-		if _, err := w.Write(data); err != nil {
+		if _, err := out.Write(data); err != nil {
 			return
 		}
+		itemsSent++
 
-		// Apply delay
-		if err := applyDelay(ctx, strategy, baseDelay, scenario, i); err != nil {
-			// Context cancelled during delay
-			_, _ = w.Write([]byte("\n]"))
+		// Drop the connection mid-stream to simulate a network failure. The
+		// body is intentionally truncated: the JSON array is never closed.
+		if rollProbability(resetRate) {
+			flushOut()
+			resetConnection(w)
 			return
 		}
 
+		// Apply delay
+		if err := applyDelay(streamCtx, strategy, baseDelay, scenario, i, slowRate); err != nil {
+			if ctx.Err() != nil {
+				// Client disconnected during delay
+				closeStream()
+				return
+			}
+			// max_duration elapsed during delay
+			truncated = i+1 < count
+			break
+		}
+
 		// Flush in batches
-		if i%batchSize == 0 {
+		if chunkBytes == 0 && (i-start)%batchSize == 0 {
 			flusher.Flush()
 		}
 	}
 
-	// Close JSON array
-	_, _ = w.Write([]byte("\n]"))
-	flusher.Flush()
+	// Hold back the end of the stream like a backend that is slow to
+	// finalize. Every item has been flushed, only the closing bracket waits.
+	if finalDelay > 0 && !truncated {
+		flushOut()
+		if err := sleepContext(ctx, finalDelay); err != nil {
+			return
+		}
+	}
+
+	// Close JSON array and envelope
+	closeStream()
+	flushOut()
+	if maxDuration > 0 {
+		w.Header().Set(truncatedTrailer, strconv.FormatBool(truncated))
+	}
 }
 
 // OpenAPISpec returns the OpenAPI specification for the streaming payload endpoint
 func (s StreamingPayloadPlugin) OpenAPISpec() OpenAPIPathSpec {
-	return OpenAPIPathSpec{
+	spec := OpenAPIPathSpec{
 		Path: "/stream_payload",
 		Operation: OpenAPIPath{
 			Get: &OpenAPIOperation{
+				OperationID: "getStreamPayload",
 				Summary:     "Get streaming JSON payload",
 				Description: "Returns a real-time JSON stream with configurable delays and ServiceNow-specific scenarios",
 				Tags:        []string{"streaming"},
@@ -407,7 +801,7 @@ func (s StreamingPayloadPlugin) OpenAPISpec() OpenAPIPathSpec {
 					{
 						Name:        "delay",
 						In:          "query",
-						Description: "Base delay between items (e.g., '100ms', '1s', or just milliseconds)",
+						Description: "Base delay between items (e.g., '100ms', '1s', or just milliseconds). Defaults to the server's -stream-default-delay (10ms unless configured)",
 						Required:    false,
 						Schema: &OpenAPISchema{
 							Type:    "string",
@@ -428,11 +822,21 @@ func (s StreamingPayloadPlugin) OpenAPISpec() OpenAPIPathSpec {
 					{
 						Name:        "scenario",
 						In:          "query",
-						Description: "ServiceNow simulation scenario. All scenarios work with streaming: 'peak_hours' (consistent delays, ideal for both), 'maintenance' (periodic spikes per batch), 'network_issues' (random delays per item), 'database_load' (progressive delays per item)",
+						Description: "ServiceNow simulation scenario. All scenarios work with streaming: 'peak_hours' (consistent delays, ideal for both), 'maintenance' (periodic spikes per batch), 'network_issues' (random delays per item), 'database_load' (progressive delays per item), 'recovery' (high delays per item ramping down to baseline), 'error_storm' (first requests fail, then recovery), 'slow_connect' (long time to first byte, then normal streaming), 'circuit_breaker' (503 while open, then a half-open probe closes the circuit), 'token_expiry' (401 after 5 requests per Authorization header until it changes). 'peak_hours,error_storm' layers several scenarios (delays from the first, error injection from the first that enables it). 'random:peak_hours=3,network_issues=1' picks a scenario per request by weight (non-deterministic)",
+						Required:    false,
+						Schema: &OpenAPISchema{
+							Type:    "string",
+							Enum:    []any{"peak_hours", "maintenance", "network_issues", "database_load", "recovery", "error_storm", "slow_connect", "circuit_breaker", "token_expiry"},
+							Example: "peak_hours",
+						},
+					},
+					{
+						Name:        "X-Scenario",
+						In:          "header",
+						Description: "Alternative to the scenario query parameter for header-only clients. Ignored when the query parameter is set",
 						Required:    false,
 						Schema: &OpenAPISchema{
 							Type:    "string",
-							Enum:    []any{"peak_hours", "maintenance", "network_issues", "database_load"},
 							Example: "peak_hours",
 						},
 					},
@@ -457,6 +861,306 @@ func (s StreamingPayloadPlugin) OpenAPISpec() OpenAPIPathSpec {
 							Example: false,
 						},
 					},
+					{
+						Name:        "start",
+						In:          "query",
+						Description: "Absolute item position to start streaming from (default: 0). Resume an interrupted stream by passing the last received id - id_start + 1; ids and ServiceNow numbers reflect the absolute position",
+						Required:    false,
+						Schema: &OpenAPISchema{
+							Type:    "integer",
+							Minimum: &[]int{0}[0],
+							Example: 0,
+						},
+					},
+					{
+						Name:        "envelope",
+						In:          "query",
+						Description: "Wrap the streamed items: array for a bare JSON array (default), object for {\"result\":[...],\"metadata\":{...}} with total_count, items_sent, complete and duration appended after the last item",
+						Required:    false,
+						Schema: &OpenAPISchema{
+							Type:    "string",
+							Enum:    []interface{}{EnvelopeArray, EnvelopeObject},
+							Example: EnvelopeObject,
+						},
+					},
+					{
+						Name:        "format",
+						In:          "query",
+						Description: "json streams a JSON document framed by envelope (default); jsonrpc streams one JSON-RPC 2.0 notification per line ({\"jsonrpc\":\"2.0\",\"method\":\"item\",\"params\":{...}}) as application/x-ndjson; grpc-web wraps each JSON item in a gRPC-Web data frame (flag byte 0x00 and a 4 byte big-endian length) and ends with a trailer frame (flag 0x80, grpc-status: 0) as application/grpc-web+json. jsonrpc and grpc-web cannot be combined with envelope and ignore pretty",
+						Required:    false,
+						Schema: &OpenAPISchema{
+							Type:    "string",
+							Enum:    []interface{}{StreamFormatJSON, StreamFormatJSONRPC, StreamFormatGRPCWeb},
+							Example: StreamFormatJSONRPC,
+						},
+					},
+					{
+						Name:        "chunk_bytes",
+						In:          "query",
+						Description: "Flush the body in chunks of exactly this many bytes (the last chunk may be shorter), independent of item boundaries. Overrides batch_size flushing; reproduces client buffering bugs tied to chunk sizes (default: 0, off; max: 1048576)",
+						Required:    false,
+						Schema: &OpenAPISchema{
+							Type:    "integer",
+							Minimum: &[]int{0}[0],
+							Maximum: &[]int{maxChunkBytes}[0],
+							Example: 1460,
+						},
+					},
+					{
+						Name:        "max_duration",
+						In:          "query",
+						Description: "End the stream cleanly once this much time has elapsed, closing the JSON array (and envelope) early. The X-PayloadBuddy-Truncated trailer reports whether items were cut off (default: unlimited)",
+						Required:    false,
+						Schema: &OpenAPISchema{
+							Type:    "string",
+							Example: "30s",
+						},
+					},
+					{
+						Name:        "final_delay",
+						In:          "query",
+						Description: "Wait this long after the last item has been flushed before closing the JSON array, to test clients that time out waiting for stream completion (default: 0). Skipped when max_duration cuts the stream short",
+						Required:    false,
+						Schema: &OpenAPISchema{
+							Type:    "string",
+							Example: "5s",
+						},
+					},
+					{
+						Name:        "bandwidth",
+						In:          "query",
+						Description: "Pace the body to at most this many bytes per second, like a bandwidth-limited link. Units are decimal bytes: Bps, KBps, MBps, GBps; a plain number is bytes per second (default: unlimited)",
+						Required:    false,
+						Schema: &OpenAPISchema{
+							Type:    "string",
+							Example: "1MBps",
+						},
+					},
+					{
+						Name:        "reset_rate",
+						In:          "query",
+						Description: "Probability (0.0-1.0) per item of abruptly closing the connection mid-stream to test client reconnection (default: 0). The body is intentionally truncated: the JSON array is never closed",
+						Required:    false,
+						Schema: &OpenAPISchema{
+							Type:    "number",
+							Example: 0.01,
+						},
+					},
+					{
+						Name:        "slow_rate",
+						In:          "query",
+						Description: "Fraction of items (0.0-1.0) that receive the delay of the configured strategy or scenario; all other items are sent without delay (default: 1.0). E.g. 0.05 for tail latency testing",
+						Required:    false,
+						Schema: &OpenAPISchema{
+							Type:    "number",
+							Example: 0.05,
+						},
+					},
+					{
+						Name:        "id_start",
+						In:          "query",
+						Description: "ID of the item at position 0 (default: 0 for backward compatibility; use id_start=1 to match /rest_payload and /paginated_payload)",
+						Required:    false,
+						Schema: &OpenAPISchema{
+							Type:    "integer",
+							Minimum: &[]int{0}[0],
+							Maximum: &[]int{maxIDStart}[0],
+							Example: 1,
+						},
+					},
+					{
+						Name:        "value_template",
+						In:          "query",
+						Description: "Go text/template rendering the value of every item, with {{.ID}} (item ID) and {{.Index}} (absolute position, from 0); also in ServiceNow mode. Templates that do not parse or render yield 400",
+						Required:    false,
+						Schema: &OpenAPISchema{
+							Type:    "string",
+							Example: "User-{{.ID}}",
+						},
+					},
+					{
+						Name:        "fixed_timestamp",
+						In:          "query",
+						Description: "RFC 3339 time used for all item timestamps instead of the current time, for reproducible output",
+						Required:    false,
+						Schema: &OpenAPISchema{
+							Type:    "string",
+							Format:  "date-time",
+							Example: "2024-01-15T10:00:00Z",
+						},
+					},
+					{
+						Name:        "size_variation",
+						In:          "query",
+						Description: "Pad every item value to a random length within this percentage around value_size, modelling records of heterogeneous size (default: 0, no padding)",
+						Required:    false,
+						Schema: &OpenAPISchema{
+							Type:    "integer",
+							Minimum: &[]int{0}[0],
+							Maximum: &[]int{100}[0],
+							Example: 50,
+						},
+					},
+					{
+						Name:        "value_size",
+						In:          "query",
+						Description: "Base length of item values in bytes for size_variation (default: 256)",
+						Required:    false,
+						Schema: &OpenAPISchema{
+							Type:    "integer",
+							Minimum: &[]int{1}[0],
+							Maximum: &[]int{maxValueSize}[0],
+							Example: 1024,
+						},
+					},
+					{
+						Name:        "seed",
+						In:          "query",
+						Description: "Seed for reproducible data: the same seed gives every item ID the same value, sys_id and size_variation length on every request. Scenarios with random_seed pick a random seed if none is given and report it in the X-PayloadBuddy-Seed header. null_rate, shuffle_fields, error injection, reset_rate and random delays are not seeded",
+						Required:    false,
+						Schema: &OpenAPISchema{
+							Type:    "integer",
+							Minimum: &[]int{0}[0],
+							Example: 42,
+						},
+					},
+					{
+						Name:        "cardinality",
+						In:          "query",
+						Description: "Number of distinct values: item values and ServiceNow states cycle through this many values, while ids, numbers and sys_ids stay unique (default: one value per item). For testing grouping and deduplication",
+						Required:    false,
+						Schema: &OpenAPISchema{
+							Type:    "integer",
+							Minimum: &[]int{1}[0],
+							Maximum: &[]int{maxCardinality}[0],
+							Example: 5,
+						},
+					},
+					{
+						Name:        "string_ids",
+						In:          "query",
+						Description: "Emit the item id as a JSON string (\"42\") instead of a number, as ServiceNow does for numeric-looking values (default: false)",
+						Required:    false,
+						Schema: &OpenAPISchema{
+							Type:    "boolean",
+							Example: true,
+						},
+					},
+					{
+						Name:        "null_rate",
+						In:          "query",
+						Description: "Probability (0.0-1.0) of each item field except id being JSON null, independently per field and item (default: 0). Exposes clients that crash on unexpected nulls. Ignored with a scenario item_template",
+						Required:    false,
+						Schema: &OpenAPISchema{
+							Type:    "number",
+							Example: 0.1,
+						},
+					},
+					{
+						Name:        "timestamp_field",
+						In:          "query",
+						Description: "JSON key under which the item timestamp is emitted (default: 'timestamp'), e.g. 'sys_created_on'",
+						Required:    false,
+						Schema: &OpenAPISchema{
+							Type:    "string",
+							Example: "sys_created_on",
+						},
+					},
+					{
+						Name:        "timestamp_format",
+						In:          "query",
+						Description: "Timestamp format: 'rfc3339' = RFC 3339 string (default), 'epoch' = Unix epoch milliseconds",
+						Required:    false,
+						Schema: &OpenAPISchema{
+							Type:    "string",
+							Enum:    []interface{}{"rfc3339", "epoch"},
+							Example: "rfc3339",
+						},
+					},
+					{
+						Name:        "shuffle_fields",
+						In:          "query",
+						Description: "Emit the keys of every item in a random order (default: false). The JSON stays valid, only the key order varies from item to item; exposes clients that depend on a fixed key order. Ignored with a scenario item_template",
+						Required:    false,
+						Schema: &OpenAPISchema{
+							Type:    "boolean",
+							Example: true,
+						},
+					},
+					{
+						Name:        "callback_url",
+						In:          "query",
+						Description: "HTTP(S) URL that receives a JSON POST (items sent, duration, client disconnected) when the stream ends",
+						Required:    false,
+						Schema: &OpenAPISchema{
+							Type:    "string",
+							Example: "http://localhost:9000/stream_done",
+						},
+					},
+					{
+						Name:        "pretty",
+						In:          "query",
+						Description: "Indent each streamed item for reading in a browser (default: false, compact)",
+						Required:    false,
+						Schema: &OpenAPISchema{
+							Type:    "boolean",
+							Example: false,
+						},
+					},
+					{
+						Name:        "json_style",
+						In:          "query",
+						Description: "Whitespace between JSON tokens: compact (none), spaced (one space after every , and :) or pretty (indented, like pretty=true). Overrides pretty. compact and spaced also put the whole stream on one line instead of one item per line; ignored for format=jsonrpc and grpc-web",
+						Required:    false,
+						Schema: &OpenAPISchema{
+							Type:    "string",
+							Enum:    []interface{}{"compact", "spaced", "pretty"},
+							Example: "spaced",
+						},
+					},
+					{
+						Name:        "proto",
+						In:          "query",
+						Description: "Answer HTTP/1.0-style with \"1.0\": the whole stream is buffered and sent with Content-Length and Connection: close instead of chunked encoding, so keep count and delays small (default: 1.1). The -http10 flag applies this to every request",
+						Required:    false,
+						Schema: &OpenAPISchema{
+							Type:    "string",
+							Enum:    []any{"1.0", "1.1"},
+							Example: "1.0",
+						},
+					},
+					{
+						Name:        "big_header",
+						In:          "query",
+						Description: "Add an X-PayloadBuddy-Big-Header response header with a value of this many bytes (repeating a-z0-9), to test header size limits of clients and proxies",
+						Required:    false,
+						Schema: &OpenAPISchema{
+							Type:    "integer",
+							Minimum: &[]int{1}[0],
+							Maximum: &[]int{maxBigHeaderBytes}[0],
+							Example: 16384,
+						},
+					},
+					{
+						Name:        "dry_run",
+						In:          "query",
+						Description: "Return a JSON summary of the planned stream (item count, estimated bytes, estimated total delay, effective parameters) instead of streaming any items",
+						Required:    false,
+						Schema: &OpenAPISchema{
+							Type:    "boolean",
+							Example: false,
+						},
+					},
+					{
+						Name:        "ttfb",
+						In:          "query",
+						Description: "Artificial time to first byte: headers are sent immediately, the body starts after this duration (e.g., '2s'). Independent of the inter-item delay. Defaults to the scenario's setup_delay (e.g. 'slow_connect')",
+						Required:    false,
+						Schema: &OpenAPISchema{
+							Type:    "string",
+							Example: "2s",
+						},
+					},
 				},
 				Responses: map[string]OpenAPIResponse{
 					"200": {
@@ -518,6 +1222,31 @@ func (s StreamingPayloadPlugin) OpenAPISpec() OpenAPIPathSpec {
 									},
 								},
 							},
+							"application/x-ndjson": {
+								Schema: &OpenAPISchema{
+									Type:        "string",
+									Description: "With format=jsonrpc: one JSON-RPC 2.0 notification per line, the item in params",
+								},
+								Example: `{"jsonrpc":"2.0","method":"item","params":{"id":1,"value":"streamed data 1","timestamp":"2024-01-15T10:00:00Z"}}`,
+							},
+							grpcWebContentType: {
+								Schema: &OpenAPISchema{
+									Type:        "string",
+									Format:      "binary",
+									Description: "With format=grpc-web: one length-prefixed gRPC-Web data frame per JSON item, then a trailer frame with grpc-status: 0",
+								},
+							},
+						},
+					},
+					"403": {
+						Description: "The scenario is not allowed by -allow-scenarios or -deny-scenarios",
+						Content: map[string]OpenAPIMediaType{
+							"text/plain": {
+								Schema: &OpenAPISchema{
+									Type:    "string",
+									Example: "scenario is not allowed on this server: network_issues",
+								},
+							},
 						},
 					},
 					"500": {
@@ -531,6 +1260,17 @@ func (s StreamingPayloadPlugin) OpenAPISpec() OpenAPIPathSpec {
 							},
 						},
 					},
+					"503": {
+						Description: "The scenario's max_concurrent limit is reached, or its circuit_breaker is open (with Retry-After and X-PayloadBuddy-Circuit-State)",
+						Content: map[string]OpenAPIMediaType{
+							"text/plain": {
+								Schema: &OpenAPISchema{
+									Type:    "string",
+									Example: "Scenario custom is at its concurrent request limit",
+								},
+							},
+						},
+					},
 				},
 			},
 		},
@@ -568,4 +1308,9 @@ func (s StreamingPayloadPlugin) OpenAPISpec() OpenAPIPathSpec {
 			},
 		},
 	}
+
+	// Offer a sample response per loaded scenario
+	content := spec.Operation.Get.Responses["200"].Content
+	content["application/json"] = withScenarioExamples(content["application/json"], streamScenarioExample)
+	return spec
 }
@@ -1,9 +1,12 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"encoding/base64"
+	"encoding/binary"
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -432,7 +435,7 @@ func TestApplyDelay_EdgeCases(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			start := time.Now()
-			err := applyDelay(ctx, tt.strategy, tt.baseDelay, tt.scenario, tt.itemIndex)
+			err := applyDelay(ctx, tt.strategy, tt.baseDelay, tt.scenario, tt.itemIndex, 1)
 			elapsed := time.Since(start)
 
 			if tt.expectErr && err == nil {
@@ -458,7 +461,7 @@ func TestApplyDelay_ContextCancellation(t *testing.T) {
 	// Cancel context immediately
 	cancel()
 
-	err := applyDelay(ctx, FixedDelay, 100*time.Millisecond, "", 0)
+	err := applyDelay(ctx, FixedDelay, 100*time.Millisecond, "", 0, 1)
 
 	if err == nil {
 		t.Error("Expected context cancellation error")
@@ -478,7 +481,7 @@ func TestApplyDelay_NetworkIssuesScenario(t *testing.T) {
 	// Run many iterations to increase chance of hitting both paths
 	for i := 0; i < 100; i++ {
 		start := time.Now()
-		err := applyDelay(ctx, FixedDelay, 1*time.Millisecond, "network_issues", i)
+		err := applyDelay(ctx, FixedDelay, 1*time.Millisecond, "network_issues", i, 1)
 		elapsed := time.Since(start)
 
 		if err != nil {
@@ -553,3 +556,672 @@ func TestParameterParsing_EdgeCases(t *testing.T) {
 		}
 	})
 }
+
+func TestStreamingPayloadHandler_StartParameter(t *testing.T) {
+	*enableAuth = false
+	req := httptest.NewRequest("GET", "/stream_payload?count=1003&start=1000&delay=0&servicenow=true", nil)
+	w := httptest.NewRecorder()
+
+	StreamingPayloadHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	var items []StreamItem
+	if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil {
+		t.Fatalf("Failed to parse JSON: %v", err)
+	}
+
+	if len(items) != 3 {
+		t.Fatalf("Expected 3 remaining items, got %d", len(items))
+	}
+	if items[0].ID != 1000 {
+		t.Errorf("Expected first item id 1000, got %d", items[0].ID)
+	}
+	if items[0].Number != "INC0001001" {
+		t.Errorf("Expected absolute ServiceNow number INC0001001, got %s", items[0].Number)
+	}
+}
+
+func TestStreamingPayloadHandler_ServiceNowNumbersStartAtOne(t *testing.T) {
+	*enableAuth = false
+	numbers := func(handler http.HandlerFunc, url string) []string {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest("GET", url, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected status 200, got %d", url, w.Code)
+		}
+		var items []StreamItem
+		if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil {
+			var page struct {
+				Result []StreamItem `json:"result"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+				t.Fatalf("%s: failed to parse JSON: %v", url, err)
+			}
+			items = page.Result
+		}
+		result := make([]string, len(items))
+		for i, item := range items {
+			result[i] = item.Number
+		}
+		return result
+	}
+
+	stream := numbers(StreamingPayloadHandler, "/stream_payload?count=3&delay=0&servicenow=true")
+	if stream[0] != "INC0000001" {
+		t.Errorf("Expected streaming numbers to start at INC0000001, got %s", stream[0])
+	}
+
+	// All endpoints number the same records alike, whatever their IDs
+	page := numbers(PaginatedPayloadHandler, "/paginated_payload?limit=3&servicenow=true")
+	shifted := numbers(StreamingPayloadHandler, "/stream_payload?count=3&delay=0&servicenow=true&id_start=500")
+	for i := range stream {
+		if stream[i] != page[i] || stream[i] != shifted[i] {
+			t.Errorf("Item %d: numbers differ: stream=%s, paginated=%s, id_start=500: %s", i, stream[i], page[i], shifted[i])
+		}
+	}
+}
+
+func TestStreamingPayloadHandler_InvalidStart(t *testing.T) {
+	*enableAuth = false
+	for _, start := range []string{"-1", "5"} {
+		req := httptest.NewRequest("GET", "/stream_payload?count=5&delay=0&start="+start, nil)
+		w := httptest.NewRecorder()
+
+		StreamingPayloadHandler(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for start=%s, got %d", start, w.Code)
+		}
+	}
+}
+
+func TestStreamingPayloadHandler_TimestampOptions(t *testing.T) {
+	*enableAuth = false
+	req := httptest.NewRequest("GET", "/stream_payload?count=2&delay=0&timestamp_field=sys_created_on&timestamp_format=epoch", nil)
+	w := httptest.NewRecorder()
+
+	StreamingPayloadHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	var items []map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil {
+		t.Fatalf("Failed to parse JSON: %v", err)
+	}
+
+	for _, item := range items {
+		if _, ok := item["timestamp"]; ok {
+			t.Error("Expected default timestamp key to be replaced")
+		}
+		if _, ok := item["sys_created_on"].(float64); !ok {
+			t.Errorf("Expected numeric sys_created_on field, got %T", item["sys_created_on"])
+		}
+	}
+}
+
+func TestStreamingPayloadHandler_InvalidTimestampOptions(t *testing.T) {
+	*enableAuth = false
+	tests := []string{
+		"/stream_payload?count=1&delay=0&timestamp_format=unix_nanos",
+		"/stream_payload?count=1&delay=0&timestamp_field=id",
+	}
+
+	for _, url := range tests {
+		req := httptest.NewRequest("GET", url, nil)
+		w := httptest.NewRecorder()
+
+		StreamingPayloadHandler(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for %s, got %d", url, w.Code)
+		}
+	}
+}
+
+func TestStreamingPayloadHandler_TTFB(t *testing.T) {
+	*enableAuth = false
+	server := httptest.NewServer(http.HandlerFunc(StreamingPayloadHandler))
+	defer server.Close()
+
+	ttfb := 150 * time.Millisecond
+	start := time.Now()
+	resp, err := http.Get(server.URL + "/stream_payload?count=2&delay=0&ttfb=150ms")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	headersAfter := time.Since(start)
+
+	firstByte := make([]byte, 1)
+	if _, err := resp.Body.Read(firstByte); err != nil {
+		t.Fatalf("Failed to read first byte: %v", err)
+	}
+	firstByteAfter := time.Since(start)
+
+	if headersAfter >= ttfb {
+		t.Errorf("Expected headers before the TTFB elapsed, got them after %v", headersAfter)
+	}
+	if firstByteAfter < ttfb {
+		t.Errorf("Expected first byte after at least %v, got it after %v", ttfb, firstByteAfter)
+	}
+	if firstByte[0] != '[' {
+		t.Errorf("Expected body to start with '[', got %q", firstByte[0])
+	}
+}
+
+// TestStreamingPayloadHandler_ScenarioHeader checks that the X-Scenario header selects a
+// scenario when the query parameter is absent, and that the query parameter takes precedence.
+func TestStreamingPayloadHandler_ScenarioHeader(t *testing.T) {
+	*enableAuth = false
+	originalManager := scenarioManager
+	defer func() { scenarioManager = originalManager }()
+	scenarioManager = NewScenarioManager()
+
+	// peak_hours delays every item by 200ms
+	req := httptest.NewRequest("GET", "/stream_payload?count=3", nil)
+	req.Header.Set("X-Scenario", "peak_hours")
+	w := httptest.NewRecorder()
+
+	start := time.Now()
+	StreamingPayloadHandler(w, req)
+	elapsed := time.Since(start)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if elapsed < 500*time.Millisecond {
+		t.Errorf("Expected header-selected peak_hours delay of ~600ms, got %v", elapsed)
+	}
+
+	// The query parameter wins over the header
+	req = httptest.NewRequest("GET", "/stream_payload?count=3&scenario=unknown_scenario", nil)
+	req.Header.Set("X-Scenario", "peak_hours")
+	w = httptest.NewRecorder()
+
+	start = time.Now()
+	StreamingPayloadHandler(w, req)
+	elapsed = time.Since(start)
+
+	if elapsed >= 500*time.Millisecond {
+		t.Errorf("Expected query scenario to take precedence over X-Scenario, got %v", elapsed)
+	}
+}
+
+// TestStreamingPayloadHandler_EffectiveParams checks that the resolved parameters are reported in a response header.
+func TestStreamingPayloadHandler_EffectiveParams(t *testing.T) {
+	*enableAuth = false
+	req := httptest.NewRequest("GET", "/stream_payload?count=3&delay=0&strategy=unknown&batch_size=2", nil)
+	w := httptest.NewRecorder()
+
+	StreamingPayloadHandler(w, req)
+
+	effective, err := url.ParseQuery(w.Header().Get("X-PayloadBuddy-Effective"))
+	if err != nil {
+		t.Fatalf("Failed to parse effective parameters header: %v", err)
+	}
+
+	expected := map[string]string{
+		"count":      "3",
+		"strategy":   "fixed",
+		"batch_size": "2",
+		"servicenow": "false",
+		"scenario":   "",
+	}
+	for key, value := range expected {
+		if got := effective.Get(key); got != value {
+			t.Errorf("Expected effective %s=%q, got %q", key, value, got)
+		}
+	}
+}
+
+// TestStreamingPayloadHandler_Callback checks that a completion POST is sent to the callback URL.
+func TestStreamingPayloadHandler_Callback(t *testing.T) {
+	*enableAuth = false
+	received := make(chan StreamCompletion, 1)
+	callback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("Expected POST callback, got %s", r.Method)
+		}
+		var completion StreamCompletion
+		if err := json.NewDecoder(r.Body).Decode(&completion); err != nil {
+			t.Errorf("Failed to decode callback payload: %v", err)
+		}
+		received <- completion
+	}))
+	defer callback.Close()
+
+	req := httptest.NewRequest("GET", "/stream_payload?count=5&delay=0&callback_url="+url.QueryEscape(callback.URL), nil)
+	w := httptest.NewRecorder()
+
+	StreamingPayloadHandler(w, req)
+
+	select {
+	case completion := <-received:
+		if completion.ItemsSent != 5 {
+			t.Errorf("Expected 5 items sent, got %d", completion.ItemsSent)
+		}
+		if completion.ClientDisconnected {
+			t.Error("Expected client_disconnected=false for a completed stream")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Callback was not received")
+	}
+}
+
+// TestStreamingPayloadHandler_InvalidCallback checks that non-http callback URLs are rejected.
+func TestStreamingPayloadHandler_InvalidCallback(t *testing.T) {
+	*enableAuth = false
+	for _, callbackURL := range []string{"file:///etc/passwd", "ftp://example.com/done", "/relative"} {
+		req := httptest.NewRequest("GET", "/stream_payload?count=1&callback_url="+url.QueryEscape(callbackURL), nil)
+		w := httptest.NewRecorder()
+
+		StreamingPayloadHandler(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("callback_url=%s: expected status 400, got %d", callbackURL, w.Code)
+		}
+	}
+}
+
+// TestStreamingPayloadHandler_SlowRateZero checks that slow_rate=0 skips every delay.
+func TestStreamingPayloadHandler_SlowRateZero(t *testing.T) {
+	*enableAuth = false
+	req := httptest.NewRequest("GET", "/stream_payload?count=100&delay=1s&slow_rate=0", nil)
+	w := httptest.NewRecorder()
+
+	start := time.Now()
+	StreamingPayloadHandler(w, req)
+	elapsed := time.Since(start)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	// 100 items with a 1s delay would take 100s without slow_rate
+	if elapsed > 500*time.Millisecond {
+		t.Errorf("Expected stream to complete nearly instantly with slow_rate=0, took %v", elapsed)
+	}
+
+	var items []StreamItem
+	if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil {
+		t.Fatalf("Failed to parse JSON response: %v", err)
+	}
+	if len(items) != 100 {
+		t.Errorf("Expected 100 items, got %d", len(items))
+	}
+}
+
+// TestApplyDelay_SlowRate checks that only the configured fraction of items is delayed.
+func TestApplyDelay_SlowRate(t *testing.T) {
+	ctx := context.Background()
+	delayed := 0
+	for i := 0; i < 200; i++ {
+		start := time.Now()
+		if err := applyDelay(ctx, FixedDelay, 2*time.Millisecond, "", i, 0.25); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if time.Since(start) >= 2*time.Millisecond {
+			delayed++
+		}
+	}
+
+	// Expect ~50 delayed items; allow a wide margin for randomness
+	if delayed < 20 || delayed > 90 {
+		t.Errorf("Expected about 25%% of 200 items to be delayed, got %d", delayed)
+	}
+}
+
+// TestStreamingPayloadHandler_InvalidSlowRate checks that slow_rate outside [0, 1] is rejected.
+func TestStreamingPayloadHandler_InvalidSlowRate(t *testing.T) {
+	*enableAuth = false
+	for _, slowRate := range []string{"-0.1", "1.5", "often"} {
+		req := httptest.NewRequest("GET", "/stream_payload?count=1&slow_rate="+slowRate, nil)
+		w := httptest.NewRecorder()
+
+		StreamingPayloadHandler(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("slow_rate=%s: expected status 400, got %d", slowRate, w.Code)
+		}
+	}
+}
+
+// TestStreamingPayloadHandler_EnvelopeObject checks that envelope=object wraps
+// the items in a result array followed by the stream metadata.
+func TestStreamingPayloadHandler_JSONRPC(t *testing.T) {
+	*enableAuth = false
+	req := httptest.NewRequest("GET", "/stream_payload?count=5&delay=1ms&servicenow=true&format=jsonrpc", nil)
+	w := httptest.NewRecorder()
+
+	StreamingPayloadHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("Expected Content-Type application/x-ndjson, got %q", ct)
+	}
+
+	body := w.Body.String()
+	if !strings.HasSuffix(body, "\n") {
+		t.Error("Expected the last notification to end with a newline")
+	}
+	lines := strings.Split(strings.TrimSuffix(body, "\n"), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("Expected 5 lines, got %d: %q", len(lines), body)
+	}
+	for i, line := range lines {
+		var notification struct {
+			JSONRPC string          `json:"jsonrpc"`
+			Method  string          `json:"method"`
+			Params  *StreamItem     `json:"params"`
+			ID      json.RawMessage `json:"id"`
+		}
+		if err := json.Unmarshal([]byte(line), &notification); err != nil {
+			t.Fatalf("Line %d is not valid JSON: %v (%q)", i, err, line)
+		}
+		if notification.JSONRPC != "2.0" || notification.Method != "item" {
+			t.Errorf("Line %d: expected a JSON-RPC 2.0 item notification, got %q", i, line)
+		}
+		if notification.ID != nil {
+			t.Errorf("Line %d: notifications must not have an id, got %s", i, notification.ID)
+		}
+		if notification.Params == nil || notification.Params.ID != i || notification.Params.SysID == "" {
+			t.Errorf("Line %d: expected item %d with ServiceNow fields in params, got %q", i, i, line)
+		}
+	}
+
+	// format=jsonrpc replaces the envelope
+	w = httptest.NewRecorder()
+	StreamingPayloadHandler(w, httptest.NewRequest("GET", "/stream_payload?count=5&format=jsonrpc&envelope=object", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for format=jsonrpc with envelope, got %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	StreamingPayloadHandler(w, httptest.NewRequest("GET", "/stream_payload?count=5&format=xml", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unknown format, got %d", w.Code)
+	}
+}
+
+// TestStreamingPayloadHandler_GRPCWeb parses the length-prefixed gRPC-Web
+// frames back into items
+func TestStreamingPayloadHandler_GRPCWeb(t *testing.T) {
+	*enableAuth = false
+	w := httptest.NewRecorder()
+	StreamingPayloadHandler(w, httptest.NewRequest("GET", "/stream_payload?count=5&delay=1ms&format=grpc-web", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/grpc-web+json" {
+		t.Errorf("Expected Content-Type application/grpc-web+json, got %q", ct)
+	}
+
+	body := w.Body.Bytes()
+	var items []StreamItem
+	var trailers string
+	for len(body) > 0 {
+		if len(body) < 5 {
+			t.Fatalf("Truncated frame header: %v", body)
+		}
+		flag, length := body[0], int(binary.BigEndian.Uint32(body[1:5]))
+		if len(body) < 5+length {
+			t.Fatalf("Frame announces %d bytes, only %d left", length, len(body)-5)
+		}
+		payload := body[5 : 5+length]
+		body = body[5+length:]
+
+		switch flag {
+		case 0x00:
+			if trailers != "" {
+				t.Fatal("Expected no data frames after the trailer frame")
+			}
+			var item StreamItem
+			if err := json.Unmarshal(payload, &item); err != nil {
+				t.Fatalf("Data frame %d is not a JSON item: %v (%q)", len(items), err, payload)
+			}
+			items = append(items, item)
+		case 0x80:
+			trailers = string(payload)
+		default:
+			t.Fatalf("Unexpected frame flag %#x", flag)
+		}
+	}
+
+	if len(items) != 5 {
+		t.Fatalf("Expected 5 data frames, got %d", len(items))
+	}
+	for i, item := range items {
+		if item.ID != i {
+			t.Errorf("Frame %d: expected item %d, got %d", i, i, item.ID)
+		}
+	}
+	if !strings.Contains(trailers, "grpc-status: 0\r\n") {
+		t.Errorf("Expected a trailer frame with grpc-status 0, got %q", trailers)
+	}
+
+	w = httptest.NewRecorder()
+	StreamingPayloadHandler(w, httptest.NewRequest("GET", "/stream_payload?count=5&format=grpc-web&envelope=array", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for format=grpc-web with envelope, got %d", w.Code)
+	}
+}
+
+func TestStreamingPayloadHandler_EnvelopeObject(t *testing.T) {
+	*enableAuth = false
+	req := httptest.NewRequest("GET", "/stream_payload?count=10&start=4&delay=1ms&envelope=object", nil)
+	w := httptest.NewRecorder()
+
+	StreamingPayloadHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	var response struct {
+		Result   []StreamItem   `json:"result"`
+		Metadata StreamMetadata `json:"metadata"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse enveloped stream: %v", err)
+	}
+	if len(response.Result) != 6 {
+		t.Errorf("Expected 6 items in result, got %d", len(response.Result))
+	}
+	if response.Metadata.TotalCount != 6 || response.Metadata.ItemsSent != 6 {
+		t.Errorf("Expected total_count=6 and items_sent=6, got %+v", response.Metadata)
+	}
+	if !response.Metadata.Complete {
+		t.Error("Expected complete=true for a finished stream")
+	}
+	if response.Metadata.Duration == "" {
+		t.Error("Expected duration in metadata")
+	}
+}
+
+func TestStreamingPayloadHandler_InvalidEnvelope(t *testing.T) {
+	*enableAuth = false
+	req := httptest.NewRequest("GET", "/stream_payload?count=10&envelope=xml", nil)
+	w := httptest.NewRecorder()
+
+	StreamingPayloadHandler(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for invalid envelope, got %d", w.Code)
+	}
+}
+
+// TestStreamingPayloadHandler_ResetRate checks that reset_rate=1.0 drops the
+// connection before the stream completes.
+func TestStreamingPayloadHandler_ResetRate(t *testing.T) {
+	*enableAuth = false
+	server := httptest.NewServer(http.HandlerFunc(StreamingPayloadHandler))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/stream_payload?count=100&delay=0ms&reset_rate=1.0")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err == nil {
+		t.Errorf("Expected the connection to be reset mid-stream, got complete body of %d bytes", len(body))
+	}
+	if json.Valid(body) {
+		t.Error("Expected truncated body to be invalid JSON")
+	}
+	if !strings.HasPrefix(string(body), "[\n{\"id\":0") {
+		t.Errorf("Expected the first item before the reset, got %q", body)
+	}
+}
+
+func TestStreamingPayloadHandler_InvalidResetRate(t *testing.T) {
+	*enableAuth = false
+	w := httptest.NewRecorder()
+	StreamingPayloadHandler(w, httptest.NewRequest("GET", "/stream_payload?reset_rate=2", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for invalid reset_rate, got %d", w.Code)
+	}
+}
+
+// flushRecorder records the body length at every flush
+type flushRecorder struct {
+	*httptest.ResponseRecorder
+	flushedAt []int
+}
+
+func (f *flushRecorder) Flush() {
+	f.flushedAt = append(f.flushedAt, f.Body.Len())
+	f.ResponseRecorder.Flush()
+}
+
+// TestStreamingPayloadHandler_ChunkBytes checks that chunk_bytes flushes at
+// multiples of the configured size, regardless of item boundaries.
+func TestStreamingPayloadHandler_ChunkBytes(t *testing.T) {
+	*enableAuth = false
+	const chunkBytes = 100
+	w := &flushRecorder{ResponseRecorder: httptest.NewRecorder()}
+
+	StreamingPayloadHandler(w, httptest.NewRequest("GET", "/stream_payload?count=20&delay=0ms&batch_size=1&chunk_bytes=100", nil))
+
+	total := w.Body.Len()
+	if len(w.flushedAt) < 2 {
+		t.Fatalf("Expected several flushes, got %v", w.flushedAt)
+	}
+	for i, at := range w.flushedAt[:len(w.flushedAt)-1] {
+		if at != (i+1)*chunkBytes {
+			t.Errorf("Flush %d at %d bytes, expected %d", i+1, at, (i+1)*chunkBytes)
+		}
+	}
+	if last := w.flushedAt[len(w.flushedAt)-1]; last != total {
+		t.Errorf("Expected final flush at end of body (%d bytes), got %d", total, last)
+	}
+
+	var items []StreamItem
+	if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil {
+		t.Fatalf("Failed to parse JSON response: %v", err)
+	}
+	if len(items) != 20 {
+		t.Errorf("Expected 20 items, got %d", len(items))
+	}
+}
+
+// TestStreamingPayloadHandler_MaxDuration checks that max_duration ends a
+// slow stream early with a valid JSON array and a truncation trailer.
+func TestStreamingPayloadHandler_MaxDuration(t *testing.T) {
+	*enableAuth = false
+	w := httptest.NewRecorder()
+
+	StreamingPayloadHandler(w, httptest.NewRequest("GET", "/stream_payload?count=1000&delay=10ms&batch_size=1&max_duration=50ms", nil))
+
+	var items []StreamItem
+	if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil {
+		t.Fatalf("Failed to parse JSON response: %v", err)
+	}
+	if len(items) == 0 || len(items) >= 1000 {
+		t.Errorf("Expected the stream to stop early, got %d items", len(items))
+	}
+	if got := w.Result().Trailer.Get(truncatedTrailer); got != "true" {
+		t.Errorf("Expected %s trailer true, got %q", truncatedTrailer, got)
+	}
+
+	w = httptest.NewRecorder()
+	StreamingPayloadHandler(w, httptest.NewRequest("GET", "/stream_payload?count=5&delay=0ms&max_duration=10s", nil))
+	if got := w.Result().Trailer.Get(truncatedTrailer); got != "false" {
+		t.Errorf("Expected %s trailer false for a complete stream, got %q", truncatedTrailer, got)
+	}
+}
+
+// TestStreamingPayloadHandler_FinalDelay checks that all items arrive right
+// away and only the closing bracket waits for final_delay
+func TestStreamingPayloadHandler_FinalDelay(t *testing.T) {
+	*enableAuth = false
+	const finalDelay = 150 * time.Millisecond
+	ts := httptest.NewServer(http.HandlerFunc(StreamingPayloadHandler))
+	defer ts.Close()
+
+	start := time.Now()
+	resp, err := http.Get(ts.URL + "/stream_payload?count=3&delay=0ms&batch_size=1&final_delay=150ms")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var body []byte
+	var itemsAt, closedAt time.Duration
+	buf := make([]byte, 4096)
+	for {
+		n, err := resp.Body.Read(buf)
+		body = append(body, buf[:n]...)
+		if itemsAt == 0 && bytes.Contains(body, []byte(`"value":"streamed data 2"`)) {
+			itemsAt = time.Since(start)
+		}
+		if closedAt == 0 && bytes.HasSuffix(bytes.TrimSpace(body), []byte("]")) {
+			closedAt = time.Since(start)
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	var items []StreamItem
+	if err := json.Unmarshal(body, &items); err != nil || len(items) != 3 {
+		t.Fatalf("Expected a complete array of 3 items, got %d (%v)", len(items), err)
+	}
+	if itemsAt == 0 || itemsAt >= finalDelay {
+		t.Errorf("Expected all items before the final delay, the last arrived after %v", itemsAt)
+	}
+	if closedAt-itemsAt < finalDelay-20*time.Millisecond {
+		t.Errorf("Expected the closing bracket ~%v after the last item, got %v", finalDelay, closedAt-itemsAt)
+	}
+}
+
+func TestStreamingPayloadHandler_DefaultDelayFlag(t *testing.T) {
+	*enableAuth = false
+	originalDelay := *paramStreamDefaultDelay
+	*paramStreamDefaultDelay = 250 * time.Millisecond
+	defer func() { *paramStreamDefaultDelay = originalDelay }()
+
+	delayFor := func(query string) interface{} {
+		w := httptest.NewRecorder()
+		StreamingPayloadHandler(w, httptest.NewRequest("GET", "/stream_payload?count=10&dry_run=true"+query, nil))
+		var summary DryRunSummary
+		if err := json.Unmarshal(w.Body.Bytes(), &summary); err != nil {
+			t.Fatalf("Failed to parse dry run summary: %v", err)
+		}
+		return summary.EffectiveParameters["delay"]
+	}
+
+	if got := delayFor(""); got != "250ms" {
+		t.Errorf("Expected the configured default delay 250ms, got %v", got)
+	}
+	if got := delayFor("&delay=5ms"); got != "5ms" {
+		t.Errorf("Expected the explicit delay 5ms to win, got %v", got)
+	}
+}
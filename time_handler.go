@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// maxTimeOffset bounds the offset parameter of the time endpoint
+const maxTimeOffset = 100 * 365 * 24 * time.Hour
+
+// rfc3339Millis is RFC 3339 with exactly millisecond precision, so the
+// string carries the same instant as epoch_millis
+const rfc3339Millis = "2006-01-02T15:04:05.000Z07:00"
+
+// TimePlugin implements PayloadPlugin for clock synchronization tests
+type TimePlugin struct{}
+
+// Path returns the HTTP path for the time endpoint
+func (p TimePlugin) Path() string {
+	return "/time"
+}
+
+// Handler returns the handler function for the time endpoint
+func (p TimePlugin) Handler() http.HandlerFunc {
+	return TimeHandler
+}
+
+func init() {
+	registerPlugin(TimePlugin{})
+}
+
+// TimeResult is the server time in several representations of the same instant
+type TimeResult struct {
+	RFC3339      string `json:"rfc3339"`       // UTC with millisecond precision
+	EpochSeconds int64  `json:"epoch_seconds"` // Truncated towards the past
+	EpochMillis  int64  `json:"epoch_millis"`
+	Offset       string `json:"offset"` // The applied offset, "0s" unless skewed
+}
+
+// getTimeOffset parses the offset parameter, a signed duration such as
+// "-90s" or "2h"
+func getTimeOffset(r *http.Request) (time.Duration, error) {
+	val := r.URL.Query().Get("offset")
+	if val == "" {
+		return 0, nil
+	}
+	offset, err := time.ParseDuration(val)
+	if err != nil || offset < -maxTimeOffset || offset > maxTimeOffset {
+		return 0, fmt.Errorf("offset must be a duration between -%v and %v (e.g. -90s, 2h)", maxTimeOffset, maxTimeOffset)
+	}
+	return offset, nil
+}
+
+// newTimeResult describes t in all representations, truncated to
+// milliseconds so they agree
+func newTimeResult(t time.Time, offset time.Duration) TimeResult {
+	t = t.UTC().Truncate(time.Millisecond)
+	return TimeResult{
+		RFC3339:      t.Format(rfc3339Millis),
+		EpochSeconds: t.Unix(),
+		EpochMillis:  t.UnixMilli(),
+		Offset:       offset.String(),
+	}
+}
+
+// TimeHandler returns the current server time for clients that synchronize
+// their clock against the test server.
+//
+// Query Parameters:
+//   - offset: Skew the reported time by this duration to simulate clock drift (e.g., "-90s", "2h", default: 0)
+func TimeHandler(w http.ResponseWriter, r *http.Request) {
+	offset, err := getTimeOffset(r)
+	if err != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, errCodeBadRequest, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := json.NewEncoder(w).Encode(newTimeResult(time.Now().Add(offset), offset)); err != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, errCodeInternal, "Failed to encode response")
+	}
+}
+
+// OpenAPISpec returns the OpenAPI specification for the time endpoint
+func (p TimePlugin) OpenAPISpec() OpenAPIPathSpec {
+	return OpenAPIPathSpec{
+		Path: "/time",
+		Operation: OpenAPIPath{
+			Get: &OpenAPIOperation{
+				OperationID: "getTime",
+				Summary:     "Get the server time",
+				Description: "Returns the current server time as RFC 3339, epoch seconds and epoch milliseconds, optionally skewed by an offset to test clock drift handling",
+				Tags:        []string{"resilience"},
+				Parameters: []OpenAPIParameter{
+					{
+						Name:        "offset",
+						In:          "query",
+						Description: "Signed duration added to the server time to simulate clock drift (default: 0)",
+						Required:    false,
+						Schema: &OpenAPISchema{
+							Type:    "string",
+							Example: "-90s",
+						},
+					},
+				},
+				Responses: map[string]OpenAPIResponse{
+					"200": {
+						Description: "The (skewed) server time; all representations describe the same instant",
+						Content: map[string]OpenAPIMediaType{
+							"application/json": {
+								Schema: &OpenAPISchema{
+									Type: "object",
+									Properties: map[string]*OpenAPISchema{
+										"rfc3339":       {Type: "string", Format: "date-time", Description: "UTC time with millisecond precision"},
+										"epoch_seconds": {Type: "integer", Description: "Seconds since the Unix epoch"},
+										"epoch_millis":  {Type: "integer", Description: "Milliseconds since the Unix epoch"},
+										"offset":        {Type: "string", Description: "The applied offset"},
+									},
+								},
+								Example: TimeResult{
+									RFC3339:      "2024-01-15T09:58:30.250Z",
+									EpochSeconds: 1705312710,
+									EpochMillis:  1705312710250,
+									Offset:       "-1m30s",
+								},
+							},
+						},
+					},
+					"400": {
+						Description: "Bad request - invalid offset",
+						Content: map[string]OpenAPIMediaType{
+							"text/plain": {
+								Schema: &OpenAPISchema{
+									Type:    "string",
+									Example: "offset must be a duration between -876000h0m0s and 876000h0m0s (e.g. -90s, 2h)",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
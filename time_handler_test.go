@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTimeHandler(t *testing.T) {
+	getTime := func(url string) TimeResult {
+		w := httptest.NewRecorder()
+		TimeHandler(w, httptest.NewRequest("GET", url, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected status 200, got %d", url, w.Code)
+		}
+		var result TimeResult
+		if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+			t.Fatalf("%s: failed to parse response: %v", url, err)
+		}
+		return result
+	}
+
+	before := time.Now()
+	result := getTime("/time")
+	after := time.Now()
+
+	// The three representations describe the same instant
+	parsed, err := time.Parse(time.RFC3339, result.RFC3339)
+	if err != nil {
+		t.Fatalf("Invalid rfc3339 %q: %v", result.RFC3339, err)
+	}
+	if parsed.UnixMilli() != result.EpochMillis {
+		t.Errorf("rfc3339 %s and epoch_millis %d differ", result.RFC3339, result.EpochMillis)
+	}
+	if result.EpochMillis/1000 != result.EpochSeconds {
+		t.Errorf("epoch_seconds %d and epoch_millis %d differ", result.EpochSeconds, result.EpochMillis)
+	}
+	if parsed.Before(before.Truncate(time.Millisecond)) || parsed.After(after) {
+		t.Errorf("Expected the current time, got %s", result.RFC3339)
+	}
+	if result.Offset != "0s" {
+		t.Errorf("Expected offset 0s, got %q", result.Offset)
+	}
+
+	// offset skews the time; the second request runs slightly later, which
+	// shrinks the difference
+	skewed := getTime("/time?offset=-1h")
+	if diff := result.EpochMillis - skewed.EpochMillis; diff < 3599*1000 || diff > 3600*1000 {
+		t.Errorf("Expected the time to be skewed by -1h, got a difference of %dms", diff)
+	}
+	if skewed.Offset != "-1h0m0s" {
+		t.Errorf("Expected offset -1h0m0s, got %q", skewed.Offset)
+	}
+
+	for _, offset := range []string{"yesterday", "1000000h"} {
+		w := httptest.NewRecorder()
+		TimeHandler(w, httptest.NewRequest("GET", "/time?offset="+offset, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("offset=%s: expected status 400, got %d", offset, w.Code)
+		}
+	}
+}
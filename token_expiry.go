@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// tokenExpiryAfterKey is the simulation_config key after how many requests a
+// client's credentials expire
+const tokenExpiryAfterKey = "token_expiry_after"
+
+// Limits of the token sessions, so clients sending ever new tokens or
+// addresses cannot grow them without bound
+const (
+	maxTokenSessions = 1024 // Sessions above which an arbitrary one is dropped
+	maxExpiredTokens = 64   // Expired tokens remembered per session, oldest dropped first
+)
+
+// tokenSession tracks the credentials of one client of a token_expiry_after
+// scenario
+type tokenSession struct {
+	token    string   // Authorization header value currently in use
+	requests int      // Requests made with token
+	expired  []string // Earlier tokens, which stay rejected, oldest first
+}
+
+// isExpired reports whether token was replaced by a refresh
+func (s *tokenSession) isExpired(token string) bool {
+	for _, expired := range s.expired {
+		if expired == token {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTokenExpiryAfter validates a token_expiry_after value, which must be a
+// positive whole number
+func parseTokenExpiryAfter(value interface{}) (int, error) {
+	n, ok := value.(float64)
+	if !ok || n < 1 || n != float64(int(n)) {
+		return 0, fmt.Errorf("%s must be a positive integer", tokenExpiryAfterKey)
+	}
+	return int(n), nil
+}
+
+// GetTokenExpiryAfter returns after how many requests a client's credentials
+// expire with the scenario, or 0 if they never do
+func (sm *ScenarioManager) GetTokenExpiryAfter(scenarioType string) int {
+	scenario := sm.GetScenario(scenarioType)
+	if scenario == nil || scenario.ScenarioParams == nil {
+		return 0
+	}
+	value, exists := scenario.ScenarioParams.SimulationConfig[tokenExpiryAfterKey]
+	if !exists {
+		return 0
+	}
+	after, err := parseTokenExpiryAfter(value)
+	if err != nil {
+		return 0
+	}
+	return after
+}
+
+// UseToken records a request of the client with the given Authorization
+// header value and reports whether the token is still valid.
+//
+// A token is valid for its first after requests. A different header value
+// counts as a refreshed token and starts over, while tokens replaced by a
+// refresh stay expired, up to maxExpiredTokens of them. Sessions are kept per
+// scenario and client; POST /reset forgets them.
+func (sm *ScenarioManager) UseToken(scenarioType, client, token string, after int) bool {
+	sm.tokensMu.Lock()
+	defer sm.tokensMu.Unlock()
+	if sm.tokenSessions == nil {
+		sm.tokenSessions = make(map[string]*tokenSession)
+	}
+
+	key := scenarioType + "\x00" + client
+	session, exists := sm.tokenSessions[key]
+	if !exists {
+		if len(sm.tokenSessions) >= maxTokenSessions {
+			for other := range sm.tokenSessions {
+				delete(sm.tokenSessions, other)
+				break
+			}
+		}
+		session = &tokenSession{token: token}
+		sm.tokenSessions[key] = session
+	}
+	if token != session.token {
+		if session.isExpired(token) {
+			return false
+		}
+		if len(session.expired) >= maxExpiredTokens {
+			session.expired = session.expired[1:]
+		}
+		session.expired = append(session.expired, session.token)
+		session.token, session.requests = token, 0
+	}
+	session.requests++
+	return session.requests <= after
+}
+
+// ResetTokenExpiry forgets the token sessions of all clients
+func (sm *ScenarioManager) ResetTokenExpiry() {
+	sm.tokensMu.Lock()
+	defer sm.tokensMu.Unlock()
+	sm.tokenSessions = nil
+}
+
+// tokenExpiryScenario returns the requested scenario and its
+// token_expiry_after value if credentials expire with it. Random selections
+// are resolved by the handler and never expire credentials.
+func tokenExpiryScenario(r *http.Request) (scenarioType string, after int) {
+	if scenarioManager == nil {
+		return "", 0
+	}
+	scenarioType = r.URL.Query().Get("scenario")
+	if scenarioType == "" {
+		scenarioType = r.Header.Get("X-Scenario")
+	}
+	scenarioType = strings.ToLower(strings.TrimSpace(scenarioType))
+	if scenarioType == "" || checkScenarioAccess(scenarioType) != nil {
+		return "", 0
+	}
+	return scenarioType, scenarioManager.GetTokenExpiryAfter(scenarioType)
+}
+
+// checkExpiringToken authenticates a request of a token_expiry_after
+// scenario, for testing token refresh logic. The Authorization header value
+// is taken as an opaque token: any value is accepted until the client has
+// used it for token_expiry_after requests, after which it is answered with
+// 401 until the client sends a different value. Without -auth clients are
+// told apart by IP address. With -auth basicAuthMiddleware validates the
+// credentials first and passes the user as client; since the credentials
+// cannot change, they stay expired until POST /reset.
+func checkExpiringToken(w http.ResponseWriter, r *http.Request, next http.HandlerFunc, scenarioType, client string, after int) {
+	token := r.Header.Get("Authorization")
+	if token == "" {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="Restricted"`)
+		writeErrorResponse(w, r, http.StatusUnauthorized, errCodeUnauthorized, "Unauthorized")
+		return
+	}
+	if !scenarioManager.UseToken(scenarioType, client, token, after) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="Restricted", error="invalid_token", error_description="The access token expired"`)
+		writeErrorResponse(w, r, http.StatusUnauthorized, errCodeUnauthorized, "Token expired")
+		return
+	}
+	next(w, r)
+}
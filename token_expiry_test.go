@@ -0,0 +1,209 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestTokenExpiryScenario drives a token_expiry scenario through
+// success -> 401 -> success after the client changes its credentials.
+func TestTokenExpiryScenario(t *testing.T) {
+	originalAuth := *enableAuth
+	*enableAuth = false
+	defer func() { *enableAuth = originalAuth }()
+
+	originalManager := scenarioManager
+	defer func() { scenarioManager = originalManager }()
+	scenarioManager = newTokenExpiryManager()
+
+	handler := basicAuthMiddleware(PaginatedPayloadHandler)
+	request := func(remoteAddr, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/?scenario=token_expiry&limit=1", nil)
+		req.RemoteAddr = remoteAddr
+		if token != "" {
+			req.Header.Set("Authorization", token)
+		}
+		w := httptest.NewRecorder()
+		handler(w, req)
+		return w
+	}
+	expect := func(stage string, w *httptest.ResponseRecorder, code int) {
+		t.Helper()
+		if w.Code != code {
+			t.Errorf("%s: expected status %d, got %d", stage, code, w.Code)
+		}
+	}
+
+	// Any token is valid for token_expiry_after requests
+	for i := 0; i < 2; i++ {
+		expect("valid", request("192.0.2.1:1234", "Bearer first"), http.StatusOK)
+	}
+
+	// Expired until the client refreshes
+	w := request("192.0.2.1:1234", "Bearer first")
+	expect("expired", w, http.StatusUnauthorized)
+	if got := w.Header().Get("WWW-Authenticate"); !strings.Contains(got, `error="invalid_token"`) {
+		t.Errorf("expired: expected an invalid_token challenge, got %q", got)
+	}
+
+	// Other clients have their own session
+	expect("other client", request("192.0.2.2:1234", "Bearer first"), http.StatusOK)
+
+	// A new token is valid again, the replaced one stays expired
+	expect("refreshed", request("192.0.2.1:1234", "Bearer second"), http.StatusOK)
+	expect("replaced token", request("192.0.2.1:1234", "Bearer first"), http.StatusUnauthorized)
+	expect("refreshed again", request("192.0.2.1:1234", "Bearer second"), http.StatusOK)
+	expect("refreshed expired", request("192.0.2.1:1234", "Bearer second"), http.StatusUnauthorized)
+
+	// Without a token
+	expect("missing token", request("192.0.2.3:1234", ""), http.StatusUnauthorized)
+
+	// Reset forgets the sessions
+	scenarioManager.ResetTokenExpiry()
+	expect("after reset", request("192.0.2.1:1234", "Bearer first"), http.StatusOK)
+}
+
+// newTokenExpiryManager returns a scenario manager whose token_expiry
+// scenario expires credentials after two requests
+func newTokenExpiryManager() *ScenarioManager {
+	return &ScenarioManager{
+		scenarios: map[string]*Scenario{
+			"token_expiry": {
+				ScenarioType: "token_expiry",
+				BaseDelay:    "1ms",
+				ScenarioParams: &ScenarioParameters{
+					SimulationConfig: map[string]interface{}{tokenExpiryAfterKey: float64(2)},
+				},
+			},
+		},
+		validator: NewScenarioValidator(),
+	}
+}
+
+// TestTokenExpiryWithAuth checks that with -auth the scenario does not
+// replace the credential check: bad credentials are rejected, and valid ones
+// expire per user
+func TestTokenExpiryWithAuth(t *testing.T) {
+	originalAuth := *enableAuth
+	*enableAuth = true
+	defer func() { *enableAuth = originalAuth }()
+
+	originalUser, originalPass := authUsername, authPassword
+	authUsername, authPassword = "user", "secret"
+	defer func() { authUsername, authPassword = originalUser, originalPass }()
+
+	originalManager := scenarioManager
+	defer func() { scenarioManager = originalManager }()
+	scenarioManager = newTokenExpiryManager()
+
+	handler := basicAuthMiddleware(PaginatedPayloadHandler)
+	request := func(target, remoteAddr string, setAuth func(*http.Request)) int {
+		req := httptest.NewRequest("GET", target, nil)
+		req.RemoteAddr = remoteAddr
+		setAuth(req)
+		w := httptest.NewRecorder()
+		handler(w, req)
+		return w.Code
+	}
+	bearer := func(req *http.Request) { req.Header.Set("Authorization", "Bearer garbage") }
+	wrong := func(req *http.Request) { req.SetBasicAuth("user", "wrong") }
+	valid := func(req *http.Request) { req.SetBasicAuth("user", "secret") }
+	header := func(req *http.Request) {
+		req.Header.Set("X-Scenario", "token_expiry")
+		bearer(req)
+	}
+
+	for name, code := range map[string]int{
+		"bearer token":    request("/?scenario=token_expiry&limit=1", "192.0.2.1:1234", bearer),
+		"wrong password":  request("/?scenario=token_expiry&limit=1", "192.0.2.1:1234", wrong),
+		"X-Scenario":      request("/?limit=1", "192.0.2.1:1234", header),
+		"no scenario":     request("/?limit=1", "192.0.2.1:1234", bearer),
+		"valid first use": request("/?scenario=token_expiry&limit=1", "192.0.2.1:1234", valid),
+	} {
+		want := http.StatusUnauthorized
+		if name == "valid first use" {
+			want = http.StatusOK
+		}
+		if code != want {
+			t.Errorf("%s: expected status %d, got %d", name, want, code)
+		}
+	}
+
+	// Valid credentials expire per user, whatever the client address
+	if code := request("/?scenario=token_expiry&limit=1", "192.0.2.2:1234", valid); code != http.StatusOK {
+		t.Errorf("second use: expected status 200, got %d", code)
+	}
+	if code := request("/?scenario=token_expiry&limit=1", "192.0.2.3:1234", valid); code != http.StatusUnauthorized {
+		t.Errorf("expired: expected status 401, got %d", code)
+	}
+	scenarioManager.ResetTokenExpiry()
+	if code := request("/?scenario=token_expiry&limit=1", "192.0.2.1:1234", valid); code != http.StatusOK {
+		t.Errorf("after reset: expected status 200, got %d", code)
+	}
+}
+
+// TestTokenSessionLimits checks that sessions and expired tokens are capped
+func TestTokenSessionLimits(t *testing.T) {
+	sm := &ScenarioManager{}
+	for i := 0; i < maxTokenSessions+10; i++ {
+		sm.UseToken("token_expiry", fmt.Sprintf("client-%d", i), "token", 2)
+	}
+	if len(sm.tokenSessions) > maxTokenSessions {
+		t.Errorf("Expected at most %d sessions, got %d", maxTokenSessions, len(sm.tokenSessions))
+	}
+
+	for i := 0; i < maxExpiredTokens+10; i++ {
+		sm.UseToken("token_expiry", "client", fmt.Sprintf("token-%d", i), 2)
+	}
+	session := sm.tokenSessions["token_expiry\x00client"]
+	if len(session.expired) != maxExpiredTokens {
+		t.Fatalf("Expected %d expired tokens, got %d", maxExpiredTokens, len(session.expired))
+	}
+	// The most recently replaced token stays rejected, the oldest is forgotten
+	if sm.UseToken("token_expiry", "client", fmt.Sprintf("token-%d", maxExpiredTokens+8), 2) {
+		t.Error("Expected a recently replaced token to stay expired")
+	}
+	if !sm.UseToken("token_expiry", "client", "token-0", 2) {
+		t.Error("Expected the oldest expired token to be forgotten")
+	}
+}
+
+// TestTokenExpiryOtherScenarios checks that requests without a
+// token_expiry_after scenario still use the -auth credentials
+func TestTokenExpiryOtherScenarios(t *testing.T) {
+	originalAuth := *enableAuth
+	*enableAuth = true
+	defer func() { *enableAuth = originalAuth }()
+
+	originalManager := scenarioManager
+	defer func() { scenarioManager = originalManager }()
+	scenarioManager = &ScenarioManager{
+		scenarios: map[string]*Scenario{"peak_hours": {ScenarioType: "peak_hours", BaseDelay: "1ms"}},
+		validator: NewScenarioValidator(),
+	}
+
+	handler := basicAuthMiddleware(PaginatedPayloadHandler)
+	for _, target := range []string{"/?limit=1", "/?scenario=peak_hours&limit=1"} {
+		req := httptest.NewRequest("GET", target, nil)
+		req.Header.Set("Authorization", "Bearer token")
+		w := httptest.NewRecorder()
+		handler(w, req)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("%s: expected status 401, got %d", target, w.Code)
+		}
+	}
+}
+
+func TestParseTokenExpiryAfter(t *testing.T) {
+	if after, err := parseTokenExpiryAfter(float64(5)); err != nil || after != 5 {
+		t.Errorf("expected 5, got %d (%v)", after, err)
+	}
+	for _, value := range []interface{}{float64(0), float64(-1), 1.5, "5", nil} {
+		if _, err := parseTokenExpiryAfter(value); err == nil {
+			t.Errorf("expected an error for %v", value)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestTransferModeMiddleware checks that the transfer parameter forces
+// chunked encoding or a Content-Length regardless of the payload size
+func TestTransferModeMiddleware(t *testing.T) {
+	originalAuth := *enableAuth
+	*enableAuth = false
+	defer func() { *enableAuth = originalAuth }()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/rest_payload", transferModeMiddleware("/rest_payload", http10Middleware("/rest_payload", RestPayloadHandler)))
+	mux.HandleFunc("/paginated_payload", transferModeMiddleware("/paginated_payload", http10Middleware("/paginated_payload", PaginatedPayloadHandler)))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	get := func(url string) (*http.Response, []byte) {
+		t.Helper()
+		resp, err := http.Get(server.URL + url)
+		if err != nil {
+			t.Fatalf("%s: request failed: %v", url, err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("%s: failed to read body: %v", url, err)
+		}
+		return resp, body
+	}
+	isChunked := func(resp *http.Response) bool {
+		return len(resp.TransferEncoding) == 1 && resp.TransferEncoding[0] == "chunked"
+	}
+
+	// A small response gets a Content-Length by default, a large one is chunked
+	if resp, body := get("/rest_payload?count=1"); isChunked(resp) || resp.ContentLength != int64(len(body)) {
+		t.Errorf("Expected Content-Length %d by default, got %d (%v)", len(body), resp.ContentLength, resp.TransferEncoding)
+	}
+
+	for _, url := range []string{"/rest_payload?count=1&transfer=chunked", "/paginated_payload?limit=1&transfer=chunked"} {
+		resp, body := get(url)
+		if resp.StatusCode != http.StatusOK || len(body) == 0 {
+			t.Fatalf("%s: expected status 200 with a body, got %d: %s", url, resp.StatusCode, body)
+		}
+		if !isChunked(resp) || resp.ContentLength != -1 {
+			t.Errorf("%s: expected chunked encoding, got %v with Content-Length %d", url, resp.TransferEncoding, resp.ContentLength)
+		}
+	}
+
+	for _, url := range []string{"/rest_payload?count=2000&transfer=length", "/paginated_payload?limit=500&transfer=length"} {
+		resp, body := get(url)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("%s: expected status 200, got %d: %s", url, resp.StatusCode, body)
+		}
+		if isChunked(resp) || resp.ContentLength != int64(len(body)) {
+			t.Errorf("%s: expected Content-Length %d, got %d (%v)", url, len(body), resp.ContentLength, resp.TransferEncoding)
+		}
+		if resp.Close {
+			t.Errorf("%s: expected the connection to stay open", url)
+		}
+	}
+
+	for _, query := range []string{"transfer=gzip", "transfer=chunked&proto=1.0"} {
+		if resp, _ := get("/rest_payload?count=1&" + query); resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", query, resp.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"text/template"
+)
+
+// Limits of the value_template parameter. The output limit applies to the
+// sample rendered during validation, so a template cannot pad every value
+// to an arbitrary length.
+const (
+	maxValueTemplateLength = 1024
+	maxValueTemplateOutput = 4096
+)
+
+// valueTemplateData is the data available to value templates
+type valueTemplateData struct {
+	ID    int // Item ID
+	Index int // Position of the item in the data set, starting at 0
+}
+
+// getValueTemplate parses the value_template query parameter, a
+// text/template such as "User-{{.ID}}" that replaces the value of every item.
+// The template is rendered once for a sample item, so unknown fields and
+// other execution errors are reported here rather than mid-response.
+// It returns nil if the parameter is not set.
+func getValueTemplate(r *http.Request) (*template.Template, error) {
+	text := r.URL.Query().Get("value_template")
+	if text == "" {
+		return nil, nil
+	}
+	if len(text) > maxValueTemplateLength {
+		return nil, fmt.Errorf("value_template must not be longer than %d bytes", maxValueTemplateLength)
+	}
+
+	tmpl, err := template.New("value_template").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("value_template does not parse: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, valueTemplateData{ID: 1}); err != nil {
+		return nil, fmt.Errorf("value_template failed to render: %v", err)
+	}
+	if buf.Len() > maxValueTemplateOutput {
+		return nil, fmt.Errorf("value_template must not render more than %d bytes", maxValueTemplateOutput)
+	}
+	return tmpl, nil
+}
+
+// renderValueTemplate renders the value of the item with the given ID and
+// position. The template has been validated by getValueTemplate, so errors
+// are not expected; the output written up to an error is used as is.
+func renderValueTemplate(tmpl *template.Template, id, index int) string {
+	var buf bytes.Buffer
+	_ = tmpl.Execute(&buf, valueTemplateData{ID: id, Index: index})
+	return buf.String()
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestValueTemplate(t *testing.T) {
+	*enableAuth = false
+	const tmpl = "User-{{.ID}} #{{.Index}}"
+	query := "value_template=" + url.QueryEscape(tmpl)
+
+	get := func(handler http.HandlerFunc, target string) *httptest.ResponseRecorder {
+		t.Helper()
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest("GET", target, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected status 200, got %d: %s", target, w.Code, w.Body.String())
+		}
+		return w
+	}
+
+	// Streaming items, in plain and ServiceNow mode
+	for _, servicenow := range []string{"false", "true"} {
+		w := get(StreamingPayloadHandler, "/stream_payload?count=5&delay=0&id_start=10&servicenow="+servicenow+"&"+query)
+		var items []StreamItem
+		if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil {
+			t.Fatalf("failed to parse streaming response: %v", err)
+		}
+		for i, item := range items {
+			if want := fmt.Sprintf("User-%d #%d", 10+i, i); item.Value != want {
+				t.Errorf("stream servicenow=%s item %d: expected value %q, got %q", servicenow, i, want, item.Value)
+			}
+		}
+		if !strings.Contains(w.Header().Get(effectiveParamsHeader), "value_template=") {
+			t.Errorf("expected value_template in %s", w.Header().Get(effectiveParamsHeader))
+		}
+	}
+
+	// Paginated items use their position in the data set
+	w := get(PaginatedPayloadHandler, "/paginated_payload?limit=3&offset=4&"+query)
+	var page PaginatedResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+		t.Fatalf("failed to parse paginated response: %v", err)
+	}
+	for i, item := range page.Result {
+		if want := fmt.Sprintf("User-%d #%d", 5+i, 4+i); item.Value != want {
+			t.Errorf("paginated item %d: expected value %q, got %q", i, want, item.Value)
+		}
+	}
+
+	// Rest items get the template as their name, JSON-escaped, compact and pretty
+	for _, extra := range []string{"", "&pretty=true"} {
+		w = get(RestPayloadHandler, `/rest_payload?count=3&value_template=`+url.QueryEscape(`"{{.ID}}"`)+extra)
+		var restItems []Item
+		if err := json.Unmarshal(w.Body.Bytes(), &restItems); err != nil {
+			t.Fatalf("failed to parse rest response: %v", err)
+		}
+		for i, item := range restItems {
+			if want := fmt.Sprintf(`"%d"`, i+1); item.Name != want {
+				t.Errorf("rest%s item %d: expected name %q, got %q", extra, i, want, item.Name)
+			}
+		}
+	}
+}
+
+func TestValueTemplateInvalid(t *testing.T) {
+	*enableAuth = false
+	invalid := []string{
+		"User-{{.ID",              // Does not parse
+		"User-{{.Name}}",          // Unknown field
+		`{{printf "%5000d" .ID}}`, // Renders too much
+		strings.Repeat("x", 1025), // Too long
+	}
+	for _, tmpl := range invalid {
+		for _, handler := range []http.HandlerFunc{RestPayloadHandler, StreamingPayloadHandler, PaginatedPayloadHandler} {
+			w := httptest.NewRecorder()
+			handler(w, httptest.NewRequest("GET", "/?count=1&delay=0&value_template="+url.QueryEscape(tmpl), nil))
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("%.20q: expected status 400, got %d", tmpl, w.Code)
+			}
+			if !strings.Contains(w.Body.String(), "value_template") {
+				t.Errorf("%.20q: expected the error to name value_template, got %q", tmpl, w.Body.String())
+			}
+		}
+	}
+}